@@ -1,14 +1,23 @@
 package app
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	osexec "os/exec"
 	"path/filepath"
+	goruntime "runtime"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"kodkafa/internal/app/dto"
 	"kodkafa/internal/app/usecases"
 	"kodkafa/internal/build"
+	"kodkafa/internal/domain/ports"
 	"kodkafa/internal/infra/exec"
+	"kodkafa/internal/infra/registry"
 	"kodkafa/internal/infra/repo"
 	"kodkafa/internal/infra/runtime"
 	"kodkafa/internal/infra/store"
@@ -19,48 +28,140 @@ import (
 
 // Run initializes dependencies and starts the TUI or executes CLI commands.
 func Run() error {
-	home, err := os.UserHomeDir()
+	// Sandboxed and resource-limited plugin runs re-enter the binary before anything touches disk
+	if len(os.Args) > 1 && os.Args[1] == exec.SandboxInitArg {
+		return exec.SandboxInit(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == exec.LimitInitArg {
+		return exec.LimitInit(os.Args[2:])
+	}
+
+	// Global flags come before the command: kod [--home <dir>] [--profile <name>] <command>
+	fs := flag.NewFlagSet("kod", flag.ContinueOnError)
+	homeFlag := fs.String("home", "", "KODKAFA home directory (default $KODKAFA_HOME or ~/.kodkafa)")
+	profileFlag := fs.String("profile", "", "profile to use (default $KODKAFA_PROFILE or the active profile)")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args := fs.Args()
+
+	home, err := resolveHome(*homeFlag)
 	if err != nil {
-		return fmt.Errorf("could not determine home directory: %w", err)
+		return err
+	}
+	profileStore := store.NewProfileStore(home)
+	profile := *profileFlag
+	if profile == "" {
+		profile = os.Getenv("KODKAFA_PROFILE")
+	}
+	if profile == "" {
+		if profile, err = profileStore.Active(); err != nil {
+			return err
+		}
+	}
+	baseDir, err := profileStore.Dir(profile)
+	if err != nil {
+		return err
 	}
-	baseDir := filepath.Join(home, ".kodkafa")
 
 	// 1. Initialize Essential Infrastructure
 	configStore := store.NewConfigStore(baseDir)
-	initUC := usecases.NewInitLayoutUseCase(baseDir, "default_config.json", configStore)
+	initUC := usecases.NewInitLayoutUseCase(baseDir, configStore, store.NewMigrator(baseDir))
+
+	getConfigUC := usecases.NewGetConfigUseCase(configStore)
+	listConfigUC := usecases.NewListConfigUseCase(configStore)
+	setConfigUC := usecases.NewSetConfigUseCase(configStore)
+	checkConfigUC := usecases.NewCheckConfigUseCase(configStore)
 
-	// Always ensure layout on start
+	// Always ensure layout on start; `kod config` still runs when that fails
+	// so an invalid config.json can be repaired.
+	isConfig := len(args) > 0 && args[0] == "config"
 	if err := initUC.Execute(); err != nil {
-		return fmt.Errorf("failed to initialize layout: %w", err)
+		if !isConfig {
+			return fmt.Errorf("failed to initialize layout: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if isConfig {
+		return configCommand(getConfigUC, listConfigUC, setConfigUC, checkConfigUC, args[1:])
 	}
 
-	pluginRepo := repo.NewPluginRepository(baseDir)
-	usageStore := store.NewUsageStore(baseDir)
-	stateStore := store.NewStateStore(baseDir)
+	cfg, err := configStore.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	pluginRepo := repo.NewPluginRepository(baseDir, configStore)
+	stateStore, usageStore, closeStores, err := openStores(baseDir, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStores()
 	runner := exec.NewProcessRunner(baseDir)
 	installer := runtime.NewFSInstaller(baseDir, configStore)
+	registryIndex := registry.NewIndexRegistry(baseDir)
+
+	// Inside a repository with a .kodkafa.yml, the project's plugins and
+	// their state live under projects/<id> and are listed with the global ones.
+	cwd, _ := os.Getwd()
+	project, err := repo.NewProjectStore().Find(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring project manifest: %v\n", err)
+		project = nil
+	}
+	var projectUC *usecases.SyncProjectUseCase
+	if project != nil {
+		projectDir := filepath.Join(baseDir, "projects", project.ID)
+		projectRepo := repo.NewPluginRepository(projectDir, configStore)
+		projectState, projectUsage, closeProjectStores, err := openStores(projectDir, cfg)
+		if err != nil {
+			return err
+		}
+		defer closeProjectStores()
+		projectUC = usecases.NewSyncProjectUseCase(project, projectRepo,
+			usecases.NewAddPluginUseCase(projectRepo, projectState, configStore, registryIndex),
+			usecases.NewUpdatePluginUseCase(projectRepo, projectState, configStore, installer),
+			usecases.NewDeletePluginUseCase(projectRepo, projectState, projectUsage, installer),
+			usecases.NewLoadPluginDepsUseCase(projectRepo, installer))
+
+		pluginRepo = repo.NewProjectPluginRepository(pluginRepo, projectRepo, project.Name)
+		stateStore = store.NewProjectStateStore(stateStore, projectState, projectRepo.Exists)
+		usageStore = store.NewProjectUsageStore(usageStore, projectUsage, projectRepo.Exists)
+	} else {
+		projectUC = usecases.NewSyncProjectUseCase(nil, pluginRepo, nil, nil, nil, nil)
+	}
 
 	// Initialize Use Cases
-	listUC := usecases.NewListPluginsUseCase(pluginRepo, usageStore, configStore, stateStore)
-	addUC := usecases.NewAddPluginUseCase(pluginRepo, stateStore, configStore)
+	listUC := usecases.NewListPluginsUseCase(pluginRepo, usageStore, configStore, stateStore, project)
+	addUC := usecases.NewAddPluginUseCase(pluginRepo, stateStore, configStore, registryIndex)
 	deleteUC := usecases.NewDeletePluginUseCase(pluginRepo, stateStore, usageStore, installer)
 	loadUC := usecases.NewLoadPluginDepsUseCase(pluginRepo, installer)
 	infoUC := usecases.NewGetPluginInfoUseCase(pluginRepo, stateStore)
 	runUC := usecases.NewRunPluginUseCase(pluginRepo, stateStore, usageStore, configStore, runner)
+	updateUC := usecases.NewUpdatePluginUseCase(pluginRepo, stateStore, configStore, installer)
+	rollbackUC := usecases.NewRollbackPluginUseCase(pluginRepo, stateStore, installer)
+	verifyUC := usecases.NewVerifyPluginUseCase(pluginRepo)
+	searchUC := usecases.NewSearchPluginsUseCase(registryIndex, configStore, pluginRepo)
+	lockStore := store.NewLockfileStore()
+	exportUC := usecases.NewExportPluginsUseCase(pluginRepo, lockStore)
+	syncUC := usecases.NewSyncPluginsUseCase(pluginRepo, lockStore, addUC, updateUC, deleteUC, loadUC)
+	listProfilesUC := usecases.NewListProfilesUseCase(profileStore, profile)
+	createProfileUC := usecases.NewCreateProfileUseCase(profileStore)
+	switchProfileUC := usecases.NewSwitchProfileUseCase(profileStore)
+	tagUC := usecases.NewTagPluginUseCase(pluginRepo, stateStore)
+	pinUC := usecases.NewPinPluginUseCase(pluginRepo, usageStore)
+	historyUC := usecases.NewListHistoryUseCase(pluginRepo, stateStore)
+	statsUC := usecases.NewGetStatsUseCase(pluginRepo, stateStore)
 
 	// 2. Dispatch CLI or TUI
-	if len(os.Args) > 1 {
-		return handleCLI(os.Args[1:], initUC, addUC, deleteUC, loadUC, infoUC, runUC, listUC)
+	if len(args) > 0 {
+		return handleCLI(args, initUC, addUC, deleteUC, loadUC, infoUC, runUC, listUC, updateUC, rollbackUC, verifyUC, searchUC, exportUC, syncUC, projectUC, listProfilesUC, createProfileUC, switchProfileUC, listConfigUC, setConfigUC, tagUC, pinUC, historyUC, statsUC)
 	}
 
 	// 3. Start TUI
-	cfg, err := configStore.Read()
-	showSplash := true
-	if err == nil && cfg != nil {
-		showSplash = cfg.Splash
-	}
-
-	rootModel := ui.NewModel(listUC, addUC, deleteUC, loadUC, infoUC, runUC, initUC, showSplash)
+	rootModel := ui.NewModel(listUC, addUC, deleteUC, loadUC, infoUC, runUC, initUC, searchUC, listConfigUC, setConfigUC, pinUC, cfg.Splash)
 	p := tea_pkg.NewProgram(rootModel, tea_pkg.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		return fmt.Errorf("TUI error: %w", err)
@@ -68,7 +169,7 @@ func Run() error {
 	return nil
 }
 
-func handleCLI(args []string, initUC *usecases.InitLayoutUseCase, addUC *usecases.AddPluginUseCase, deleteUC *usecases.DeletePluginUseCase, loadUC *usecases.LoadPluginDepsUseCase, infoUC *usecases.GetPluginInfoUseCase, runUC *usecases.RunPluginUseCase, listUC *usecases.ListPluginsUseCase) error {
+func handleCLI(args []string, initUC *usecases.InitLayoutUseCase, addUC *usecases.AddPluginUseCase, deleteUC *usecases.DeletePluginUseCase, loadUC *usecases.LoadPluginDepsUseCase, infoUC *usecases.GetPluginInfoUseCase, runUC *usecases.RunPluginUseCase, listUC *usecases.ListPluginsUseCase, updateUC *usecases.UpdatePluginUseCase, rollbackUC *usecases.RollbackPluginUseCase, verifyUC *usecases.VerifyPluginUseCase, searchUC *usecases.SearchPluginsUseCase, exportUC *usecases.ExportPluginsUseCase, syncUC *usecases.SyncPluginsUseCase, projectUC *usecases.SyncProjectUseCase, listProfilesUC *usecases.ListProfilesUseCase, createProfileUC *usecases.CreateProfileUseCase, switchProfileUC *usecases.SwitchProfileUseCase, listConfigUC *usecases.ListConfigUseCase, setConfigUC *usecases.SetConfigUseCase, tagUC *usecases.TagPluginUseCase, pinUC *usecases.PinPluginUseCase, historyUC *usecases.ListHistoryUseCase, statsUC *usecases.GetStatsUseCase) error {
 	cmd := args[0]
 	switch cmd {
 	case "init":
@@ -76,15 +177,42 @@ func handleCLI(args []string, initUC *usecases.InitLayoutUseCase, addUC *usecase
 			return fmt.Errorf("init error: %w", err)
 		}
 		fmt.Println("System initialized successfully.")
-	case "add", "a":
-		if len(args) < 2 {
-			return fmt.Errorf("Usage: kodkafa add <path|url>")
+	case "add", "a", "dev":
+		fs := flag.NewFlagSet("add", flag.ContinueOnError)
+		allowUntrusted := fs.Bool("allow-untrusted", false, "install from a remote source outside trusted_domains")
+		subdir := fs.String("subdir", "", "plugin directory inside the source")
+		link := fs.Bool("link", cmd == "dev", "register a local directory by reference instead of copying it")
+		rest, err := parseFlags(fs, args[1:])
+		if err != nil || len(rest) < 1 {
+			if cmd == "dev" {
+				return fmt.Errorf("Usage: kodkafa dev <path> [--subdir <path>]")
+			}
+			return fmt.Errorf("Usage: kodkafa add <path|url[@ref]|name[@version]> [--subdir <path>] [--link] [--allow-untrusted]")
+		}
+		input := usecases.AddPluginInput{Source: rest[0], Subdir: *subdir, Link: *link, AllowUntrusted: *allowUntrusted}
+		input.Approve = func(plugin dto.PluginInfo) bool {
+			return confirmPermissions(plugin, "Grant these permissions and install?")
+		}
+
+		res, err := addUC.Execute(input)
+		if errors.Is(err, usecases.ErrUntrustedSource) {
+			return fmt.Errorf("add error: %w (use --allow-untrusted to install anyway)", err)
 		}
-		res, err := addUC.Execute(usecases.AddPluginInput{Source: args[1]})
 		if err != nil {
 			return fmt.Errorf("add error: %w", err)
 		}
-		fmt.Printf("Plugin added: %s\n", res.Plugin.Name)
+		if res.ResolvedSource != "" {
+			fmt.Printf("Resolved %s from %s: %s\n", rest[0], res.Registry, res.ResolvedSource)
+		}
+		if res.NeedsConsent {
+			fmt.Println("Operation cancelled.")
+			return nil
+		}
+		if *link {
+			fmt.Printf("Plugin linked: %s -> %s\n", res.Plugin.Name, res.Plugin.Source)
+		} else {
+			fmt.Printf("Plugin added: %s\n", res.Plugin.Name)
+		}
 
 		// Auto-load dependencies
 		fmt.Printf("loading dependencies for %s...\n", res.Plugin.Name)
@@ -94,6 +222,32 @@ func handleCLI(args []string, initUC *usecases.InitLayoutUseCase, addUC *usecase
 		} else {
 			fmt.Printf("Dependencies loaded: %s\n", loadRes.Status)
 		}
+	case "update", "upgrade":
+		return updateCommand(updateUC, args[1:])
+	case "export":
+		return exportCommand(exportUC, args[1:])
+	case "import", "sync":
+		return syncCommand(syncUC, args[1:])
+	case "project":
+		return projectCommand(projectUC, args[1:])
+	case "profile":
+		return profileCommand(listProfilesUC, createProfileUC, switchProfileUC, args[1:])
+	case "search":
+		return searchCommand(searchUC, args[1:])
+	case "list", "ls":
+		return listCommand(listUC, args[1:])
+	case "tag":
+		return tagCommand(tagUC, args[1:])
+	case "history":
+		return historyCommand(historyUC, args[1:])
+	case "stats":
+		return statsCommand(statsUC, args[1:])
+	case "pin", "unpin":
+		return pinCommand(pinUC, cmd == "unpin", args[1:])
+	case "verify":
+		return verifyCommand(verifyUC, args[1:])
+	case "rollback":
+		return rollbackCommand(rollbackUC, args[1:])
 	case "del", "d":
 		if len(args) < 2 {
 			return fmt.Errorf("Usage: kodkafa del <name>")
@@ -133,21 +287,14 @@ func handleCLI(args []string, initUC *usecases.InitLayoutUseCase, addUC *usecase
 		name := args[1]
 
 		// Launch TUI for run
-		rootModel := ui.NewModel(listUC, addUC, deleteUC, loadUC, infoUC, runUC, initUC, false)
+		rootModel := ui.NewModel(listUC, addUC, deleteUC, loadUC, infoUC, runUC, initUC, searchUC, listConfigUC, setConfigUC, pinUC, false)
 		rootModel.StartRun(name)
 		p := tea_pkg.NewProgram(rootModel, tea_pkg.WithAltScreen())
 		if _, err := p.Run(); err != nil {
 			return fmt.Errorf("TUI run error: %w", err)
 		}
 	case "info", "i":
-		if len(args) < 2 {
-			return fmt.Errorf("Usage: kodkafa info <name>")
-		}
-		res, err := infoUC.Execute(usecases.GetPluginInfoInput{PluginName: args[1]})
-		if err != nil {
-			return fmt.Errorf("info error: %w", err)
-		}
-		fmt.Printf("Plugin: %s\nInterpreter: %s\nDescription: %s\n", res.Plugin.Name, res.Plugin.Interpreter, res.Plugin.Description)
+		return infoCommand(infoUC, args[1:])
 	case "load", "l":
 		if len(args) < 2 {
 			return fmt.Errorf("Usage: kodkafa load <name>")
@@ -167,3 +314,846 @@ func handleCLI(args []string, initUC *usecases.InitLayoutUseCase, addUC *usecase
 	}
 	return nil
 }
+
+// confirmPermissions shows the permissions a plugin asks for and asks the
+// user to approve them.
+func confirmPermissions(plugin dto.PluginInfo, question string) bool {
+	fmt.Printf("PLUGIN PERMISSIONS: %s\n", plugin.Name)
+	for _, line := range plugin.Permissions.Describe() {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Printf("%s (y/N): ", question)
+	var confirm string
+	fmt.Scanln(&confirm)
+	return strings.ToLower(confirm) == "y"
+}
+
+// updateCommand implements `kod update <name> [--ref <ref>]` and `kod update --all`.
+func updateCommand(updateUC *usecases.UpdatePluginUseCase, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	all := fs.Bool("all", false, "update every installed plugin")
+	ref := fs.String("ref", "", "switch to another tag, branch or commit")
+	rest, err := parseFlags(fs, args)
+	if err != nil || (*all == (len(rest) > 0)) || (*all && *ref != "") {
+		return fmt.Errorf("Usage: kodkafa update <name> [--ref <ref>] | kodkafa update --all")
+	}
+
+	if *all {
+		results, err := updateUC.ExecuteAll()
+		if err != nil {
+			return fmt.Errorf("update error: %w", err)
+		}
+		printUpdateTable(results)
+		return nil
+	}
+
+	input := usecases.UpdatePluginInput{PluginName: rest[0], Ref: *ref}
+	res, err := updateUC.Execute(input)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	if res.NeedsConsent {
+		printUpdateSummary(res)
+		if !confirmPermissions(res.Plugin, "Grant these permissions and update?") {
+			fmt.Println("Operation cancelled.")
+			return nil
+		}
+		input.Approved = true
+		input.Granted = res.Plugin.Permissions
+		res, err = updateUC.Execute(input)
+		if err != nil {
+			return fmt.Errorf("update error: %w", err)
+		}
+		if res.NeedsConsent {
+			return fmt.Errorf("update error: plugin permissions changed, please try again")
+		}
+	}
+	printUpdateSummary(res)
+	return nil
+}
+
+// rollbackCommand implements `kod rollback <name> [version]`.
+func rollbackCommand(rollbackUC *usecases.RollbackPluginUseCase, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("Usage: kodkafa rollback <name> [version]")
+	}
+	input := usecases.RollbackPluginInput{PluginName: args[0]}
+	if len(args) == 2 {
+		input.Version = args[1]
+	}
+
+	res, err := rollbackUC.Execute(input)
+	if err != nil {
+		return fmt.Errorf("rollback error: %w", err)
+	}
+	if res.NeedsConsent {
+		if !confirmPermissions(res.Plugin, "Grant these permissions and roll back?") {
+			fmt.Println("Operation cancelled.")
+			return nil
+		}
+		input.Approved = true
+		input.Granted = res.Plugin.Permissions
+		res, err = rollbackUC.Execute(input)
+		if err != nil {
+			return fmt.Errorf("rollback error: %w", err)
+		}
+	}
+	fmt.Printf("%s: %s\n", res.PluginName, res.Message)
+	if res.DepsReinstalled {
+		fmt.Println("  dependencies reinstalled")
+	}
+	return nil
+}
+
+// defaultLockfile is the lockfile used by export and import when no path is given.
+const defaultLockfile = "kod.lock"
+
+// exportCommand implements `kod export [path]`.
+func exportCommand(exportUC *usecases.ExportPluginsUseCase, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("Usage: kodkafa export [path]")
+	}
+	path := defaultLockfile
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	res, err := exportUC.Execute(usecases.ExportPluginsInput{Path: path})
+	if err != nil {
+		return fmt.Errorf("export error: %w", err)
+	}
+	for _, skipped := range res.Skipped {
+		fmt.Printf("Skipped %s\n", skipped)
+	}
+	fmt.Printf("Exported %d plugin(s) to %s\n", len(res.Exported), res.Path)
+	return nil
+}
+
+// syncCommand implements `kod import [path] [--check]`, also available as
+// `kod sync`.
+func syncCommand(syncUC *usecases.SyncPluginsUseCase, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	check := fs.Bool("check", false, "only report plugins that differ from the lockfile")
+	allowUntrusted := fs.Bool("allow-untrusted", false, "install from remote sources outside trusted_domains")
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) > 1 {
+		return fmt.Errorf("Usage: kodkafa import [path] [--check] [--allow-untrusted]")
+	}
+	path := defaultLockfile
+	if len(rest) == 1 {
+		path = rest[0]
+	}
+
+	res, err := syncUC.Execute(usecases.SyncPluginsInput{
+		Path:           path,
+		Check:          *check,
+		AllowUntrusted: *allowUntrusted,
+		Approve: func(plugin dto.PluginInfo) bool {
+			return confirmPermissions(plugin, "Grant these permissions?")
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("import error: %w", err)
+	}
+
+	failed := printSyncActions(res.Actions)
+	switch {
+	case *check && res.Drift:
+		return fmt.Errorf("installed plugins differ from %s", path)
+	case failed > 0:
+		return fmt.Errorf("import error: %d plugin(s) failed", failed)
+	case !res.Drift:
+		fmt.Printf("Plugins match %s\n", path)
+	}
+	return nil
+}
+
+// profileCommand implements `kod profile [list|create <name>|switch <name>]`.
+func profileCommand(listUC *usecases.ListProfilesUseCase, createUC *usecases.CreateProfileUseCase, switchUC *usecases.SwitchProfileUseCase, args []string) error {
+	usage := fmt.Errorf("Usage: kodkafa profile [list | create <name> [--switch] | switch <name>]")
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+
+	switch sub {
+	case "list", "ls":
+		if len(args) > 0 {
+			return usage
+		}
+		res, err := listUC.Execute()
+		if err != nil {
+			return fmt.Errorf("profile error: %w", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  PROFILE\tDIR")
+		for _, p := range res.Profiles {
+			marker := "  "
+			if p.Active {
+				marker = "* "
+			}
+			name := p.Name
+			if p.Current && !p.Active {
+				name += " (in use)"
+			}
+			fmt.Fprintf(w, "%s%s\t%s\n", marker, name, p.Dir)
+		}
+		w.Flush()
+
+	case "create":
+		fs := flag.NewFlagSet("profile create", flag.ContinueOnError)
+		switchTo := fs.Bool("switch", false, "make the new profile the active one")
+		rest, err := parseFlags(fs, args)
+		if err != nil || len(rest) != 1 {
+			return usage
+		}
+		res, err := createUC.Execute(usecases.CreateProfileInput{Name: rest[0], Switch: *switchTo})
+		if err != nil {
+			return fmt.Errorf("profile error: %w", err)
+		}
+		fmt.Printf("Profile created: %s (%s)\n", res.Name, res.Dir)
+		if res.Active {
+			fmt.Printf("Switched to profile %s\n", res.Name)
+		}
+
+	case "switch", "use":
+		if len(args) != 1 {
+			return usage
+		}
+		res, err := switchUC.Execute(usecases.SwitchProfileInput{Name: args[0]})
+		if err != nil {
+			return fmt.Errorf("profile error: %w", err)
+		}
+		fmt.Printf("Switched to profile %s (%s)\n", res.Name, res.Dir)
+
+	default:
+		return usage
+	}
+	return nil
+}
+
+// configCommand implements `kod config [list|get|set|unset|edit|path]`.
+func configCommand(getUC *usecases.GetConfigUseCase, listUC *usecases.ListConfigUseCase, setUC *usecases.SetConfigUseCase, checkUC *usecases.CheckConfigUseCase, args []string) error {
+	usage := fmt.Errorf("Usage: kodkafa config [list | get <key> | set <key> <value> [--add | --remove] | unset <key> | edit | path]")
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+
+	switch sub {
+	case "list", "ls":
+		if len(args) > 0 {
+			return usage
+		}
+		res, err := listUC.Execute()
+		if err != nil {
+			return fmt.Errorf("config error: %w", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  KEY\tVALUE")
+		for _, s := range res.Settings {
+			marker := "  "
+			if !s.Default {
+				marker = "* "
+			}
+			fmt.Fprintf(w, "%s%s\t%s\n", marker, s.Key, usecases.FormatConfigValue(s.Value))
+		}
+		w.Flush()
+		fmt.Printf("\n* changed from the default, in %s\n", res.Path)
+
+	case "get":
+		if len(args) != 1 {
+			return usage
+		}
+		res, err := getUC.Execute(usecases.GetConfigInput{Key: args[0]})
+		if err != nil {
+			return fmt.Errorf("config error: %w", err)
+		}
+		fmt.Println(usecases.FormatConfigValue(res.Value))
+
+	case "set":
+		fs := flag.NewFlagSet("config set", flag.ContinueOnError)
+		add := fs.Bool("add", false, "append the value to a list")
+		remove := fs.Bool("remove", false, "remove the value from a list")
+		rest, err := parseFlags(fs, args)
+		if err != nil || len(rest) != 2 || *add && *remove {
+			return usage
+		}
+		op := usecases.ConfigSet
+		switch {
+		case *add:
+			op = usecases.ConfigAdd
+		case *remove:
+			op = usecases.ConfigRemove
+		}
+		res, err := setUC.Execute(usecases.SetConfigInput{Key: rest[0], Value: rest[1], Op: op})
+		if err != nil {
+			return fmt.Errorf("config error: %w", err)
+		}
+		fmt.Printf("%s = %s\n", res.Key, usecases.FormatConfigValue(res.Value))
+
+	case "unset":
+		if len(args) != 1 {
+			return usage
+		}
+		res, err := setUC.Execute(usecases.SetConfigInput{Key: args[0], Op: usecases.ConfigUnset})
+		if err != nil {
+			return fmt.Errorf("config error: %w", err)
+		}
+		if res.Value == nil {
+			fmt.Printf("%s removed\n", res.Key)
+		} else {
+			fmt.Printf("%s reset to %s\n", res.Key, usecases.FormatConfigValue(res.Value))
+		}
+
+	case "path":
+		if len(args) > 0 {
+			return usage
+		}
+		res, _ := checkUC.Execute()
+		fmt.Println(res.Path)
+
+	case "edit":
+		if len(args) > 0 {
+			return usage
+		}
+		return editConfig(checkUC)
+
+	default:
+		return usage
+	}
+	return nil
+}
+
+// editConfig opens config.json in $VISUAL or $EDITOR and checks it when the
+// editor exits, offering to reopen it while it is invalid.
+func editConfig(checkUC *usecases.CheckConfigUseCase) error {
+	res, _ := checkUC.Execute()
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if goruntime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	for {
+		parts := strings.Fields(editor)
+		cmd := osexec.Command(parts[0], append(parts[1:], res.Path)...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("config error: failed to run editor %q: %w", editor, err)
+		}
+
+		res, err := checkUC.Execute()
+		if err == nil {
+			for _, w := range res.Warnings {
+				fmt.Printf("Warning: %s\n", w)
+			}
+			fmt.Println("Config saved.")
+			return nil
+		}
+		fmt.Printf("Config is invalid:\n%v\n", err)
+		fmt.Print("Edit again? (Y/n): ")
+		var again string
+		fmt.Scanln(&again)
+		if strings.ToLower(again) == "n" {
+			return fmt.Errorf("config error: %s is invalid", res.Path)
+		}
+	}
+}
+
+// openStores returns the state and usage stores kept in dir by the
+// configured storage backend, and a func closing them.
+func openStores(dir string, cfg *ports.Config) (ports.StateStore, ports.UsageStore, func(), error) {
+	switch cfg.Storage {
+	case "", ports.StorageJSON:
+		return store.NewStateStore(dir), store.NewUsageStore(dir), func() {}, nil
+	case ports.StorageSQLite:
+		db, err := store.OpenSQLite(dir)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to open storage: %w", err)
+		}
+		return store.NewSQLiteStateStore(db, cfg.HistorySize), store.NewSQLiteUsageStore(db), func() { db.Close() }, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown storage %q in config (use %q or %q)", cfg.Storage, ports.StorageJSON, ports.StorageSQLite)
+	}
+}
+
+// resolveHome returns the KODKAFA home: the --home flag, then $KODKAFA_HOME,
+// then ~/.kodkafa.
+func resolveHome(flagValue string) (string, error) {
+	home := flagValue
+	if home == "" {
+		home = os.Getenv("KODKAFA_HOME")
+	}
+	if home == "" {
+		userHome, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not determine home directory: %w", err)
+		}
+		return filepath.Join(userHome, ".kodkafa"), nil
+	}
+	abs, err := filepath.Abs(home)
+	if err != nil {
+		return "", fmt.Errorf("invalid home directory: %w", err)
+	}
+	return abs, nil
+}
+
+// projectCommand implements `kod project [sync]`: without sync it only
+// shows how the project's plugins compare with its .kodkafa.yml.
+func projectCommand(projectUC *usecases.SyncProjectUseCase, args []string) error {
+	fs := flag.NewFlagSet("project", flag.ContinueOnError)
+	check := fs.Bool("check", false, "only report plugins that differ from .kodkafa.yml")
+	allowUntrusted := fs.Bool("allow-untrusted", false, "install from remote sources outside trusted_domains")
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) > 1 || len(rest) == 1 && rest[0] != "sync" {
+		return fmt.Errorf("Usage: kodkafa project [sync] [--check] [--allow-untrusted]")
+	}
+	apply := len(rest) == 1 && !*check
+
+	res, err := projectUC.Execute(usecases.SyncProjectInput{
+		Check:          !apply,
+		AllowUntrusted: *allowUntrusted,
+		Approve: func(plugin dto.PluginInfo) bool {
+			return confirmPermissions(plugin, "Grant these permissions?")
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("project error: %w", err)
+	}
+
+	failed := printSyncActions(res.Actions)
+	switch {
+	case failed > 0:
+		return fmt.Errorf("project error: %d plugin(s) failed", failed)
+	case res.Drift && *check:
+		return fmt.Errorf("project plugins differ from %s", res.Path)
+	case res.Drift && !apply:
+		fmt.Println("Run 'kod project sync' to apply.")
+	case !res.Drift:
+		fmt.Printf("Plugins match %s\n", res.Path)
+	}
+	return nil
+}
+
+// printSyncActions prints a sync plan or its outcome as a table and returns
+// the number of failed actions.
+func printSyncActions(actions []dto.SyncAction) int {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLUGIN\tACTION\tSTATUS\tDETAILS")
+	failed := 0
+	for _, a := range actions {
+		if a.Status == "failed" {
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.PluginName, a.Action, a.Status, a.Message)
+	}
+	w.Flush()
+	return failed
+}
+
+// infoCommand implements `kod info <name>`.
+func infoCommand(infoUC *usecases.GetPluginInfoUseCase, args []string) error {
+	fs := flag.NewFlagSet("info", flag.ContinueOnError)
+	format := outputFlags(fs)
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) != 1 {
+		return fmt.Errorf("Usage: kodkafa info <name> [--json | --format table|yaml|json]")
+	}
+	out, err := format()
+	if err != nil {
+		return err
+	}
+	res, err := infoUC.Execute(usecases.GetPluginInfoInput{PluginName: rest[0]})
+	if err != nil {
+		return fmt.Errorf("info error: %w", err)
+	}
+	if out != formatTable {
+		return printData(out, res)
+	}
+
+	fmt.Printf("Plugin: %s\nInterpreter: %s\nDescription: %s\n", res.Plugin.Name, res.Plugin.Interpreter, res.Plugin.Description)
+	if res.Plugin.Version != "" {
+		fmt.Printf("Version: %s\n", res.Plugin.Version)
+	}
+	if res.Plugin.Category != "" {
+		fmt.Printf("Category: %s\n", res.Plugin.Category)
+	}
+	if tags := append(append([]string{}, res.Plugin.Tags...), res.State.Tags...); len(tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(tags, ", "))
+	}
+	if in := res.Plugin.Install; in != nil && in.Linked {
+		fmt.Printf("Linked: %s\n", res.Plugin.Source)
+	} else if in != nil {
+		fmt.Printf("Origin: %s\n", in.Origin)
+		if in.Ref != "" {
+			fmt.Printf("Ref: %s\n", in.Ref)
+		}
+		if in.Commit != "" {
+			fmt.Printf("Commit: %s\n", in.Commit)
+		}
+		if in.Subdir != "" {
+			fmt.Printf("Subdir: %s\n", in.Subdir)
+		}
+		if in.Checksum != "" {
+			fmt.Printf("Checksum: %s\n", in.Checksum)
+		}
+		if in.Signer != "" {
+			fmt.Printf("Signed by: %s\n", in.Signer)
+		}
+	}
+	if res.State.RunCount > 0 {
+		fmt.Printf("Runs: %d, last %s\n", res.State.RunCount, res.State.LastExecutedAt.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// listCommand implements `kod list [--tag <tag>] [--category <name>]`.
+func listCommand(listUC *usecases.ListPluginsUseCase, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	tag := fs.String("tag", "", "only plugins with this tag; separate several with commas")
+	category := fs.String("category", "", "only plugins whose category starts with this")
+	format := outputFlags(fs)
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) > 0 {
+		return fmt.Errorf("Usage: kodkafa list [--tag <tag>[,<tag>...]] [--category <name>] [--json | --format table|yaml|json]")
+	}
+	out, err := format()
+	if err != nil {
+		return err
+	}
+
+	// The filters are search tokens, see the dashboard search
+	var query []string
+	for _, t := range strings.Split(*tag, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			query = append(query, "tag:"+t)
+		}
+	}
+	if c := strings.TrimSpace(*category); c != "" {
+		query = append(query, `category:"`+c+`"`)
+	}
+	res, err := listUC.Execute(usecases.ListPluginsInput{Query: strings.Join(query, " "), All: true})
+	if err != nil {
+		return fmt.Errorf("list error: %w", err)
+	}
+	if out != formatTable {
+		// Project plugins first, as in the table
+		return printData(out, append(append([]dto.PluginListItem{}, res.ProjectPlugins...), res.MainPlugins...))
+	}
+	if len(res.ProjectPlugins)+len(res.MainPlugins) == 0 {
+		fmt.Println("No plugins found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tINTERPRETER\tCATEGORY\tTAGS\tDESCRIPTION")
+	row := func(p dto.PluginListItem, note string) {
+		category := p.Category
+		if category == "" {
+			category = "-"
+		}
+		fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\n", p.Name, note, p.Interpreter, category, strings.Join(p.Tags, ","), p.Description)
+	}
+	for _, p := range res.ProjectPlugins {
+		row(p, " (project)")
+	}
+	for _, p := range res.MainPlugins {
+		note := ""
+		if p.Linked {
+			note = " (linked)"
+		}
+		row(p, note)
+	}
+	w.Flush()
+	return nil
+}
+
+// tagCommand implements `kod tag <name> [tag...] [--remove]`.
+func tagCommand(tagUC *usecases.TagPluginUseCase, args []string) error {
+	fs := flag.NewFlagSet("tag", flag.ContinueOnError)
+	remove := fs.Bool("remove", false, "remove the tags instead of adding them")
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) < 1 || (*remove && len(rest) < 2) {
+		return fmt.Errorf("Usage: kodkafa tag <name> [tag...] [--remove]")
+	}
+
+	input := usecases.TagPluginInput{PluginName: rest[0]}
+	if *remove {
+		input.Remove = rest[1:]
+	} else {
+		input.Add = rest[1:]
+	}
+	res, err := tagUC.Execute(input)
+	if err != nil {
+		return fmt.Errorf("tag error: %w", err)
+	}
+	fmt.Printf("Tags of %s:\n", res.PluginName)
+	fmt.Printf("  plugin.yml: %s\n", listOrNone(res.ManifestTags))
+	fmt.Printf("  local:      %s\n", listOrNone(res.LocalTags))
+	return nil
+}
+
+// pinCommand implements `kod pin [<name> [--at <n>] [--remove]]` and
+// `kod unpin <name>`.
+func pinCommand(pinUC *usecases.PinPluginUseCase, unpin bool, args []string) error {
+	fs := flag.NewFlagSet("pin", flag.ContinueOnError)
+	at := fs.Int("at", 0, "position among the pinned plugins, from 1")
+	remove := fs.Bool("remove", unpin, "unpin the plugin")
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) > 1 || ((unpin || *remove || *at != 0) && len(rest) != 1) || (*remove && *at != 0) {
+		if unpin {
+			return fmt.Errorf("Usage: kodkafa unpin <name>")
+		}
+		return fmt.Errorf("Usage: kodkafa pin [<name> [--at <n>] | <name> --remove]")
+	}
+
+	input := usecases.PinPluginInput{Position: *at}
+	if len(rest) == 1 {
+		input.PluginName = rest[0]
+	}
+	if *remove {
+		input.Op = usecases.PinRemove
+	}
+	res, err := pinUC.Execute(input)
+	if err != nil {
+		return fmt.Errorf("pin error: %w", err)
+	}
+	switch {
+	case input.PluginName == "":
+		// Just listing
+	case res.Pinned:
+		fmt.Printf("Pinned %s at position %d\n", res.PluginName, res.Position)
+	default:
+		fmt.Printf("Unpinned %s\n", res.PluginName)
+	}
+	if len(res.Pins) == 0 {
+		fmt.Println("No pinned plugins.")
+		return nil
+	}
+	for i, name := range res.Pins {
+		fmt.Printf("%2d. %s\n", i+1, name)
+	}
+	return nil
+}
+
+// historyCommand implements `kod history [name] [--limit <n>] [--failed]`.
+func historyCommand(historyUC *usecases.ListHistoryUseCase, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "show at most this many runs; 0 for all")
+	failed := fs.Bool("failed", false, "only failed runs")
+	format := outputFlags(fs)
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) > 1 || *limit < 0 {
+		return fmt.Errorf("Usage: kodkafa history [name] [--limit <n>] [--failed] [--json | --format table|yaml|json]")
+	}
+	out, err := format()
+	if err != nil {
+		return err
+	}
+	input := usecases.ListHistoryInput{Limit: *limit, Failed: *failed}
+	if len(rest) == 1 {
+		input.PluginName = rest[0]
+	}
+	res, err := historyUC.Execute(input)
+	if err != nil {
+		return fmt.Errorf("history error: %w", err)
+	}
+	if out != formatTable {
+		return printData(out, res)
+	}
+	if len(res.Runs) == 0 {
+		fmt.Println("No runs recorded.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tPLUGIN\tSTATUS\tEXIT\tDURATION\tARGS")
+	for _, r := range res.Runs {
+		status := r.Status
+		if r.Reason != "" {
+			status += " (" + r.Reason + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", r.Timestamp.Format("2006-01-02 15:04:05"), r.PluginName, status, r.ExitCode, r.Duration.Round(time.Millisecond), r.Args)
+	}
+	w.Flush()
+	return nil
+}
+
+// statsCommand implements `kod stats [name]`.
+func statsCommand(statsUC *usecases.GetStatsUseCase, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	format := outputFlags(fs)
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) > 1 {
+		return fmt.Errorf("Usage: kodkafa stats [name] [--json | --format table|yaml|json]")
+	}
+	out, err := format()
+	if err != nil {
+		return err
+	}
+	input := usecases.GetStatsInput{}
+	if len(rest) == 1 {
+		input.PluginName = rest[0]
+	}
+	res, err := statsUC.Execute(input)
+	if err != nil {
+		return fmt.Errorf("stats error: %w", err)
+	}
+	if out != formatTable {
+		return printData(out, res)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLUGIN\tRUNS\tFAILED\tAVG DURATION\tLAST RUN")
+	for _, p := range res.PluginStats {
+		lastRun := "-"
+		if !p.LastRun.IsZero() {
+			lastRun = p.LastRun.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d/%d\t%s\t%s\n", p.Name, p.RunCount, p.Failures, p.HistoryRuns, p.AvgDuration.Round(time.Millisecond), lastRun)
+	}
+	w.Flush()
+	fmt.Printf("\n%d plugin(s), %d run(s); %d of the %d kept in history failed\n", res.Plugins, res.TotalRuns, res.Failures, res.HistoryRuns)
+	return nil
+}
+
+// listOrNone joins items with commas, or returns "none".
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
+
+// searchCommand implements `kod search [term]`.
+func searchCommand(searchUC *usecases.SearchPluginsUseCase, args []string) error {
+	res, err := searchUC.Execute(usecases.SearchPluginsInput{Query: strings.Join(args, " ")})
+	if err != nil {
+		return fmt.Errorf("search error: %w", err)
+	}
+	for _, msg := range res.Errors {
+		fmt.Printf("Warning: %s\n", msg)
+	}
+	if len(res.Plugins) == 0 {
+		fmt.Println("No plugins found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tTAGS\tDESCRIPTION")
+	for _, p := range res.Plugins {
+		version := p.Latest
+		if version == "" {
+			version = "-"
+		}
+		name := p.Name
+		if p.Installed {
+			name += " (installed)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, version, strings.Join(p.Tags, ","), p.Description)
+	}
+	w.Flush()
+	return nil
+}
+
+// verifyCommand implements `kod verify [name]` and `kod verify --hash <path>`.
+func verifyCommand(verifyUC *usecases.VerifyPluginUseCase, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	hash := fs.String("hash", "", "print the content hash of a plugin directory")
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) > 1 || (*hash != "" && len(rest) > 0) {
+		return fmt.Errorf("Usage: kodkafa verify [name] | kodkafa verify --hash <path>")
+	}
+
+	if *hash != "" {
+		sum, err := verifyUC.Checksum(*hash)
+		if err != nil {
+			return fmt.Errorf("verify error: %w", err)
+		}
+		fmt.Println(sum)
+		return nil
+	}
+
+	var results []dto.VerifyPluginResult
+	if len(rest) == 1 {
+		res, err := verifyUC.Execute(usecases.VerifyPluginInput{PluginName: rest[0]})
+		if err != nil {
+			return fmt.Errorf("verify error: %w", err)
+		}
+		results = append(results, res)
+	} else if results, err = verifyUC.ExecuteAll(); err != nil {
+		return fmt.Errorf("verify error: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLUGIN\tSTATUS\tDETAILS")
+	modified := 0
+	for _, res := range results {
+		if res.Status == "modified" {
+			modified++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", res.PluginName, res.Status, res.Message)
+	}
+	w.Flush()
+	if modified > 0 {
+		return fmt.Errorf("verify error: %d plugin(s) changed since install", modified)
+	}
+	return nil
+}
+
+// printUpdateSummary prints what an update changed.
+func printUpdateSummary(res dto.UpdatePluginResult) {
+	fmt.Printf("%s: %s\n", res.PluginName, res.Message)
+	if res.FromCommit != "" && res.ToCommit != "" && res.FromCommit != res.ToCommit {
+		fmt.Printf("  %s %s -> %s %s\n", res.FromRef, shortCommit(res.FromCommit), res.ToRef, shortCommit(res.ToCommit))
+	}
+	for _, change := range res.ManifestChanges {
+		fmt.Printf("  plugin.yml  %s\n", change)
+	}
+	for _, f := range res.Added {
+		fmt.Printf("  + %s\n", f)
+	}
+	for _, f := range res.Removed {
+		fmt.Printf("  - %s\n", f)
+	}
+	for _, f := range res.Modified {
+		fmt.Printf("  ~ %s\n", f)
+	}
+	if res.DepsReinstalled {
+		fmt.Println("  dependencies reinstalled")
+	}
+}
+
+// printUpdateTable prints one row per plugin for `kod update --all`.
+func printUpdateTable(results []dto.UpdatePluginResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLUGIN\tSTATUS\tFROM\tTO\tDETAILS")
+	for _, res := range results {
+		to := shortCommit(res.ToCommit)
+		if res.Status != "updated" && res.Status != "up_to_date" {
+			to = "-"
+		}
+		details := res.Message
+		if res.NeedsConsent {
+			details += fmt.Sprintf(" (run kodkafa update %s)", res.PluginName)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", res.PluginName, res.Status, shortCommit(res.FromCommit), to, details)
+	}
+	w.Flush()
+}
+
+func shortCommit(sha string) string {
+	if sha == "" {
+		return "-"
+	}
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -12,6 +14,13 @@ type PluginListItem struct {
 	Usage       string    `json:"usage"`
 	LastRun     time.Time `json:"last_run"`  // from state
 	RunCount    int       `json:"run_count"` // from state
+	Linked      bool      `json:"linked"`
+	AddedAt     time.Time `json:"added_at"`              // from state
+	LastFailure time.Time `json:"last_failure,omitzero"` // latest failed run in history
+	Failed      bool      `json:"failed"`                // the latest run failed
+	Tags        []string  `json:"tags,omitempty"`        // plugin.yml tags, then local ones
+	Category    string    `json:"category,omitempty"`
+	Pinned      bool      `json:"pinned"` // leads the top list, see PinPluginUseCase
 }
 
 // PluginInfo - detailed plugin info
@@ -21,8 +30,81 @@ type PluginInfo struct {
 	Description string    `json:"description"`
 	Entry       string    `json:"entry"`
 	Usage       string    `json:"usage"`
+	Version     string    `json:"version,omitempty"`
 	Source      string    `json:"source"`
 	AddedAt     time.Time `json:"added_at"`
+	Tags        []string  `json:"tags,omitempty"`
+	Category    string    `json:"category,omitempty"`
+
+	Permissions *PermissionsInfo `json:"permissions,omitempty"`
+	Install     *InstallInfo     `json:"install,omitempty"`
+}
+
+// InstallInfo - where the installed code came from
+type InstallInfo struct {
+	Origin      string    `json:"origin"`
+	Ref         string    `json:"ref,omitempty"`
+	Commit      string    `json:"commit,omitempty"`
+	Subdir      string    `json:"subdir,omitempty"`
+	Linked      bool      `json:"linked"`
+	Checksum    string    `json:"checksum,omitempty"`
+	Signer      string    `json:"signer,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// PermissionsInfo - permissions requested by (or granted to) a plugin
+type PermissionsInfo struct {
+	Sandbox      bool     `json:"sandbox"`
+	Network      bool     `json:"network"`
+	Filesystem   []string `json:"filesystem"`
+	Env          []string `json:"env"`
+	Secrets      []string `json:"secrets"`
+	Subprocesses bool     `json:"subprocesses"`
+}
+
+// Describe renders the permissions as human-readable lines for consent prompts.
+// A nil receiver stands for a plugin that declares no permissions.
+func (p *PermissionsInfo) Describe() []string {
+	if p == nil {
+		return []string{
+			"No permissions declared.",
+			"Runs unrestricted with your full user privileges, network and environment.",
+		}
+	}
+
+	var lines []string
+	if p.Sandbox {
+		network := "denied"
+		if p.Network {
+			network = "allowed"
+		}
+		lines = append(lines,
+			"Sandbox:      yes - read-only filesystem",
+			fmt.Sprintf("Network:      %s", network),
+			fmt.Sprintf("Writable:     %s", strings.Join(append([]string{"plugin data dir", "current directory"}, p.Filesystem...), ", ")),
+		)
+	} else {
+		lines = append(lines,
+			"Sandbox:      no - full filesystem access",
+			"Network:      allowed (only the sandbox can deny it)",
+		)
+	}
+
+	lines = append(lines, fmt.Sprintf("Env vars:     %s", listOrNone(p.Env)))
+	lines = append(lines, fmt.Sprintf("Secrets:      %s", listOrNone(p.Secrets)))
+	subprocesses := "no"
+	if p.Subprocesses {
+		subprocesses = "yes"
+	}
+	lines = append(lines, fmt.Sprintf("Subprocesses: %s (advisory, not enforced)", subprocesses))
+	return lines
+}
+
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
 }
 
 // PluginStateInfo - state summary
@@ -30,26 +112,41 @@ type PluginStateInfo struct {
 	LastExecutedAt time.Time `json:"last_executed_at"`
 	RunCount       int       `json:"run_count"`
 	MostRecentArgs string    `json:"most_recent_args"`
+	Tags           []string  `json:"tags,omitempty"` // assigned locally with `kod tag`
 }
 
 // RunRecordInfo - run record for display
 type RunRecordInfo struct {
-	Timestamp time.Time     `json:"timestamp"`
-	Args      string        `json:"args"`
-	ExitCode  int           `json:"exit_code"`
-	Duration  time.Duration `json:"duration"`
-	Status    string        `json:"status"`
+	PluginName string        `json:"plugin_name,omitempty"` // set by ListHistoryUseCase
+	Timestamp  time.Time     `json:"timestamp"`
+	Args       string        `json:"args"`
+	ExitCode   int           `json:"exit_code"`
+	Duration   time.Duration `json:"duration"`
+	Status     string        `json:"status"`
+	Reason     string        `json:"reason,omitempty"`
 }
 
 // DashboardDTO - for ListPluginsUseCase
 type DashboardDTO struct {
-	TopPlugins  []PluginListItem `json:"top_plugins"`
+	TopPlugins  []PluginListItem `json:"top_plugins"` // pinned plugins first
 	MainPlugins []PluginListItem `json:"main_plugins"`
 	TotalCount  int              `json:"total_count"`
 	CurrentPage int              `json:"current_page"`
 	PageSize    int              `json:"page_size"`
 	TotalPages  int              `json:"total_pages"`
 	ShowTopList bool             `json:"show_top_list"`
+	WatchLinked bool             `json:"watch_linked"`    // poll linked plugins for manifest edits
+	SortBy      string           `json:"sort_by"`         // order of MainPlugins, see ports.SortModes
+	Query       string           `json:"query,omitempty"` // search the lists were filtered by
+	// Grouped lists every plugin in MainPlugins, ordered by category, with
+	// uncategorized ones last; there is a single page.
+	Grouped bool `json:"grouped"`
+	// ProjectName is set when kod runs inside a project; its plugins are
+	// listed in ProjectPlugins only, and ProjectPending counts declared
+	// plugins that `kod project sync` has not installed yet.
+	ProjectName    string           `json:"project_name,omitempty"`
+	ProjectPlugins []PluginListItem `json:"project_plugins,omitempty"`
+	ProjectPending int              `json:"project_pending,omitempty"`
 }
 
 // AddPluginResult - for AddPluginUseCase
@@ -57,6 +154,60 @@ type AddPluginResult struct {
 	Plugin  PluginInfo `json:"plugin"`
 	Success bool       `json:"success"`
 	Message string     `json:"message"`
+	// NeedsConsent is set when nothing was installed because the plugin's
+	// permissions must be approved first; see Plugin.Permissions.
+	NeedsConsent bool `json:"needs_consent"`
+	// Registry and ResolvedSource are set when the source was a plugin name
+	// looked up in a registry.
+	Registry       string `json:"registry,omitempty"`
+	ResolvedSource string `json:"resolved_source,omitempty"`
+}
+
+// UpdatePluginResult - for UpdatePluginUseCase
+type UpdatePluginResult struct {
+	PluginName string     `json:"plugin_name"`
+	Success    bool       `json:"success"`
+	Message    string     `json:"message"`
+	Status     string     `json:"status"` // updated, up_to_date, needs_consent, failed
+	Plugin     PluginInfo `json:"plugin"`
+	// NeedsConsent is set when nothing was updated because the new version
+	// asks for permissions beyond those approved; see Plugin.Permissions.
+	NeedsConsent bool `json:"needs_consent"`
+
+	FromRef    string `json:"from_ref,omitempty"`
+	FromCommit string `json:"from_commit,omitempty"`
+	ToRef      string `json:"to_ref,omitempty"`
+	ToCommit   string `json:"to_commit,omitempty"`
+
+	ManifestChanges []string `json:"manifest_changes,omitempty"` // e.g. "entry: main.py -> app.py"
+	Added           []string `json:"added,omitempty"`
+	Removed         []string `json:"removed,omitempty"`
+	Modified        []string `json:"modified,omitempty"`
+	DepsReinstalled bool     `json:"deps_reinstalled"`
+}
+
+// PluginVersionInfo - a previous version kept for rollback
+type PluginVersionInfo struct {
+	ID          string    `json:"id"`
+	Label       string    `json:"label"`
+	Version     string    `json:"version,omitempty"`
+	Commit      string    `json:"commit,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// RollbackPluginResult - for RollbackPluginUseCase
+type RollbackPluginResult struct {
+	PluginName   string     `json:"plugin_name"`
+	Success      bool       `json:"success"`
+	Message      string     `json:"message"`
+	Status       string     `json:"status"` // rolled_back, needs_consent, failed
+	Plugin       PluginInfo `json:"plugin"`
+	NeedsConsent bool       `json:"needs_consent"`
+
+	FromVersion     string              `json:"from_version,omitempty"`
+	ToVersion       string              `json:"to_version,omitempty"`
+	Available       []PluginVersionInfo `json:"available,omitempty"`
+	DepsReinstalled bool                `json:"deps_reinstalled"`
 }
 
 // DeletePluginResult - for DeletePluginUseCase
@@ -78,6 +229,22 @@ type RunPluginResult struct {
 	Status      string        `json:"status"`
 	Interpreter string        `json:"interpreter"`
 	Output      string        `json:"output"`
+	Reason      string        `json:"reason,omitempty"` // e.g. "limit exceeded: memory (512M)"
+}
+
+// PinPluginResult - for PinPluginUseCase
+type PinPluginResult struct {
+	PluginName string   `json:"plugin_name,omitempty"`
+	Pinned     bool     `json:"pinned"`
+	Position   int      `json:"position,omitempty"` // from 1
+	Pins       []string `json:"pins"`               // every pinned plugin, in order
+}
+
+// TagPluginResult - for TagPluginUseCase
+type TagPluginResult struct {
+	PluginName   string   `json:"plugin_name"`
+	ManifestTags []string `json:"manifest_tags"` // from plugin.yml, not editable
+	LocalTags    []string `json:"local_tags"`
 }
 
 // PluginInfoResult - for GetPluginInfoUseCase
@@ -86,3 +253,113 @@ type PluginInfoResult struct {
 	State         PluginStateInfo `json:"state"`
 	RecentHistory []RunRecordInfo `json:"recent_history"`
 }
+
+// VerifyPluginResult - for VerifyPluginUseCase
+type VerifyPluginResult struct {
+	PluginName string `json:"plugin_name"`
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	Status     string `json:"status"`             // ok, modified, linked, unrecorded, failed
+	Expected   string `json:"expected,omitempty"` // checksum recorded at install
+	Actual     string `json:"actual,omitempty"`   // checksum of the files now
+	Signer     string `json:"signer,omitempty"`
+}
+
+// RegistryPluginInfo - a plugin listed in a registry
+type RegistryPluginInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	Source      string   `json:"source"`
+	Latest      string   `json:"latest,omitempty"`
+	Versions    []string `json:"versions,omitempty"`
+	Registry    string   `json:"registry"`
+	Installed   bool     `json:"installed"`
+}
+
+// SearchPluginsResult - for SearchPluginsUseCase
+type SearchPluginsResult struct {
+	Query   string               `json:"query"`
+	Plugins []RegistryPluginInfo `json:"plugins"`
+	Errors  []string             `json:"errors,omitempty"` // registries that could not be read
+}
+
+// ExportPluginsResult - for ExportPluginsUseCase
+type ExportPluginsResult struct {
+	Path     string   `json:"path"`
+	Exported []string `json:"exported"`
+	Skipped  []string `json:"skipped,omitempty"` // "<name>: <reason>"
+}
+
+// SyncAction - one step of SyncPluginsUseCase
+type SyncAction struct {
+	PluginName string `json:"plugin_name"`
+	Action     string `json:"action"` // install, update, reinstall, remove, ok, skip
+	Status     string `json:"status"` // ok, planned, done, skipped, failed
+	Message    string `json:"message,omitempty"`
+}
+
+// SyncPluginsResult - for SyncPluginsUseCase
+type SyncPluginsResult struct {
+	Path    string       `json:"path"`
+	Actions []SyncAction `json:"actions"`
+	Drift   bool         `json:"drift"` // installed plugins differed from the lockfile
+}
+
+// ProfileInfo - one profile of ListProfilesUseCase
+type ProfileInfo struct {
+	Name    string `json:"name"`
+	Dir     string `json:"dir"`
+	Active  bool   `json:"active"`  // used when no --profile is given
+	Current bool   `json:"current"` // used by this invocation
+}
+
+// ListProfilesResult - for ListProfilesUseCase
+type ListProfilesResult struct {
+	Profiles []ProfileInfo `json:"profiles"`
+}
+
+// ConfigSetting - one setting of GetConfigUseCase, ListConfigUseCase and SetConfigUseCase
+type ConfigSetting struct {
+	Key     string `json:"key"`   // dotted path, e.g. supported_runtimes.python
+	Type    string `json:"type"`  // string, bool, int, list or map
+	Value   any    `json:"value"` // decoded JSON value
+	Default bool   `json:"default"`
+}
+
+// ListConfigResult - for ListConfigUseCase
+type ListConfigResult struct {
+	Path     string          `json:"path"`
+	Settings []ConfigSetting `json:"settings"`
+}
+
+// CheckConfigResult - for CheckConfigUseCase
+type CheckConfigResult struct {
+	Path     string   `json:"path"`
+	Warnings []string `json:"warnings"` // unknown keys, with their line
+}
+
+// HistoryResult - for ListHistoryUseCase
+type HistoryResult struct {
+	Runs []RunRecordInfo `json:"runs"` // newest first
+}
+
+// PluginStats - run statistics of one plugin in StatsResult
+type PluginStats struct {
+	Name        string        `json:"name"`
+	RunCount    int           `json:"run_count"`    // every run since the plugin was added
+	HistoryRuns int           `json:"history_runs"` // runs in the kept history, which the rest describe
+	Failures    int           `json:"failures"`
+	AvgDuration time.Duration `json:"avg_duration"` // of finished runs
+	LastRun     time.Time     `json:"last_run,omitzero"`
+	LastFailure time.Time     `json:"last_failure,omitzero"`
+}
+
+// StatsResult - for GetStatsUseCase
+type StatsResult struct {
+	Plugins     int           `json:"plugins"`
+	TotalRuns   int           `json:"total_runs"`
+	HistoryRuns int           `json:"history_runs"`
+	Failures    int           `json:"failures"`
+	PluginStats []PluginStats `json:"plugin_stats"` // most run first
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"flag"
+	"io"
+	"slices"
+)
+
+// parseFlags parses args with fs, allowing flags before and after positional
+// arguments (e.g. `kod add <url> --allow-untrusted`). A bare "--" ends flag
+// parsing. It returns the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+
+	var positional []string
+	for len(args) > 0 {
+		// Stop at "--" ourselves: flag.Parse would swallow it silently.
+		end := slices.Index(args, "--")
+		head := args
+		if end >= 0 {
+			head = args[:end]
+		}
+		if err := fs.Parse(head); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			if end >= 0 {
+				positional = append(positional, args[end+1:]...)
+			}
+			break
+		}
+		positional = append(positional, rest[0])
+		args = append(rest[1:len(rest):len(rest)], args[len(head):]...)
+	}
+	return positional, nil
+}
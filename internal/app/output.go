@@ -0,0 +1,70 @@
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of the commands taking --format.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// outputFlags adds --json and --format to fs. The returned func gives the
+// chosen format once fs is parsed.
+func outputFlags(fs *flag.FlagSet) func() (string, error) {
+	asJSON := fs.Bool("json", false, "print JSON, same as --format json")
+	format := fs.String("format", formatTable, "output format: table, json or yaml")
+	return func() (string, error) {
+		switch {
+		case *asJSON && *format != formatTable && *format != formatJSON:
+			return "", fmt.Errorf("--json conflicts with --format %s", *format)
+		case *asJSON:
+			return formatJSON, nil
+		case *format == formatTable, *format == formatJSON, *format == formatYAML:
+			return *format, nil
+		}
+		return "", fmt.Errorf("unknown format %q: use table, json or yaml", *format)
+	}
+}
+
+// printData writes a dto to stdout as JSON or YAML. YAML keys are the JSON
+// ones, in the same order.
+func printData(format string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format == formatJSON {
+		_, err = fmt.Fprintln(os.Stdout, string(data))
+		return err
+	}
+
+	// JSON is YAML, so decoding it into a node keeps the key order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle drops the JSON flow and quoting styles from a decoded node, so
+// it encodes as ordinary YAML.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
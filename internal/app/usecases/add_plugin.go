@@ -1,8 +1,9 @@
 package usecases
 
 import (
+	"errors"
 	"fmt"
-	"net/url"
+	"os"
 	"strings"
 
 	"kodkafa/internal/app/dto"
@@ -10,25 +11,53 @@ import (
 	"kodkafa/internal/domain/ports"
 )
 
+// ErrUntrustedSource is returned when a remote source fails the trust policy.
+var ErrUntrustedSource = errors.New("source not trusted")
+
 // AddPluginUseCase handles adding a new plugin.
 type AddPluginUseCase struct {
 	pluginRepo  ports.PluginRepository
 	stateStore  ports.StateStore
 	configStore ports.ConfigStore
+	registry    ports.Registry
 }
 
 // NewAddPluginUseCase creates a new AddPluginUseCase.
-func NewAddPluginUseCase(pluginRepo ports.PluginRepository, stateStore ports.StateStore, configStore ports.ConfigStore) *AddPluginUseCase {
+func NewAddPluginUseCase(pluginRepo ports.PluginRepository, stateStore ports.StateStore, configStore ports.ConfigStore, registry ports.Registry) *AddPluginUseCase {
 	return &AddPluginUseCase{
 		pluginRepo:  pluginRepo,
 		stateStore:  stateStore,
 		configStore: configStore,
+		registry:    registry,
 	}
 }
 
 // AddPluginInput represents the input for AddPluginUseCase.
 type AddPluginInput struct {
+	// Source is a path, URL, or a plugin name ("name" or "name@version")
+	// listed in a configured registry. An existing local path wins over a
+	// registry name; "./name" forces a local path.
 	Source string
+	// Subdir selects the plugin directory inside a repository holding several.
+	Subdir string
+	// Link registers a local directory by reference instead of copying it,
+	// for plugin development.
+	Link bool
+	// Approved is set once the user has seen and accepted the plugin's
+	// permissions; Granted holds what was shown (nil: unrestricted).
+	Approved bool
+	Granted  *dto.PermissionsInfo
+	// Approve is asked to accept the permissions of the fetched plugin when
+	// Approved and Granted do not cover them; the plugin it accepts is
+	// installed from the same fetched copy. Without approval nothing is
+	// installed and the result has NeedsConsent set.
+	Approve func(plugin dto.PluginInfo) bool
+	// Checksum is the content hash the installed files must have; empty
+	// skips the check unless a registry entry provides one.
+	Checksum string
+	// AllowUntrusted installs from a remote source outside trusted_domains.
+	// Sources matching denied_domains are still refused.
+	AllowUntrusted bool
 }
 
 // Execute adds a plugin and initializes its state.
@@ -37,71 +66,172 @@ func (uc *AddPluginUseCase) Execute(input AddPluginInput) (dto.AddPluginResult,
 		return dto.AddPluginResult{Success: false, Message: "source path is required"}, fmt.Errorf("source is required")
 	}
 
-	// 1. Check if source is a remote URL
-	if strings.HasPrefix(input.Source, "http") || strings.Contains(input.Source, "@") {
-		// Parse URL to check domain
-		u, err := url.Parse(input.Source)
-		if err != nil && !strings.Contains(input.Source, "@") {
-			return dto.AddPluginResult{Success: false, Message: "invalid source URL"}, err
-		}
-
-		domain := ""
-		if u != nil && u.Host != "" {
-			domain = u.Host
-		} else if strings.Contains(input.Source, "@") {
-			// Handle git@github.com:...
-			parts := strings.Split(strings.Split(input.Source, "@")[1], ":")
-			domain = parts[0]
+	// 1. Resolve registry names, then enforce the trust policy for remote sources
+	raw, subdir, checksum := input.Source, input.Subdir, input.Checksum
+	var resolved dto.AddPluginResult
+	if !input.Link {
+		entry, version, err := uc.lookup(input.Source)
+		if err != nil {
+			return dto.AddPluginResult{Success: false, Message: err.Error()}, err
 		}
-
-		config, err := uc.configStore.Read()
-		if err == nil { // Proceed with trusted domain check only if config can be read
-			trusted := false
-			for _, d := range config.TrustedDomains {
-				if strings.Contains(domain, d) {
-					trusted = true
-					break
-				}
+		if entry != nil {
+			var ok bool
+			var listed string
+			if raw, listed, ok = entry.Resolve(version); !ok {
+				err := fmt.Errorf("registry %s lists no version %q of %s", entry.Registry, version, entry.Name)
+				return dto.AddPluginResult{Success: false, Message: err.Error()}, err
+			}
+			if subdir == "" {
+				subdir = entry.Subdir
 			}
-			if !trusted {
-				return dto.AddPluginResult{Success: false, Message: "domain not trusted: " + domain}, fmt.Errorf("domain not trusted")
+			if checksum == "" {
+				checksum = listed
 			}
+			resolved.Registry = entry.Registry
+			resolved.ResolvedSource = raw
 		}
 	}
-
-	// 2. Add plugin via repository
-	plugin, err := uc.pluginRepo.Add(input.Source)
+	source, err := entities.ParseSource(raw)
 	if err != nil {
 		return dto.AddPluginResult{Success: false, Message: err.Error()}, err
 	}
+	source.Subdir = subdir
+	source.Checksum = checksum
+	if input.Link && source.Kind != entities.SourceLocal {
+		err := fmt.Errorf("only local directories can be linked")
+		return dto.AddPluginResult{Success: false, Message: err.Error()}, err
+	}
+	if source.IsRemote() {
+		config, err := uc.configStore.Read()
+		if err != nil {
+			return dto.AddPluginResult{Success: false, Message: "failed to read trust policy"}, fmt.Errorf("failed to read config: %w", err)
+		}
+		policy := entities.TrustPolicy{Allow: config.TrustedDomains, Deny: config.DeniedDomains}
+		if trusted, reason := policy.Evaluate(source); !trusted {
+			if !input.AllowUntrusted || policy.IsDenied(source) {
+				err := fmt.Errorf("%w: %s", ErrUntrustedSource, reason)
+				return dto.AddPluginResult{Success: false, Message: err.Error()}, err
+			}
+		}
+	}
+
+	// 2. Ask for consent before installing anything
+	consent := uc.consent(input)
+	var plugin *entities.Plugin
+	if input.Link {
+		// Linked code is read from the source directory on every run, so
+		// there is no copy to pin: check the manifest again after linking
+		inspected, err := uc.pluginRepo.Inspect(source)
+		if err != nil {
+			return dto.AddPluginResult{Success: false, Message: err.Error()}, err
+		}
+		if consent, err = uc.approve(consent, inspected, input.Approve); err != nil {
+			return uc.needsConsent(inspected, resolved), nil
+		}
+		if plugin, err = uc.pluginRepo.Link(source); err != nil {
+			return dto.AddPluginResult{Success: false, Message: err.Error()}, err
+		}
+		if !consent.Covers(plugin.Permissions) {
+			_ = uc.pluginRepo.Remove(plugin.Name)
+			err := fmt.Errorf("plugin permissions changed during install, please try again")
+			return dto.AddPluginResult{Success: false, Message: err.Error()}, err
+		}
+	} else {
+		// 3. Fetch once and install the copy the user approved
+		staged, err := uc.pluginRepo.Stage(source)
+		if err != nil {
+			return dto.AddPluginResult{Success: false, Message: err.Error()}, err
+		}
+		defer staged.Discard()
+		if consent, err = uc.approve(consent, staged.Plugin(), input.Approve); err != nil {
+			return uc.needsConsent(staged.Plugin(), resolved), nil
+		}
+		if plugin, err = staged.Install(); err != nil {
+			return dto.AddPluginResult{Success: false, Message: err.Error()}, err
+		}
+	}
 
-	// 3. Initialize state
+	// 4. Initialize state with the granted permissions
 	state := entities.NewPluginState(plugin.Name)
+	state.Consent = consent
 	if err := uc.stateStore.Write(state); err != nil {
 		return dto.AddPluginResult{
 			Success: true, // Plugin was added, but state failed
 			Message: fmt.Sprintf("plugin added but failed to initialize state: %v", err),
-			Plugin: dto.PluginInfo{
-				Name:        plugin.Name,
-				Interpreter: plugin.Interpreter,
-				Description: plugin.Description,
-				Entry:       plugin.Entry,
-				Source:      plugin.Source,
-				AddedAt:     plugin.AddedAt,
-			},
+			Plugin:  toPluginInfo(plugin),
 		}, nil
 	}
 
 	return dto.AddPluginResult{
-		Success: true,
-		Message: "plugin added successfully",
-		Plugin: dto.PluginInfo{
-			Name:        plugin.Name,
-			Interpreter: plugin.Interpreter,
-			Description: plugin.Description,
-			Entry:       plugin.Entry,
-			Source:      plugin.Source,
-			AddedAt:     plugin.AddedAt,
-		},
+		Success:        true,
+		Message:        "plugin added successfully",
+		Plugin:         toPluginInfo(plugin),
+		Registry:       resolved.Registry,
+		ResolvedSource: resolved.ResolvedSource,
 	}, nil
 }
+
+// lookup finds a bare plugin name, optionally suffixed with "@<version>",
+// in the configured registries. It returns a nil entry when raw is not a
+// bare name, names an existing local path or no registry lists it, so it is
+// treated as a local path. A registry that cannot be read is an error: the
+// name might have resolved differently through it.
+func (uc *AddPluginUseCase) lookup(raw string) (*entities.RegistryEntry, string, error) {
+	name, version, _ := strings.Cut(raw, "@")
+	if !entities.IsValidPluginName(name) {
+		return nil, "", nil
+	}
+	if _, err := os.Stat(raw); err == nil {
+		return nil, "", nil
+	}
+	config, err := uc.configStore.Read()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config: %w", err)
+	}
+	if len(config.Registries) == 0 {
+		return nil, "", nil
+	}
+
+	entries, errs := registryEntries(uc.registry, config.Registries)
+	if len(errs) > 0 {
+		return nil, "", fmt.Errorf("failed to look up %s in the registries: %w", name, errors.Join(errs...))
+	}
+	for _, e := range entries {
+		if e.Name == name {
+			return &e, version, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// consent converts the user's approval from the input, or nil if not approved.
+func (uc *AddPluginUseCase) consent(input AddPluginInput) *entities.Consent {
+	if !input.Approved {
+		return nil
+	}
+	return entities.NewConsent(fromPermissionsInfo(input.Granted))
+}
+
+// approve returns the consent to install plugin with: consent if it covers
+// the plugin's permissions, else what approve grants. errNotApproved means
+// the permissions were not granted.
+func (uc *AddPluginUseCase) approve(consent *entities.Consent, plugin *entities.Plugin, approve func(dto.PluginInfo) bool) (*entities.Consent, error) {
+	if consent.Covers(plugin.Permissions) {
+		return consent, nil
+	}
+	if approve == nil || !approve(toPluginInfo(plugin)) {
+		return nil, errNotApproved
+	}
+	return entities.NewConsent(plugin.Permissions), nil
+}
+
+// needsConsent is the result for a plugin whose permissions were not granted.
+func (uc *AddPluginUseCase) needsConsent(plugin *entities.Plugin, resolved dto.AddPluginResult) dto.AddPluginResult {
+	return dto.AddPluginResult{
+		NeedsConsent:   true,
+		Message:        "plugin permissions require approval",
+		Plugin:         toPluginInfo(plugin),
+		Registry:       resolved.Registry,
+		ResolvedSource: resolved.ResolvedSource,
+	}
+}
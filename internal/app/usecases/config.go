@@ -0,0 +1,447 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/ports"
+)
+
+// GetConfigUseCase handles reading one setting.
+type GetConfigUseCase struct {
+	configStore ports.ConfigStore
+}
+
+// NewGetConfigUseCase creates a new GetConfigUseCase.
+func NewGetConfigUseCase(configStore ports.ConfigStore) *GetConfigUseCase {
+	return &GetConfigUseCase{configStore: configStore}
+}
+
+// GetConfigInput represents the input for GetConfigUseCase.
+type GetConfigInput struct {
+	Key string // dotted path, e.g. supported_runtimes.python
+}
+
+// Execute returns the current value of a setting.
+func (uc *GetConfigUseCase) Execute(input GetConfigInput) (dto.ConfigSetting, error) {
+	t, err := settingType(input.Key)
+	if err != nil {
+		return dto.ConfigSetting{}, err
+	}
+	doc, defaults, err := configDocs(uc.configStore)
+	if err != nil {
+		return dto.ConfigSetting{}, err
+	}
+	value, ok := lookupKey(doc, input.Key)
+	if !ok {
+		return dto.ConfigSetting{}, fmt.Errorf("%s is not set", input.Key)
+	}
+	def, _ := lookupKey(defaults, input.Key)
+	return dto.ConfigSetting{
+		Key:     input.Key,
+		Type:    typeName(t),
+		Value:   value,
+		Default: reflect.DeepEqual(value, def),
+	}, nil
+}
+
+// ListConfigUseCase handles listing every setting.
+type ListConfigUseCase struct {
+	configStore ports.ConfigStore
+}
+
+// NewListConfigUseCase creates a new ListConfigUseCase.
+func NewListConfigUseCase(configStore ports.ConfigStore) *ListConfigUseCase {
+	return &ListConfigUseCase{configStore: configStore}
+}
+
+// Execute returns all settings sorted by key. Objects are flattened into
+// their dotted keys; empty ones are listed as a single setting.
+func (uc *ListConfigUseCase) Execute() (dto.ListConfigResult, error) {
+	result := dto.ListConfigResult{Path: uc.configStore.Path(), Settings: []dto.ConfigSetting{}}
+	doc, defaults, err := configDocs(uc.configStore)
+	if err != nil {
+		return result, err
+	}
+	delete(doc, "schema_version")
+
+	var walk func(prefix string, m map[string]any)
+	walk = func(prefix string, m map[string]any) {
+		for key, value := range m {
+			path := prefix + key
+			if sub, ok := value.(map[string]any); ok && len(sub) > 0 {
+				walk(path+".", sub)
+				continue
+			}
+			t, err := settingType(path)
+			if err != nil {
+				continue
+			}
+			def, _ := lookupKey(defaults, path)
+			result.Settings = append(result.Settings, dto.ConfigSetting{
+				Key:     path,
+				Type:    typeName(t),
+				Value:   value,
+				Default: reflect.DeepEqual(value, def),
+			})
+		}
+	}
+	walk("", doc)
+	sort.Slice(result.Settings, func(i, j int) bool { return result.Settings[i].Key < result.Settings[j].Key })
+	return result, nil
+}
+
+// ConfigOp is what SetConfigUseCase does with a setting.
+type ConfigOp string
+
+const (
+	// ConfigSet replaces the value.
+	ConfigSet ConfigOp = "set"
+	// ConfigUnset restores the default, or removes a key that has none.
+	ConfigUnset ConfigOp = "unset"
+	// ConfigAdd appends a value to a list unless it is already there.
+	ConfigAdd ConfigOp = "add"
+	// ConfigRemove removes a value from a list.
+	ConfigRemove ConfigOp = "remove"
+)
+
+// SetConfigUseCase handles changing one setting.
+type SetConfigUseCase struct {
+	configStore ports.ConfigStore
+}
+
+// NewSetConfigUseCase creates a new SetConfigUseCase.
+func NewSetConfigUseCase(configStore ports.ConfigStore) *SetConfigUseCase {
+	return &SetConfigUseCase{configStore: configStore}
+}
+
+// SetConfigInput represents the input for SetConfigUseCase.
+type SetConfigInput struct {
+	Key string
+	// Value is parsed by the setting's type: true/false for switches,
+	// numbers, comma separated or JSON lists, JSON objects.
+	Value string
+	Op    ConfigOp // ConfigSet when empty
+}
+
+// Execute changes a setting and returns its new value. The configuration is
+// validated before it is written, so an invalid value changes nothing.
+func (uc *SetConfigUseCase) Execute(input SetConfigInput) (dto.ConfigSetting, error) {
+	setting := dto.ConfigSetting{Key: input.Key}
+	t, err := settingType(input.Key)
+	if err != nil {
+		return setting, err
+	}
+	setting.Type = typeName(t)
+	if input.Key == "schema_version" {
+		return setting, fmt.Errorf("schema_version is managed by kod")
+	}
+	defaults, err := uc.configStore.Defaults()
+	if err != nil {
+		return setting, err
+	}
+	defDoc, err := configDoc(defaults)
+	if err != nil {
+		return setting, err
+	}
+
+	err = uc.configStore.Update(func(config *ports.Config) error {
+		doc, err := configDoc(config)
+		if err != nil {
+			return err
+		}
+
+		switch input.Op {
+		case ConfigSet, "":
+			value, err := parseSetting(t, input.Value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", input.Key, err)
+			}
+			setKey(doc, input.Key, value)
+
+		case ConfigUnset:
+			if def, ok := lookupKey(defDoc, input.Key); ok {
+				setKey(doc, input.Key, def)
+			} else {
+				deleteKey(doc, input.Key)
+			}
+
+		case ConfigAdd, ConfigRemove:
+			if t.Kind() != reflect.Slice {
+				return fmt.Errorf("%s is not a list", input.Key)
+			}
+			item, err := parseSetting(t.Elem(), input.Value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", input.Key, err)
+			}
+			current, _ := lookupKey(doc, input.Key)
+			list, _ := current.([]any)
+			idx := slices.IndexFunc(list, func(v any) bool { return reflect.DeepEqual(v, item) })
+			switch {
+			case input.Op == ConfigAdd && idx < 0:
+				list = append(list, item)
+			case input.Op == ConfigRemove && idx < 0:
+				return fmt.Errorf("%v is not in %s", item, input.Key)
+			case input.Op == ConfigRemove:
+				list = slices.Delete(list, idx, idx+1)
+			}
+			if list == nil {
+				list = []any{}
+			}
+			setKey(doc, input.Key, list)
+
+		default:
+			return fmt.Errorf("unknown config operation %q", input.Op)
+		}
+
+		updated, err := decodeConfig(doc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", input.Key, err)
+		}
+		// Report the new value itself; the store validates everything else
+		for _, fe := range updated.Validate() {
+			if fe.Key == input.Key || strings.HasPrefix(input.Key, fe.Key+".") || strings.HasPrefix(fe.Key, input.Key+".") {
+				return fe
+			}
+		}
+		*config = *updated
+		setting.Value, _ = lookupKey(doc, input.Key)
+		def, _ := lookupKey(defDoc, input.Key)
+		setting.Default = reflect.DeepEqual(setting.Value, def)
+		return nil
+	})
+	return setting, err
+}
+
+// CheckConfigUseCase handles validating the configuration file.
+type CheckConfigUseCase struct {
+	configStore ports.ConfigStore
+}
+
+// NewCheckConfigUseCase creates a new CheckConfigUseCase.
+func NewCheckConfigUseCase(configStore ports.ConfigStore) *CheckConfigUseCase {
+	return &CheckConfigUseCase{configStore: configStore}
+}
+
+// Execute validates the configuration file. The result carries its path
+// even when the file is invalid.
+func (uc *CheckConfigUseCase) Execute() (dto.CheckConfigResult, error) {
+	result := dto.CheckConfigResult{Path: uc.configStore.Path(), Warnings: []string{}}
+	warnings, err := uc.configStore.Check()
+	if err != nil {
+		return result, err
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+	return result, nil
+}
+
+// FormatConfigValue renders a setting's value for display: strings as they
+// are, everything else as compact JSON.
+func FormatConfigValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// configDocs returns the current and default configuration as JSON
+// documents.
+func configDocs(configStore ports.ConfigStore) (map[string]any, map[string]any, error) {
+	config, err := configStore.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	defaults, err := configStore.Defaults()
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := configDoc(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	defDoc, err := configDoc(defaults)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, defDoc, nil
+}
+
+// configDoc converts config into its JSON document, keyed like config.json.
+func configDoc(config *ports.Config) (map[string]any, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// decodeConfig converts a JSON document back into a Config.
+func decodeConfig(doc map[string]any) (*ports.Config, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	config := &ports.Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// settingType returns the Go type of the setting at a dotted key, following
+// json tags through structs and accepting any key inside maps.
+func settingType(key string) (reflect.Type, error) {
+	if key == "" {
+		return nil, fmt.Errorf("setting key is required")
+	}
+	t := reflect.TypeOf(ports.Config{})
+	for _, part := range strings.Split(key, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := structField(t, part)
+			if !ok {
+				return nil, fmt.Errorf("unknown setting %q", key)
+			}
+			t = field
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, nil
+}
+
+func structField(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == name {
+			return f.Type, true
+		}
+	}
+	return nil, false
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int64:
+		return "int"
+	case reflect.Slice:
+		return "list"
+	case reflect.Map, reflect.Struct:
+		return "map"
+	}
+	return "string"
+}
+
+// parseSetting converts text typed by the user into the JSON value of a
+// setting of type t.
+func parseSetting(t reflect.Type, raw string) (any, error) {
+	raw = strings.TrimSpace(raw)
+	switch t.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", raw)
+		}
+		return b, nil
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", raw)
+		}
+		return float64(n), nil
+	case reflect.Slice:
+		if strings.HasPrefix(raw, "[") {
+			break
+		}
+		list := []any{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				v, err := parseSetting(t.Elem(), item)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+		}
+		return list, nil
+	case reflect.Interface:
+		var v any
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			return raw, nil
+		}
+		return v, nil
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return nil, fmt.Errorf("expected JSON: %w", err)
+	}
+	return v, nil
+}
+
+func lookupKey(doc map[string]any, key string) (any, bool) {
+	var cur any = doc
+	for _, part := range strings.Split(key, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func setKey(doc map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	m := doc
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[part] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+func deleteKey(doc map[string]any, key string) {
+	parts := strings.Split(key, ".")
+	m := doc
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]any)
+		if !ok {
+			return
+		}
+		m = next
+	}
+	delete(m, parts[len(parts)-1])
+}
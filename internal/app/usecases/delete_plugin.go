@@ -86,8 +86,7 @@ func (uc *DeletePluginUseCase) Execute(input DeletePluginInput) (dto.DeletePlugi
 	}
 
 	// 4. Update usage stats
-	usage, err := uc.usageStore.Read()
-	if err == nil && usage != nil {
+	_ = uc.usageStore.Update(func(usage *entities.UsageStats) error {
 		updated := false
 
 		// Remove from RecentlyUsed
@@ -112,10 +111,15 @@ func (uc *DeletePluginUseCase) Execute(input DeletePluginInput) (dto.DeletePlugi
 		}
 		usage.MostUsed = newMost
 
-		if updated {
-			_ = uc.usageStore.Write(usage)
+		if usage.Unpin(input.PluginName) {
+			updated = true
 		}
-	}
+
+		if !updated {
+			return errUnchanged
+		}
+		return nil
+	})
 
 	result.Success = true
 	result.Message = "plugin deleted successfully"
@@ -0,0 +1,184 @@
+package usecases
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"kodkafa/internal/app/dto"
+)
+
+// pluginQuery is a parsed dashboard search. Free text terms are matched
+// fuzzily against name and description; tokens narrow the result:
+//
+//	lang:python   interpreter is python
+//	tag:db        plugin is tagged db, in plugin.yml or locally
+//	category:dev  category starts with dev; quote values with spaces, as in
+//	              category:"dev tools"
+//	failed:       the latest run failed (failed:no for the opposite)
+//	linked:       plugin is linked from a local directory (linked:no)
+type pluginQuery struct {
+	terms      []string
+	langs      []string
+	tags       []string
+	categories []string
+	failed     *bool
+	linked     *bool
+}
+
+// parseQuery splits a search into terms and filter tokens. Unknown tokens
+// are searched for as text.
+func parseQuery(query string) pluginQuery {
+	var q pluginQuery
+	for _, field := range queryFields(strings.ToLower(query)) {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok {
+			q.terms = append(q.terms, field)
+			continue
+		}
+		switch key {
+		case "lang":
+			q.langs = append(q.langs, value)
+		case "tag":
+			q.tags = append(q.tags, value)
+		case "category":
+			q.categories = append(q.categories, value)
+		case "failed":
+			q.failed = flagValue(value)
+		case "linked":
+			q.linked = flagValue(value)
+		default:
+			q.terms = append(q.terms, field)
+		}
+	}
+	return q
+}
+
+// queryFields splits a search at spaces outside double quotes, removing the
+// quotes, so that a term or token value can hold spaces.
+func queryFields(query string) []string {
+	var fields []string
+	var field strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteRune(r)
+		}
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+func flagValue(value string) *bool {
+	b := value != "no" && value != "false"
+	return &b
+}
+
+// match reports whether item passes every token and term, and how well the
+// terms match; higher scores rank first. Every term must match the name, the
+// description or a tag.
+func (q pluginQuery) match(item dto.PluginListItem) (int, bool) {
+	if len(q.langs) > 0 && !slices.Contains(q.langs, strings.ToLower(item.Interpreter)) {
+		return 0, false
+	}
+	for _, tag := range q.tags {
+		if !slices.Contains(item.Tags, tag) {
+			return 0, false
+		}
+	}
+	if len(q.categories) > 0 && !slices.ContainsFunc(q.categories, func(c string) bool {
+		return strings.HasPrefix(strings.ToLower(item.Category), c)
+	}) {
+		return 0, false
+	}
+	if q.failed != nil && item.Failed != *q.failed {
+		return 0, false
+	}
+	if q.linked != nil && item.Linked != *q.linked {
+		return 0, false
+	}
+
+	total := 0
+	name := strings.ToLower(item.Name)
+	desc := strings.ToLower(item.Description)
+	for _, term := range q.terms {
+		score := fuzzyScore(term, name)
+		if strings.Contains(desc, term) {
+			score = max(score, 30)
+		}
+		// Description words count half as much as the name
+		for _, word := range strings.Fields(desc) {
+			score = max(score, fuzzyScore(term, word)/2)
+		}
+		for _, tag := range item.Tags {
+			if strings.Contains(tag, term) {
+				score = max(score, 20)
+			}
+		}
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+// filter returns the items matching the query, best matches first; items
+// that match equally well keep their order.
+func (q pluginQuery) filter(items []dto.PluginListItem) []dto.PluginListItem {
+	type scored struct {
+		item  dto.PluginListItem
+		score int
+	}
+	var matches []scored
+	for _, item := range items {
+		if score, ok := q.match(item); ok {
+			matches = append(matches, scored{item, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	out := make([]dto.PluginListItem, 0, len(matches))
+	for _, m := range matches {
+		out = append(out, m.item)
+	}
+	return out
+}
+
+// fuzzyScore rates how well term matches text: exact, prefix and substring
+// matches score highest, then the letters of term appearing in order, less
+// for every letter skipped between them. It returns 0 for no match.
+func fuzzyScore(term, text string) int {
+	switch {
+	case text == term:
+		return 100
+	case strings.HasPrefix(text, term):
+		return 80
+	case strings.Contains(text, term):
+		return 60
+	}
+
+	gaps, pos := 0, 0
+	for _, r := range term {
+		i := strings.IndexRune(text[pos:], r)
+		if i < 0 {
+			return 0
+		}
+		if pos > 0 {
+			gaps += i
+		}
+		pos += i + len(string(r))
+	}
+	return max(40-gaps*2, 1)
+}
@@ -50,19 +50,12 @@ func (uc *GetPluginInfoUseCase) Execute(input GetPluginInfoInput) (dto.PluginInf
 
 	// 3. Build Result
 	result := dto.PluginInfoResult{
-		Plugin: dto.PluginInfo{
-			Name:        plugin.Name,
-			Interpreter: plugin.Interpreter,
-			Description: plugin.Description,
-			Entry:       plugin.Entry,
-			Usage:       plugin.Usage,
-			Source:      plugin.Source,
-			AddedAt:     plugin.AddedAt,
-		},
+		Plugin: toPluginInfo(plugin),
 		State: dto.PluginStateInfo{
 			LastExecutedAt: state.LastExecutedAt,
 			RunCount:       state.RunCount,
 			MostRecentArgs: state.GetMostRecentArgs(),
+			Tags:           state.Tags,
 		},
 	}
 
@@ -79,14 +72,7 @@ func (uc *GetPluginInfoUseCase) Execute(input GetPluginInfoInput) (dto.PluginInf
 	}
 
 	for i := historyCount - 1; i >= start; i-- {
-		record := state.History[i]
-		result.RecentHistory = append(result.RecentHistory, dto.RunRecordInfo{
-			Timestamp: record.Timestamp,
-			Args:      record.Args,
-			ExitCode:  record.ExitCode,
-			Duration:  record.Duration,
-			Status:    string(record.Status),
-		})
+		result.RecentHistory = append(result.RecentHistory, toRunRecordInfo(state.History[i]))
 	}
 
 	return result, nil
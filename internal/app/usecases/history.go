@@ -0,0 +1,161 @@
+package usecases
+
+import (
+	"sort"
+	"time"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// ListHistoryUseCase handles listing the runs of one or every plugin.
+type ListHistoryUseCase struct {
+	pluginRepo ports.PluginRepository
+	stateStore ports.StateStore
+}
+
+// NewListHistoryUseCase creates a new ListHistoryUseCase.
+func NewListHistoryUseCase(pluginRepo ports.PluginRepository, stateStore ports.StateStore) *ListHistoryUseCase {
+	return &ListHistoryUseCase{
+		pluginRepo: pluginRepo,
+		stateStore: stateStore,
+	}
+}
+
+// ListHistoryInput represents the input for ListHistoryUseCase.
+type ListHistoryInput struct {
+	PluginName string // every plugin when empty
+	Limit      int    // 0 for every kept run
+	Failed     bool   // only failed runs
+}
+
+// Execute returns the kept runs, newest first. With sqlite storage every
+// recorded run is listed; the JSON files keep, like the dashboard, only the
+// latest run for each distinct set of arguments.
+func (uc *ListHistoryUseCase) Execute(input ListHistoryInput) (dto.HistoryResult, error) {
+	result := dto.HistoryResult{Runs: []dto.RunRecordInfo{}}
+	states, err := pluginStates(uc.pluginRepo, uc.stateStore, input.PluginName)
+	if err != nil {
+		return result, err
+	}
+	for _, state := range states {
+		for _, r := range state.History {
+			if input.Failed && !r.Failed() {
+				continue
+			}
+			info := toRunRecordInfo(r)
+			info.PluginName = state.PluginName
+			result.Runs = append(result.Runs, info)
+		}
+	}
+	sort.SliceStable(result.Runs, func(i, j int) bool {
+		return result.Runs[i].Timestamp.After(result.Runs[j].Timestamp)
+	})
+	if input.Limit > 0 && len(result.Runs) > input.Limit {
+		result.Runs = result.Runs[:input.Limit]
+	}
+	return result, nil
+}
+
+// GetStatsUseCase handles summarizing how plugins have run.
+type GetStatsUseCase struct {
+	pluginRepo ports.PluginRepository
+	stateStore ports.StateStore
+}
+
+// NewGetStatsUseCase creates a new GetStatsUseCase.
+func NewGetStatsUseCase(pluginRepo ports.PluginRepository, stateStore ports.StateStore) *GetStatsUseCase {
+	return &GetStatsUseCase{
+		pluginRepo: pluginRepo,
+		stateStore: stateStore,
+	}
+}
+
+// GetStatsInput represents the input for GetStatsUseCase.
+type GetStatsInput struct {
+	PluginName string // every plugin when empty
+}
+
+// Execute returns run counts for each plugin, and failures and durations
+// from the kept history.
+func (uc *GetStatsUseCase) Execute(input GetStatsInput) (dto.StatsResult, error) {
+	result := dto.StatsResult{PluginStats: []dto.PluginStats{}}
+	states, err := pluginStates(uc.pluginRepo, uc.stateStore, input.PluginName)
+	if err != nil {
+		return result, err
+	}
+	for _, state := range states {
+		stats := dto.PluginStats{
+			Name:        state.PluginName,
+			RunCount:    state.RunCount,
+			HistoryRuns: len(state.History),
+			LastRun:     state.LastExecutedAt,
+			LastFailure: state.LastFailure(),
+		}
+		var total time.Duration
+		finished := 0
+		for _, r := range state.History {
+			if r.Failed() {
+				stats.Failures++
+			}
+			if r.Status != entities.RunStatusRunning {
+				total += r.Duration
+				finished++
+			}
+		}
+		if finished > 0 {
+			stats.AvgDuration = total / time.Duration(finished)
+		}
+
+		result.Plugins++
+		result.TotalRuns += stats.RunCount
+		result.HistoryRuns += stats.HistoryRuns
+		result.Failures += stats.Failures
+		result.PluginStats = append(result.PluginStats, stats)
+	}
+	sort.SliceStable(result.PluginStats, func(i, j int) bool {
+		a, b := result.PluginStats[i], result.PluginStats[j]
+		if a.RunCount != b.RunCount {
+			return a.RunCount > b.RunCount
+		}
+		return a.Name < b.Name
+	})
+	return result, nil
+}
+
+// pluginStates reads the state of the named plugin, or of every installed
+// one when name is empty. History holds every recorded run when the store
+// keeps them.
+func pluginStates(pluginRepo ports.PluginRepository, stateStore ports.StateStore, name string) ([]*entities.PluginState, error) {
+	var names []string
+	if name != "" {
+		if _, err := pluginRepo.Get(name); err != nil {
+			return nil, err
+		}
+		names = []string{name}
+	} else {
+		plugins, err := pluginRepo.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, pl := range plugins {
+			names = append(names, pl.Name)
+		}
+	}
+
+	states := make([]*entities.PluginState, 0, len(names))
+	for _, n := range names {
+		state, err := stateStore.Read(n)
+		if err != nil {
+			return nil, err
+		}
+		if h, ok := stateStore.(ports.RunHistoryReader); ok {
+			if state.History, err = h.RunHistory(n); err != nil {
+				return nil, err
+			}
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
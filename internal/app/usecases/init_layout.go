@@ -1,7 +1,6 @@
 package usecases
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -13,17 +12,17 @@ import (
 
 // InitLayoutUseCase handles initialization of the KODKAFA directory structure.
 type InitLayoutUseCase struct {
-	configStore  ports.ConfigStore
-	baseDir      string
-	templatePath string
+	configStore ports.ConfigStore
+	migrator    ports.Migrator
+	baseDir     string
 }
 
 // NewInitLayoutUseCase creates a new InitLayoutUseCase.
-func NewInitLayoutUseCase(baseDir string, templatePath string, configStore ports.ConfigStore) *InitLayoutUseCase {
+func NewInitLayoutUseCase(baseDir string, configStore ports.ConfigStore, migrator ports.Migrator) *InitLayoutUseCase {
 	return &InitLayoutUseCase{
-		configStore:  configStore,
-		baseDir:      baseDir,
-		templatePath: templatePath,
+		configStore: configStore,
+		migrator:    migrator,
+		baseDir:     baseDir,
 	}
 }
 
@@ -40,6 +39,7 @@ func (uc *InitLayoutUseCase) Execute() error {
 		"state",
 		"core",
 		"logs",
+		"data",
 	}
 
 	for _, dir := range dirs {
@@ -49,37 +49,46 @@ func (uc *InitLayoutUseCase) Execute() error {
 		}
 	}
 
+	// Upgrade files written by older versions before anything reads them
+	migrated, backupDir, err := uc.migrator.Migrate()
+	if err != nil {
+		return fmt.Errorf("failed to migrate data: %w", err)
+	}
+	if len(migrated) > 0 {
+		fmt.Fprintf(os.Stderr, "Migrated %d file(s) to the current format; originals are in %s\n", len(migrated), backupDir)
+	}
+
 	// Initialize Core Runtimes
 	if err := uc.initCoreRuntimes(); err != nil {
 		return fmt.Errorf("failed to initialize core runtimes: %w", err)
 	}
 
-	// Runtime detection
-	config, err := uc.configStore.Read()
-	if err != nil {
-		// Load from template
-		data, err := os.ReadFile(uc.templatePath)
-		if err != nil {
-			return fmt.Errorf("failed to read default config template: %w", err)
+	// Runtime detection; a missing config is created from the defaults
+	err = uc.configStore.Update(func(config *ports.Config) error {
+		if config.RuntimePaths == nil {
+			config.RuntimePaths = make(map[string]string)
 		}
-		config = &ports.Config{}
-		if err := json.Unmarshal(data, config); err != nil {
-			return fmt.Errorf("failed to parse default config template: %w", err)
+		for key, cmd := range config.SupportedRuntimes {
+			path, found := runtime.CheckInterpreter(cmd)
+			if found {
+				config.RuntimePaths[key] = path
+			} else {
+				config.RuntimePaths[key] = "undefined"
+				fmt.Fprintf(os.Stderr, "Warning: %s interpreter (%s) not found in PATH\n", key, cmd)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
 	}
 
-	for key, cmd := range config.SupportedRuntimes {
-		path, found := runtime.CheckInterpreter(cmd)
-		if found {
-			config.RuntimePaths[key] = path
-		} else {
-			config.RuntimePaths[key] = "undefined"
-			fmt.Printf("Warning: %s interpreter (%s) not found in PATH\n", key, cmd)
-		}
+	warnings, err := uc.configStore.Check()
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
 	}
-
-	if err := uc.configStore.Write(config); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
 	}
 
 	return nil
@@ -90,7 +99,7 @@ func (uc *InitLayoutUseCase) initCoreRuntimes() error {
 	pyCoreDir := filepath.Join(uc.baseDir, "core", "python")
 	venvPath := filepath.Join(pyCoreDir, "venv")
 	if _, err := os.Stat(venvPath); os.IsNotExist(err) {
-		fmt.Println("Initializing core Python environment...")
+		fmt.Fprintln(os.Stderr, "Initializing core Python environment...")
 		_ = os.MkdirAll(pyCoreDir, 0755)
 		cmd := exec.Command("python3", "-m", "venv", "venv")
 		cmd.Dir = pyCoreDir
@@ -103,7 +112,7 @@ func (uc *InitLayoutUseCase) initCoreRuntimes() error {
 	nodeCoreDir := filepath.Join(uc.baseDir, "core", "node")
 	pkgJsonPath := filepath.Join(nodeCoreDir, "package.json")
 	if _, err := os.Stat(pkgJsonPath); os.IsNotExist(err) {
-		fmt.Println("Initializing core Node.js environment...")
+		fmt.Fprintln(os.Stderr, "Initializing core Node.js environment...")
 		_ = os.MkdirAll(nodeCoreDir, 0755)
 		pkgData := []byte(`{
   "name": "kodkafa-core",
@@ -2,7 +2,10 @@ package usecases
 
 import (
 	"math"
+	"slices"
 	"sort"
+	"strings"
+	"time"
 
 	"kodkafa/internal/app/dto"
 	"kodkafa/internal/domain/entities"
@@ -15,6 +18,7 @@ type ListPluginsUseCase struct {
 	usageStore  ports.UsageStore
 	configStore ports.ConfigStore
 	stateStore  ports.StateStore
+	project     *entities.Project
 }
 
 // NewListPluginsUseCase creates a new ListPluginsUseCase.
@@ -23,12 +27,14 @@ func NewListPluginsUseCase(
 	usageStore ports.UsageStore,
 	configStore ports.ConfigStore,
 	stateStore ports.StateStore,
+	project *entities.Project,
 ) *ListPluginsUseCase {
 	return &ListPluginsUseCase{
 		pluginRepo:  pluginRepo,
 		usageStore:  usageStore,
 		configStore: configStore,
 		stateStore:  stateStore,
+		project:     project,
 	}
 }
 
@@ -36,6 +42,13 @@ func NewListPluginsUseCase(
 type ListPluginsInput struct {
 	Page     int
 	PageSize int
+	SortBy   string // one of ports.SortModes; the sort_by setting when empty
+	// Query searches all plugins instead of listing recents and a page of
+	// the rest; see pluginQuery for its syntax.
+	Query string
+	// All lists every plugin in MainPlugins, without the top list or
+	// pagination, as `kod list` does.
+	All bool
 }
 
 // Execute returns a DashboardDTO with top and main lists.
@@ -71,6 +84,14 @@ func (uc *ListPluginsUseCase) Execute(input ListPluginsInput) (dto.DashboardDTO,
 		result.ShowTopList = true
 	}
 
+	result.WatchLinked = config.WatchLinked
+	result.Query = input.Query
+	result.Grouped = config.GroupByCategory
+	result.SortBy = input.SortBy
+	if result.SortBy == "" {
+		result.SortBy = config.SortBy
+	}
+
 	// 2. Fetch all plugins
 	plugins, err := uc.pluginRepo.List()
 	if err != nil {
@@ -93,80 +114,121 @@ func (uc *ListPluginsUseCase) Execute(input ListPluginsInput) (dto.DashboardDTO,
 		}
 	}
 
-	// 4. Build Top List
+	var query *pluginQuery
+	if strings.TrimSpace(input.Query) != "" {
+		q := parseQuery(input.Query)
+		query = &q
+	}
+
+	// Project plugins get their own section
+	if uc.project != nil {
+		result.ProjectName = uc.project.Name
+		result.ProjectPlugins, result.ProjectPending = uc.projectSection(plugins, stateMap)
+		for i := range result.ProjectPlugins {
+			result.ProjectPlugins[i].Pinned = usage != nil && usage.IsPinned(result.ProjectPlugins[i].Name)
+		}
+		if query != nil {
+			result.ProjectPlugins = query.filter(result.ProjectPlugins)
+		}
+	}
+
+	// 4. Build Top List, pinned plugins first; a search lists every match
+	// in the main list instead
 	topPluginsMap := make(map[string]bool)
-	if config.ShowLastRuns && usage != nil {
+	if usage != nil && query == nil && !input.All {
 		var topItems []string
-		if config.LastRunOrder == "most" {
-			for i := 0; i < len(usage.MostUsed) && i < config.FavLimit; i++ {
-				topItems = append(topItems, usage.MostUsed[i].PluginName)
-			}
-		} else { // default "last"
-			for i := 0; i < len(usage.RecentlyUsed) && i < config.FavLimit; i++ {
-				topItems = append(topItems, usage.RecentlyUsed[i].PluginName)
-			}
+		for _, e := range usage.Pinned {
+			topItems = append(topItems, e.PluginName)
+		}
+		switch {
+		case !config.ShowLastRuns:
+			// Pinned plugins are shown regardless
+		case config.LastRunOrder == "most":
+			topItems = appendUnpinned(topItems, usage, usage.MostUsed, config.FavLimit)
+		default: // "last"
+			topItems = appendUnpinned(topItems, usage, usage.RecentlyUsed, config.FavLimit)
 		}
 
 		for _, name := range topItems {
 			// Find plugin metadata
 			var p *dto.PluginListItem
 			for _, pl := range plugins {
-				if pl.Name == name {
-					state, _ := uc.stateStore.Read(pl.Name)
-					p = &dto.PluginListItem{
-						Name:        pl.Name,
-						Interpreter: pl.Interpreter,
-						Description: pl.Description,
-					}
-					if state != nil {
-						p.LastRun = state.LastExecutedAt
-						p.RunCount = state.RunCount
-					}
+				// Project plugins have their own section, unless pinned
+				if pl.Name == name && (pl.Project == "" || usage.IsPinned(name)) {
+					item := listItem(pl, stateMap[pl.Name])
+					p = &item
 					break
 				}
 			}
 			if p != nil {
+				p.Pinned = usage.IsPinned(name)
 				result.TopPlugins = append(result.TopPlugins, *p)
 				topPluginsMap[name] = true
 			}
 		}
+		result.ShowTopList = result.ShowTopList || len(result.TopPlugins) > 0
 	}
 
 	// 5. Build Main List
 	var mainList []dto.PluginListItem
 	for _, pl := range plugins {
-		if !topPluginsMap[pl.Name] {
-			state, _ := uc.stateStore.Read(pl.Name)
-			item := dto.PluginListItem{
-				Name:        pl.Name,
-				Interpreter: pl.Interpreter,
-				Description: pl.Description,
-			}
-			if state != nil {
-				item.LastRun = state.LastExecutedAt
-				item.RunCount = state.RunCount
-			}
+		if !topPluginsMap[pl.Name] && pl.Project == "" {
+			item := listItem(pl, stateMap[pl.Name])
+			item.Pinned = usage != nil && usage.IsPinned(pl.Name)
 			mainList = append(mainList, item)
 		}
 	}
+	sortItems(mainList, result.SortBy)
+	if query != nil {
+		mainList = query.filter(mainList)
+	}
+	if result.Grouped {
+		groupItems(mainList)
+	}
 
-	// Sort main list alphabetically
-	sort.Slice(mainList, func(i, j int) bool {
-		return mainList[i].Name < mainList[j].Name
-	})
+	paginate(&result, mainList, input.All)
+	return result, nil
+}
 
+// SearchPlugins returns the lists Execute returns for query and page, from
+// every plugin as listed by Execute with All and no query, without reading
+// them again. The dashboard uses it to search as the user types.
+func SearchPlugins(all dto.DashboardDTO, query string, page int) dto.DashboardDTO {
+	q := parseQuery(query)
+	result := all
+	result.Query = query
+	result.CurrentPage = page
+	result.TopPlugins = nil
+	result.ProjectPlugins = q.filter(all.ProjectPlugins)
+	mainList := q.filter(all.MainPlugins)
+	if result.Grouped {
+		groupItems(mainList)
+	}
+	paginate(&result, mainList, false)
+	return result
+}
+
+// paginate sets the main list of result to the page of mainList at
+// result.CurrentPage, or to all of it when all is set or the list is grouped.
+func paginate(result *dto.DashboardDTO, mainList []dto.PluginListItem, all bool) {
 	result.TotalCount = len(mainList)
-	result.TotalPages = int(math.Ceil(float64(result.TotalCount) / float64(pageSize)))
+	if result.Grouped || all {
+		// Collapsing groups replaces paging through them
+		result.MainPlugins = mainList
+		result.CurrentPage, result.TotalPages = 1, 1
+		return
+	}
+	result.TotalPages = int(math.Ceil(float64(result.TotalCount) / float64(result.PageSize)))
 	if result.TotalPages == 0 {
 		result.TotalPages = 1
 	}
 
 	// Apply pagination
-	start := (input.Page - 1) * pageSize
+	start := (result.CurrentPage - 1) * result.PageSize
 	if start < 0 {
 		start = 0
 	}
-	end := start + pageSize
+	end := start + result.PageSize
 	if start > len(mainList) {
 		result.MainPlugins = []dto.PluginListItem{}
 	} else {
@@ -175,6 +237,131 @@ func (uc *ListPluginsUseCase) Execute(input ListPluginsInput) (dto.DashboardDTO,
 		}
 		result.MainPlugins = mainList[start:end]
 	}
+}
 
-	return result, nil
+// appendUnpinned appends the names of up to limit entries that are not
+// pinned, which lead the top list already.
+func appendUnpinned(names []string, usage *entities.UsageStats, entries []entities.UsageEntry, limit int) []string {
+	n := 0
+	for _, e := range entries {
+		if n == limit {
+			break
+		}
+		if !usage.IsPinned(e.PluginName) {
+			names = append(names, e.PluginName)
+			n++
+		}
+	}
+	return names
+}
+
+// projectSection lists the plugins of the current project, in manifest
+// order, and counts the declared ones that are not installed.
+func (uc *ListPluginsUseCase) projectSection(plugins []entities.Plugin, stateMap map[string]*entities.PluginState) ([]dto.PluginListItem, int) {
+	var project []entities.Plugin
+	for _, pl := range plugins {
+		if pl.Project != "" {
+			project = append(project, pl)
+		}
+	}
+
+	items := []dto.PluginListItem{}
+	listed := make(map[string]bool)
+	add := func(pl entities.Plugin) {
+		if listed[pl.Name] {
+			return
+		}
+		listed[pl.Name] = true
+		items = append(items, listItem(pl, stateMap[pl.Name]))
+	}
+
+	pending := 0
+	for _, d := range uc.project.Plugins {
+		_, origin, err := declaredOrigin(uc.project, d)
+		if err != nil {
+			pending++
+			continue
+		}
+		if p := findDeclared(project, origin, d.Subdir); p != nil {
+			add(*p)
+		} else {
+			pending++
+		}
+	}
+	// Installed but no longer declared; sync will remove them.
+	for _, pl := range project {
+		add(pl)
+	}
+	return items, pending
+}
+
+// listItem builds the dashboard entry of a plugin; state may be nil.
+func listItem(pl entities.Plugin, state *entities.PluginState) dto.PluginListItem {
+	item := dto.PluginListItem{
+		Name:        pl.Name,
+		Interpreter: pl.Interpreter,
+		Description: pl.Description,
+		Linked:      pl.IsLinked(),
+		Tags:        pl.Tags,
+		Category:    pl.Category,
+	}
+	if state != nil {
+		item.Tags = entities.NormalizeTags(append(slices.Clone(pl.Tags), state.Tags...))
+		item.LastRun = state.LastExecutedAt
+		item.RunCount = state.RunCount
+		item.AddedAt = state.AddedAt
+		item.LastFailure = state.LastFailure()
+		item.Failed = state.LastRunFailed()
+	}
+	return item
+}
+
+// groupItems orders items by category, case-insensitively, keeping their
+// order within each category. Uncategorized plugins come last.
+func groupItems(items []dto.PluginListItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := strings.ToLower(items[i].Category), strings.ToLower(items[j].Category)
+		if a == "" || b == "" {
+			return a != "" && b == ""
+		}
+		return a < b
+	})
+}
+
+// NextSortMode returns the sort mode following mode in ports.SortModes,
+// wrapping around, for cycling through them.
+func NextSortMode(mode string) string {
+	i := slices.Index(ports.SortModes, mode)
+	return ports.SortModes[(i+1)%len(ports.SortModes)]
+}
+
+// sortItems orders items by a sort mode. Ties, and plugins a mode says
+// nothing about (never run, never failed), fall back to the name.
+func sortItems(items []dto.PluginListItem, mode string) {
+	var before func(a, b dto.PluginListItem) (less, decided bool)
+	newer := func(a, b time.Time) (bool, bool) {
+		return a.After(b), !a.Equal(b)
+	}
+	switch mode {
+	case ports.SortByRecent:
+		before = func(a, b dto.PluginListItem) (bool, bool) { return newer(a.LastRun, b.LastRun) }
+	case ports.SortByMostUsed:
+		before = func(a, b dto.PluginListItem) (bool, bool) { return a.RunCount > b.RunCount, a.RunCount != b.RunCount }
+	case ports.SortByAdded:
+		before = func(a, b dto.PluginListItem) (bool, bool) { return newer(a.AddedAt, b.AddedAt) }
+	case ports.SortByInterpreter:
+		before = func(a, b dto.PluginListItem) (bool, bool) {
+			return a.Interpreter < b.Interpreter, a.Interpreter != b.Interpreter
+		}
+	case ports.SortByFailed:
+		before = func(a, b dto.PluginListItem) (bool, bool) { return newer(a.LastFailure, b.LastFailure) }
+	default: // ports.SortByName
+		before = func(dto.PluginListItem, dto.PluginListItem) (bool, bool) { return false, false }
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if less, decided := before(items[i], items[j]); decided {
+			return less
+		}
+		return items[i].Name < items[j].Name
+	})
 }
@@ -0,0 +1,329 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// ExportPluginsUseCase handles writing the installed plugin set to a lockfile.
+type ExportPluginsUseCase struct {
+	pluginRepo ports.PluginRepository
+	lockStore  ports.LockfileStore
+}
+
+// NewExportPluginsUseCase creates a new ExportPluginsUseCase.
+func NewExportPluginsUseCase(pluginRepo ports.PluginRepository, lockStore ports.LockfileStore) *ExportPluginsUseCase {
+	return &ExportPluginsUseCase{
+		pluginRepo: pluginRepo,
+		lockStore:  lockStore,
+	}
+}
+
+// ExportPluginsInput represents the input for ExportPluginsUseCase.
+type ExportPluginsInput struct {
+	Path string
+}
+
+// Execute writes every installed plugin with its origin, pinned commit and
+// checksum to the lockfile. Plugins that cannot be reproduced elsewhere (see
+// unlockable) are reported as skipped.
+func (uc *ExportPluginsUseCase) Execute(input ExportPluginsInput) (dto.ExportPluginsResult, error) {
+	result := dto.ExportPluginsResult{Path: input.Path}
+	if input.Path == "" {
+		return result, fmt.Errorf("lockfile path is required")
+	}
+
+	plugins, err := uc.pluginRepo.List()
+	if err != nil {
+		return result, fmt.Errorf("failed to list plugins: %w", err)
+	}
+
+	lock := &entities.Lockfile{Version: entities.LockfileVersion}
+	for _, p := range plugins {
+		if reason := unlockable(p); reason != "" {
+			result.Skipped = append(result.Skipped, p.Name+": "+reason)
+			continue
+		}
+		lock.Plugins = append(lock.Plugins, entities.LockedPlugin{
+			Name:     p.Name,
+			Origin:   p.Install.Origin,
+			Ref:      p.Install.Ref,
+			Commit:   p.Install.Commit,
+			Subdir:   p.Install.Subdir,
+			Checksum: p.Install.Checksum,
+		})
+		result.Exported = append(result.Exported, p.Name)
+	}
+
+	if err := uc.lockStore.Write(input.Path, lock); err != nil {
+		return result, fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return result, nil
+}
+
+// SyncPluginsUseCase handles installing, updating and removing plugins to
+// match a lockfile. It drives the add, update and delete use cases so that
+// trust policy, consent and dependency handling stay the same as for the
+// individual commands.
+type SyncPluginsUseCase struct {
+	pluginRepo ports.PluginRepository
+	lockStore  ports.LockfileStore
+	addUC      *AddPluginUseCase
+	updateUC   *UpdatePluginUseCase
+	deleteUC   *DeletePluginUseCase
+	loadUC     *LoadPluginDepsUseCase
+}
+
+// NewSyncPluginsUseCase creates a new SyncPluginsUseCase.
+func NewSyncPluginsUseCase(
+	pluginRepo ports.PluginRepository,
+	lockStore ports.LockfileStore,
+	addUC *AddPluginUseCase,
+	updateUC *UpdatePluginUseCase,
+	deleteUC *DeletePluginUseCase,
+	loadUC *LoadPluginDepsUseCase,
+) *SyncPluginsUseCase {
+	return &SyncPluginsUseCase{
+		pluginRepo: pluginRepo,
+		lockStore:  lockStore,
+		addUC:      addUC,
+		updateUC:   updateUC,
+		deleteUC:   deleteUC,
+		loadUC:     loadUC,
+	}
+}
+
+// SyncPluginsInput represents the input for SyncPluginsUseCase.
+type SyncPluginsInput struct {
+	Path string
+	// Check only reports the drift without changing anything.
+	Check bool
+	// AllowUntrusted works as in AddPluginInput.
+	AllowUntrusted bool
+	// Approve is asked when a plugin to install or update needs permissions
+	// that were not granted yet; nil or false skips that plugin.
+	Approve func(plugin dto.PluginInfo) bool
+}
+
+// Execute compares the installed plugins with the lockfile and, unless
+// Check is set, applies the difference. Linked plugins are left alone, and
+// so are unlisted plugins that export would have skipped.
+func (uc *SyncPluginsUseCase) Execute(input SyncPluginsInput) (dto.SyncPluginsResult, error) {
+	result := dto.SyncPluginsResult{Path: input.Path, Actions: []dto.SyncAction{}}
+	if input.Path == "" {
+		return result, fmt.Errorf("lockfile path is required")
+	}
+
+	lock, err := uc.lockStore.Read(input.Path)
+	if err != nil {
+		return result, err
+	}
+	plugins, err := uc.pluginRepo.List()
+	if err != nil {
+		return result, fmt.Errorf("failed to list plugins: %w", err)
+	}
+	installed := make(map[string]entities.Plugin, len(plugins))
+	for _, p := range plugins {
+		installed[p.Name] = p
+	}
+
+	// 1. Plan
+	type step struct {
+		action dto.SyncAction
+		locked entities.LockedPlugin
+	}
+	var steps []step
+	locked := make(map[string]bool, len(lock.Plugins))
+	for _, l := range lock.Plugins {
+		locked[l.Name] = true
+		action := dto.SyncAction{PluginName: l.Name, Status: "planned"}
+		p, ok := installed[l.Name]
+		switch {
+		case !ok:
+			action.Action = "install"
+			action.Message = "not installed"
+		case p.IsLinked():
+			action.Action = "skip"
+			action.Status = "skipped"
+			action.Message = "linked for development, left as is"
+		case l.Matches(p.Install) && uc.intact(p, l):
+			action.Action = "ok"
+			action.Status = "ok"
+		case l.Matches(p.Install):
+			action.Action = "update"
+			action.Message = "files changed since install"
+		case p.Install != nil && p.Install.Origin == l.Origin && p.Install.Subdir == l.Subdir:
+			action.Action = "update"
+			action.Message = describeDrift(p.Install, l)
+		default:
+			action.Action = "reinstall"
+			action.Message = "installed from a different source"
+		}
+		steps = append(steps, step{action: action, locked: l})
+	}
+	for _, p := range plugins {
+		if locked[p.Name] {
+			continue
+		}
+		if reason := unlockable(p); reason != "" {
+			steps = append(steps, step{action: dto.SyncAction{PluginName: p.Name, Action: "skip", Status: "skipped", Message: reason + ", left as is"}})
+			continue
+		}
+		steps = append(steps, step{action: dto.SyncAction{PluginName: p.Name, Action: "remove", Status: "planned", Message: "not in lockfile"}})
+	}
+
+	// 2. Apply
+	for _, s := range steps {
+		if s.action.Status == "planned" {
+			result.Drift = true
+			if !input.Check {
+				s.action = uc.apply(s.action, s.locked, input)
+			}
+		}
+		result.Actions = append(result.Actions, s.action)
+	}
+	return result, nil
+}
+
+// intact reports whether the plugin's files still have the locked checksum.
+func (uc *SyncPluginsUseCase) intact(p entities.Plugin, l entities.LockedPlugin) bool {
+	if l.Checksum == "" {
+		return true
+	}
+	actual, err := uc.pluginRepo.InstalledChecksum(p.Name)
+	return err == nil && actual == l.Checksum
+}
+
+// apply carries out one planned action and returns it with its outcome.
+func (uc *SyncPluginsUseCase) apply(action dto.SyncAction, l entities.LockedPlugin, input SyncPluginsInput) dto.SyncAction {
+	done := func(msg string, err error) dto.SyncAction {
+		action.Status = "done"
+		action.Message = msg
+		if err != nil {
+			action.Status = "failed"
+			action.Message = err.Error()
+		}
+		return action
+	}
+
+	switch action.Action {
+	case "remove":
+		_, err := uc.deleteUC.Execute(DeletePluginInput{PluginName: action.PluginName})
+		return done("removed", err)
+
+	case "update", "reinstall":
+		// A reinstall is an update from the locked origin: the new copy is
+		// staged and only replaces the installed one once it is complete
+		update := UpdatePluginInput{PluginName: l.Name, Ref: l.Pin(), Checksum: l.Checksum}
+		if action.Action == "reinstall" {
+			update.Source = l.Origin
+			update.Subdir = l.Subdir
+			update.AllowUntrusted = input.AllowUntrusted
+		}
+		res, err := updateWithConsent(uc.updateUC, update, input.Approve)
+		if errors.Is(err, errNotApproved) {
+			action.Status = "skipped"
+		}
+		return done(res.Message, err)
+
+	case "install":
+		add := AddPluginInput{Source: l.Source(), Subdir: l.Subdir, Checksum: l.Checksum, AllowUntrusted: input.AllowUntrusted}
+		res, err := addWithConsent(uc.addUC, add, input.Approve)
+		if err != nil {
+			action = done("", err)
+			if errors.Is(err, errNotApproved) {
+				action.Status = "skipped"
+			}
+			return action
+		}
+		if res.Plugin.Name != l.Name {
+			// Keeping it would leave l.Name missing and the new plugin
+			// unlocked, so every sync would install it again
+			err := fmt.Errorf("source now provides plugin %s, not %s", res.Plugin.Name, l.Name)
+			if _, delErr := uc.deleteUC.Execute(DeletePluginInput{PluginName: res.Plugin.Name}); delErr != nil {
+				err = fmt.Errorf("%w; failed to remove %s: %v", err, res.Plugin.Name, delErr)
+			}
+			return done("", err)
+		}
+		return done("installed"+loadDeps(uc.loadUC, res.Plugin.Name), nil)
+	}
+	return action
+}
+
+// unlockable explains why a plugin cannot be written to a lockfile, or
+// returns "" if it can. Local paths are absolute and would not resolve on
+// another machine.
+func unlockable(p entities.Plugin) string {
+	switch {
+	case p.IsLinked():
+		return "linked for development"
+	case p.Install == nil:
+		return "no recorded origin"
+	}
+	if src, err := entities.ParseSource(p.Install.Origin); err != nil || src.Kind == entities.SourceLocal {
+		return "installed from a local path"
+	}
+	return ""
+}
+
+// errNotApproved is returned when the user declines a plugin's permissions.
+var errNotApproved = errors.New("permissions not granted")
+
+// addWithConsent adds a plugin, asking approve for its permissions when
+// they need consent.
+func addWithConsent(addUC *AddPluginUseCase, input AddPluginInput, approve func(dto.PluginInfo) bool) (dto.AddPluginResult, error) {
+	input.Approve = approve
+	res, err := addUC.Execute(input)
+	if err == nil && res.NeedsConsent {
+		err = errNotApproved
+	}
+	return res, err
+}
+
+// updateWithConsent updates a plugin, asking approve for new permissions.
+func updateWithConsent(updateUC *UpdatePluginUseCase, input UpdatePluginInput, approve func(dto.PluginInfo) bool) (dto.UpdatePluginResult, error) {
+	res, err := updateUC.Execute(input)
+	if err != nil || !res.NeedsConsent {
+		return res, err
+	}
+	if approve == nil || !approve(res.Plugin) {
+		return res, errNotApproved
+	}
+	input.Approved = true
+	input.Granted = res.Plugin.Permissions
+	if res, err = updateUC.Execute(input); err == nil && res.NeedsConsent {
+		err = fmt.Errorf("plugin permissions changed, please try again")
+	}
+	return res, err
+}
+
+// loadDeps installs a freshly added plugin's dependencies and returns a
+// note for the result message if that failed.
+func loadDeps(loadUC *LoadPluginDepsUseCase, name string) string {
+	if _, err := loadUC.Execute(LoadPluginDepsInput{PluginName: name}); err != nil {
+		return fmt.Sprintf("; failed to install dependencies: %v", err)
+	}
+	return ""
+}
+
+// describeDrift explains how an installed plugin differs from its lock entry.
+func describeDrift(install *entities.InstallInfo, l entities.LockedPlugin) string {
+	if l.Commit != "" && install.Commit != l.Commit {
+		return fmt.Sprintf("commit %s, locked %s", shortSHA(install.Commit), shortSHA(l.Commit))
+	}
+	return "files differ from the locked checksum"
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	if sha == "" {
+		return "-"
+	}
+	return sha
+}
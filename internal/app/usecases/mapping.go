@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/entities"
+)
+
+// toPluginInfo maps a plugin entity to its detailed DTO.
+func toPluginInfo(plugin *entities.Plugin) dto.PluginInfo {
+	return dto.PluginInfo{
+		Name:        plugin.Name,
+		Interpreter: plugin.Interpreter,
+		Description: plugin.Description,
+		Entry:       plugin.Entry,
+		Usage:       plugin.Usage,
+		Version:     plugin.Version,
+		Source:      plugin.Source,
+		AddedAt:     plugin.AddedAt,
+		Tags:        plugin.Tags,
+		Category:    plugin.Category,
+		Permissions: toPermissionsInfo(plugin.Permissions),
+		Install:     toInstallInfo(plugin.Install),
+	}
+}
+
+func toRunRecordInfo(r entities.RunRecord) dto.RunRecordInfo {
+	return dto.RunRecordInfo{
+		Timestamp: r.Timestamp,
+		Args:      r.Args,
+		ExitCode:  r.ExitCode,
+		Duration:  r.Duration,
+		Status:    string(r.Status),
+		Reason:    r.Reason,
+	}
+}
+
+func toInstallInfo(i *entities.InstallInfo) *dto.InstallInfo {
+	if i == nil {
+		return nil
+	}
+	return &dto.InstallInfo{
+		Origin:      i.Origin,
+		Ref:         i.Ref,
+		Commit:      i.Commit,
+		Subdir:      i.Subdir,
+		Linked:      i.Linked,
+		Checksum:    i.Checksum,
+		Signer:      i.Signer,
+		InstalledAt: i.InstalledAt,
+	}
+}
+
+func toPermissionsInfo(p *entities.Permissions) *dto.PermissionsInfo {
+	if p == nil {
+		return nil
+	}
+	return &dto.PermissionsInfo{
+		Sandbox:      p.Sandbox,
+		Network:      p.Network,
+		Filesystem:   p.Filesystem,
+		Env:          p.Env,
+		Secrets:      p.Secrets,
+		Subprocesses: p.Subprocesses,
+	}
+}
+
+func fromPermissionsInfo(p *dto.PermissionsInfo) *entities.Permissions {
+	if p == nil {
+		return nil
+	}
+	return &entities.Permissions{
+		Sandbox:      p.Sandbox,
+		Network:      p.Network,
+		Filesystem:   p.Filesystem,
+		Env:          p.Env,
+		Secrets:      p.Secrets,
+		Subprocesses: p.Subprocesses,
+	}
+}
+
+func toPluginVersionInfo(v entities.PluginVersion) dto.PluginVersionInfo {
+	info := dto.PluginVersionInfo{
+		ID:      v.ID,
+		Label:   v.Label(),
+		Version: v.Plugin.Version,
+	}
+	if v.Plugin.Install != nil {
+		info.Commit = v.Plugin.Install.Commit
+		info.InstalledAt = v.Plugin.Install.InstalledAt
+	}
+	return info
+}
+
+func toRegistryPluginInfo(e entities.RegistryEntry, installed bool) dto.RegistryPluginInfo {
+	info := dto.RegistryPluginInfo{
+		Name:        e.Name,
+		Description: e.Description,
+		Tags:        e.Tags,
+		Source:      e.Source,
+		Registry:    e.Registry,
+		Installed:   installed,
+	}
+	if latest, ok := e.Latest(); ok {
+		info.Latest = latest.Version
+		if info.Source == "" {
+			info.Source = latest.Source
+		}
+	}
+	for _, v := range e.Versions {
+		info.Versions = append(info.Versions, v.Version)
+	}
+	return info
+}
@@ -0,0 +1,112 @@
+package usecases
+
+import (
+	"fmt"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// PinOp is what PinPluginUseCase does with a plugin.
+type PinOp string
+
+const (
+	// PinAdd pins the plugin, or moves it when Position is set.
+	PinAdd PinOp = "add"
+	// PinRemove unpins the plugin.
+	PinRemove PinOp = "remove"
+	// PinToggle pins the plugin when it is not pinned, and unpins it otherwise.
+	PinToggle PinOp = "toggle"
+)
+
+// PinPluginUseCase handles the pinned plugins, which lead the dashboard's
+// top list in the order the user gives them.
+type PinPluginUseCase struct {
+	pluginRepo ports.PluginRepository
+	usageStore ports.UsageStore
+}
+
+// NewPinPluginUseCase creates a new PinPluginUseCase.
+func NewPinPluginUseCase(pluginRepo ports.PluginRepository, usageStore ports.UsageStore) *PinPluginUseCase {
+	return &PinPluginUseCase{
+		pluginRepo: pluginRepo,
+		usageStore: usageStore,
+	}
+}
+
+// PinPluginInput represents the input for PinPluginUseCase. Without a
+// PluginName the pinned plugins are returned unchanged.
+type PinPluginInput struct {
+	PluginName string
+	Op         PinOp // PinAdd when empty
+	// Position is where PinAdd puts the plugin, from 1; 0 adds it last or
+	// leaves a pinned plugin where it is.
+	Position int
+}
+
+// Execute pins, unpins or moves a plugin.
+func (uc *PinPluginUseCase) Execute(input PinPluginInput) (dto.PinPluginResult, error) {
+	result := dto.PinPluginResult{PluginName: input.PluginName}
+	if input.PluginName == "" {
+		usage, err := uc.usageStore.Read()
+		if err != nil {
+			return result, err
+		}
+		result.Pins = pinNames(usage)
+		return result, nil
+	}
+	if input.Position < 0 {
+		return result, fmt.Errorf("invalid position %d", input.Position)
+	}
+	op := input.Op
+	if op == "" {
+		op = PinAdd
+	}
+	if op != PinRemove {
+		if _, err := uc.pluginRepo.Get(input.PluginName); err != nil {
+			return result, err
+		}
+	}
+
+	err := uc.usageStore.Update(func(usage *entities.UsageStats) error {
+		pinned := usage.IsPinned(input.PluginName)
+		if op == PinToggle {
+			op = PinAdd
+			if pinned {
+				op = PinRemove
+			}
+		}
+		switch op {
+		case PinAdd:
+			if !pinned || input.Position > 0 {
+				usage.Pin(input.PluginName, input.Position-1)
+			}
+		case PinRemove:
+			if !usage.Unpin(input.PluginName) {
+				return fmt.Errorf("%s is not pinned", input.PluginName)
+			}
+		default:
+			return fmt.Errorf("unknown pin operation %q", op)
+		}
+		result.Pins = pinNames(usage)
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	for i, name := range result.Pins {
+		if name == input.PluginName {
+			result.Pinned, result.Position = true, i+1
+		}
+	}
+	return result, nil
+}
+
+func pinNames(usage *entities.UsageStats) []string {
+	names := []string{}
+	for _, e := range usage.Pinned {
+		names = append(names, e.PluginName)
+	}
+	return names
+}
@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"fmt"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/ports"
+)
+
+// ListProfilesUseCase handles listing the profiles in the KODKAFA home.
+type ListProfilesUseCase struct {
+	profileStore ports.ProfileStore
+	current      string
+}
+
+// NewListProfilesUseCase creates a new ListProfilesUseCase. current is the
+// profile this invocation runs with.
+func NewListProfilesUseCase(profileStore ports.ProfileStore, current string) *ListProfilesUseCase {
+	return &ListProfilesUseCase{
+		profileStore: profileStore,
+		current:      current,
+	}
+}
+
+// Execute returns every profile with its directory.
+func (uc *ListProfilesUseCase) Execute() (dto.ListProfilesResult, error) {
+	result := dto.ListProfilesResult{Profiles: []dto.ProfileInfo{}}
+	names, err := uc.profileStore.List()
+	if err != nil {
+		return result, err
+	}
+	active, err := uc.profileStore.Active()
+	if err != nil {
+		return result, err
+	}
+	for _, name := range names {
+		dir, err := uc.profileStore.Dir(name)
+		if err != nil {
+			return result, err
+		}
+		result.Profiles = append(result.Profiles, dto.ProfileInfo{
+			Name:    name,
+			Dir:     dir,
+			Active:  name == active,
+			Current: name == uc.current,
+		})
+	}
+	return result, nil
+}
+
+// CreateProfileUseCase handles adding a profile.
+type CreateProfileUseCase struct {
+	profileStore ports.ProfileStore
+}
+
+// NewCreateProfileUseCase creates a new CreateProfileUseCase.
+func NewCreateProfileUseCase(profileStore ports.ProfileStore) *CreateProfileUseCase {
+	return &CreateProfileUseCase{profileStore: profileStore}
+}
+
+// CreateProfileInput represents the input for CreateProfileUseCase.
+type CreateProfileInput struct {
+	Name string
+	// Switch also makes the new profile the active one.
+	Switch bool
+}
+
+// Execute creates an empty profile. Its layout and default config are
+// created the first time kod runs with it.
+func (uc *CreateProfileUseCase) Execute(input CreateProfileInput) (dto.ProfileInfo, error) {
+	if input.Name == "" {
+		return dto.ProfileInfo{}, fmt.Errorf("profile name is required")
+	}
+	if err := uc.profileStore.Create(input.Name); err != nil {
+		return dto.ProfileInfo{}, err
+	}
+	dir, err := uc.profileStore.Dir(input.Name)
+	if err != nil {
+		return dto.ProfileInfo{}, err
+	}
+	info := dto.ProfileInfo{Name: input.Name, Dir: dir}
+	if input.Switch {
+		if err := uc.profileStore.SetActive(input.Name); err != nil {
+			return info, fmt.Errorf("failed to switch profile: %w", err)
+		}
+		info.Active = true
+	}
+	return info, nil
+}
+
+// SwitchProfileUseCase handles changing the active profile.
+type SwitchProfileUseCase struct {
+	profileStore ports.ProfileStore
+}
+
+// NewSwitchProfileUseCase creates a new SwitchProfileUseCase.
+func NewSwitchProfileUseCase(profileStore ports.ProfileStore) *SwitchProfileUseCase {
+	return &SwitchProfileUseCase{profileStore: profileStore}
+}
+
+// SwitchProfileInput represents the input for SwitchProfileUseCase.
+type SwitchProfileInput struct {
+	Name string
+}
+
+// Execute makes an existing profile the one used when no --profile is given.
+func (uc *SwitchProfileUseCase) Execute(input SwitchProfileInput) (dto.ProfileInfo, error) {
+	if err := uc.profileStore.SetActive(input.Name); err != nil {
+		return dto.ProfileInfo{}, err
+	}
+	dir, err := uc.profileStore.Dir(input.Name)
+	if err != nil {
+		return dto.ProfileInfo{}, err
+	}
+	return dto.ProfileInfo{Name: input.Name, Dir: dir, Active: true}, nil
+}
@@ -0,0 +1,149 @@
+package usecases
+
+import (
+	"fmt"
+	"strings"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// RollbackPluginUseCase handles switching a plugin back to a kept version.
+type RollbackPluginUseCase struct {
+	pluginRepo ports.PluginRepository
+	stateStore ports.StateStore
+	installer  ports.DependencyInstaller
+}
+
+// NewRollbackPluginUseCase creates a new RollbackPluginUseCase.
+func NewRollbackPluginUseCase(
+	pluginRepo ports.PluginRepository,
+	stateStore ports.StateStore,
+	installer ports.DependencyInstaller,
+) *RollbackPluginUseCase {
+	return &RollbackPluginUseCase{
+		pluginRepo: pluginRepo,
+		stateStore: stateStore,
+		installer:  installer,
+	}
+}
+
+// RollbackPluginInput represents the input for RollbackPluginUseCase.
+type RollbackPluginInput struct {
+	PluginName string
+	// Version is a kept version's ID or plugin.yml version; empty selects
+	// the most recent one.
+	Version string
+	// Approved and Granted work as in AddPluginInput and are only needed
+	// when the kept version asks for more permissions than approved.
+	Approved bool
+	Granted  *dto.PermissionsInfo
+}
+
+// Execute activates a kept version. The current version is kept in its
+// place, so a rollback can be undone with another rollback.
+func (uc *RollbackPluginUseCase) Execute(input RollbackPluginInput) (dto.RollbackPluginResult, error) {
+	result := dto.RollbackPluginResult{PluginName: input.PluginName, Status: "failed"}
+	fail := func(err error) (dto.RollbackPluginResult, error) {
+		result.Message = err.Error()
+		return result, err
+	}
+
+	if input.PluginName == "" {
+		return fail(fmt.Errorf("plugin name is required"))
+	}
+
+	// 1. Pick the version to go back to
+	current, err := uc.pluginRepo.Get(input.PluginName)
+	if err != nil {
+		return fail(fmt.Errorf("plugin not found: %s", input.PluginName))
+	}
+	versions, err := uc.pluginRepo.Versions(input.PluginName)
+	if err != nil {
+		return fail(err)
+	}
+	for _, v := range versions {
+		result.Available = append(result.Available, toPluginVersionInfo(v))
+	}
+	if len(versions) == 0 {
+		return fail(fmt.Errorf("no previous versions of %s are kept", input.PluginName))
+	}
+	target, ok := selectVersion(versions, input.Version)
+	if !ok {
+		labels := make([]string, 0, len(versions))
+		for _, v := range versions {
+			labels = append(labels, fmt.Sprintf("%s (%s)", v.Label(), v.ID))
+		}
+		return fail(fmt.Errorf("version %s of %s not found; available: %s", input.Version, input.PluginName, strings.Join(labels, ", ")))
+	}
+	result.FromVersion = entities.PluginVersion{ID: "current", Plugin: *current}.Label()
+	result.ToVersion = target.Label()
+
+	// 2. The kept version may ask for permissions approved since narrowed
+	state, err := uc.stateStore.Read(input.PluginName)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read plugin state: %w", err))
+	}
+	consent := state.Consent
+	if input.Approved {
+		consent = entities.NewConsent(fromPermissionsInfo(input.Granted))
+	}
+	if !consent.Covers(target.Plugin.Permissions) {
+		result.Status = "needs_consent"
+		result.NeedsConsent = true
+		result.Message = "permissions of that version require approval"
+		result.Plugin = toPluginInfo(&target.Plugin)
+		return result, nil
+	}
+
+	// 3. Swap it in
+	plugin, changes, err := uc.pluginRepo.Rollback(input.PluginName, target.ID)
+	if err != nil {
+		return fail(err)
+	}
+	result.Plugin = toPluginInfo(plugin)
+
+	if consent != state.Consent {
+		err := uc.stateStore.Update(input.PluginName, func(state *entities.PluginState) error {
+			state.Consent = consent
+			return nil
+		})
+		if err != nil {
+			return fail(fmt.Errorf("plugin rolled back but failed to record consent: %w", err))
+		}
+	}
+
+	result.Success = true
+	result.Status = "rolled_back"
+	result.Message = fmt.Sprintf("rolled back from %s to %s", result.FromVersion, result.ToVersion)
+
+	// 4. Restore the dependency versions that version was installed with
+	if changes.DependenciesChanged() {
+		if err := uc.installer.Install(plugin); err != nil {
+			result.Message += fmt.Sprintf("; failed to reinstall dependencies: %v", err)
+			return result, nil
+		}
+		result.DepsReinstalled = true
+	}
+	return result, nil
+}
+
+// selectVersion finds a version by ID, then by plugin.yml version or label,
+// newest first. An empty query selects the newest version.
+func selectVersion(versions []entities.PluginVersion, query string) (entities.PluginVersion, bool) {
+	if query == "" {
+		return versions[0], true
+	}
+	for _, v := range versions {
+		if v.ID == query {
+			return v, true
+		}
+	}
+	for _, v := range versions {
+		if v.Plugin.Version == query || v.Label() == query {
+			return v, true
+		}
+	}
+	return entities.PluginVersion{}, false
+}
@@ -1,6 +1,7 @@
 package usecases
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,10 @@ import (
 	"kodkafa/internal/domain/ports"
 )
 
+// errUnchanged makes a store Update skip the write when there is nothing
+// to change.
+var errUnchanged = errors.New("nothing to change")
+
 // RunPluginUseCase handles the lifecycle of executing a plugin.
 type RunPluginUseCase struct {
 	pluginRepo  ports.PluginRepository
@@ -71,32 +76,52 @@ func (uc *RunPluginUseCase) Execute(input RunPluginInput) (dto.RunPluginResult,
 		result.Interpreter = plugin.Interpreter
 	}
 
-	// 2. Update usage stats (Run started - P0)
-	usage, err := uc.usageStore.Read()
-	if err == nil && usage != nil {
-		config, err := uc.configStore.Read()
-		limit := 10 // Default
-		if err == nil {
-			limit = config.LastRunLimit
-		}
-		// Update global usage (for favorites) - no history here
-		usage.RecordRun(input.PluginName, limit)
-		_ = uc.usageStore.Write(usage)
-	}
-
-	// 3. Update plugin state (Persist run intent - P0)
+	// Refuse to run with permissions the user never approved
 	state, err := uc.stateStore.Read(input.PluginName)
 	if err != nil {
 		state = entities.NewPluginState(input.PluginName)
 	}
+	if state.Consent != nil && !state.Consent.Covers(plugin.Permissions) {
+		result.Status = "error"
+		return result, fmt.Errorf("plugin %s requests permissions that were not approved; update or re-add it to review them", plugin.Name)
+	}
+
+	config, configErr := uc.configStore.Read()
+
+	// Apply configured resource ceilings on top of the manifest limits
+	if configErr == nil {
+		ceiling, err := config.ResourceLimits.Parse()
+		if err != nil {
+			result.Status = "error"
+			return result, fmt.Errorf("invalid resource_limits in config: %w", err)
+		}
+		plugin.Limits = plugin.Limits.Within(ceiling)
+	}
 
+	// 2. Update usage stats (Run started - P0)
+	limit := 10 // Default
+	if configErr == nil {
+		limit = config.LastRunLimit
+	}
+	// Update global usage (for favorites) - no history here
+	_ = uc.usageStore.Update(func(usage *entities.UsageStats) error {
+		usage.RecordRun(input.PluginName, limit)
+		return nil
+	})
+
+	// 3. Update plugin state (Persist run intent - P0)
 	record := entities.RunRecord{
 		Timestamp: time.Now(),
 		Args:      input.Args, // History lives here!
 		Status:    entities.RunStatusRunning,
 	}
-	state.AddRunRecord(record)
-	_ = uc.stateStore.Write(state)
+	_ = uc.stateStore.Update(input.PluginName, func(state *entities.PluginState) error {
+		if configErr == nil && config.HistorySize > 0 {
+			state.MaxHistorySize = config.HistorySize
+		}
+		state.AddRunRecord(record)
+		return nil
+	})
 
 	// 4. Run plugin (P1/P1i)
 	runResult, err := uc.runner.Run(plugin, input.Args, input.Mode, input.OutputChan)
@@ -121,13 +146,25 @@ func (uc *RunPluginUseCase) Execute(input RunPluginInput) (dto.RunPluginResult,
 		if runResult.ExitCode != 0 {
 			result.Message = fmt.Sprintf("Process exited with code %d", runResult.ExitCode)
 		}
-	}
 
-	// Update the record with final results
-	if len(state.History) > 0 {
-		state.History[len(state.History)-1] = record
-		_ = uc.stateStore.Write(state)
+		if runResult.LimitExceeded != "" {
+			record.Status = entities.RunStatusFailed
+			record.Reason = "limit exceeded: " + runResult.LimitExceeded
+
+			result.Status = string(entities.RunStatusFailed)
+			result.Success = false
+			result.Reason = record.Reason
+		}
 	}
 
+	// Update the record with final results; other runs may have added
+	// records since, so it is matched by its start time
+	_ = uc.stateStore.Update(input.PluginName, func(state *entities.PluginState) error {
+		if !state.UpdateRunRecord(record) {
+			return errUnchanged
+		}
+		return nil
+	})
+
 	return result, err
 }
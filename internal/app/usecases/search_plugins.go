@@ -0,0 +1,114 @@
+package usecases
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// SearchPluginsUseCase handles searching the configured plugin registries.
+type SearchPluginsUseCase struct {
+	registry    ports.Registry
+	configStore ports.ConfigStore
+	pluginRepo  ports.PluginRepository
+}
+
+// NewSearchPluginsUseCase creates a new SearchPluginsUseCase.
+func NewSearchPluginsUseCase(registry ports.Registry, configStore ports.ConfigStore, pluginRepo ports.PluginRepository) *SearchPluginsUseCase {
+	return &SearchPluginsUseCase{
+		registry:    registry,
+		configStore: configStore,
+		pluginRepo:  pluginRepo,
+	}
+}
+
+// SearchPluginsInput represents the input for SearchPluginsUseCase.
+type SearchPluginsInput struct {
+	// Query is matched against names, descriptions and tags; empty lists
+	// every plugin.
+	Query string
+}
+
+// Execute returns the registry plugins matching the query, exact and prefix
+// name matches first. Registries that cannot be read are reported in
+// Errors without failing the search.
+func (uc *SearchPluginsUseCase) Execute(input SearchPluginsInput) (dto.SearchPluginsResult, error) {
+	result := dto.SearchPluginsResult{Query: input.Query, Plugins: []dto.RegistryPluginInfo{}}
+
+	config, err := uc.configStore.Read()
+	if err != nil {
+		return result, fmt.Errorf("failed to read config: %w", err)
+	}
+	if len(config.Registries) == 0 {
+		return result, fmt.Errorf("no registries configured, add index URLs or paths to \"registries\" in config.json")
+	}
+
+	entries, errs := registryEntries(uc.registry, config.Registries)
+	for _, err := range errs {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	installed := make(map[string]bool)
+	if plugins, err := uc.pluginRepo.List(); err == nil {
+		for _, p := range plugins {
+			installed[p.Name] = true
+		}
+	}
+
+	query := strings.ToLower(strings.TrimSpace(input.Query))
+	var matches []entities.RegistryEntry
+	for _, e := range entries {
+		if e.Matches(query) {
+			matches = append(matches, e)
+		}
+	}
+	rank := func(e entities.RegistryEntry) int {
+		name := strings.ToLower(e.Name)
+		switch {
+		case query != "" && name == query:
+			return 0
+		case query != "" && strings.HasPrefix(name, query):
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if ri, rj := rank(matches[i]), rank(matches[j]); ri != rj {
+			return ri < rj
+		}
+		return matches[i].Name < matches[j].Name
+	})
+
+	for _, e := range matches {
+		result.Plugins = append(result.Plugins, toRegistryPluginInfo(e, installed[e.Name]))
+	}
+	return result, nil
+}
+
+// registryEntries reads every registry in order. A name listed by several
+// registries keeps the entry of the first, so earlier registries take
+// precedence, as they do when adding by name.
+func registryEntries(registry ports.Registry, locations []string) ([]entities.RegistryEntry, []error) {
+	var entries []entities.RegistryEntry
+	var errs []error
+	seen := make(map[string]bool)
+	for _, location := range locations {
+		listed, err := registry.Fetch(location)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, e := range listed {
+			if !seen[e.Name] {
+				seen[e.Name] = true
+				entries = append(entries, e)
+			}
+		}
+	}
+	return entries, errs
+}
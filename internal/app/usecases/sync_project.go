@@ -0,0 +1,206 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// SyncProjectUseCase handles installing the plugins a project declares in
+// its .kodkafa.yml. It works on the project's own plugin directory and state,
+// so the add, update and delete use cases it drives must be built on those.
+type SyncProjectUseCase struct {
+	project    *entities.Project
+	pluginRepo ports.PluginRepository
+	addUC      *AddPluginUseCase
+	updateUC   *UpdatePluginUseCase
+	deleteUC   *DeletePluginUseCase
+	loadUC     *LoadPluginDepsUseCase
+}
+
+// NewSyncProjectUseCase creates a new SyncProjectUseCase. project is nil
+// when kod was not started inside a project.
+func NewSyncProjectUseCase(
+	project *entities.Project,
+	pluginRepo ports.PluginRepository,
+	addUC *AddPluginUseCase,
+	updateUC *UpdatePluginUseCase,
+	deleteUC *DeletePluginUseCase,
+	loadUC *LoadPluginDepsUseCase,
+) *SyncProjectUseCase {
+	return &SyncProjectUseCase{
+		project:    project,
+		pluginRepo: pluginRepo,
+		addUC:      addUC,
+		updateUC:   updateUC,
+		deleteUC:   deleteUC,
+		loadUC:     loadUC,
+	}
+}
+
+// SyncProjectInput represents the input for SyncProjectUseCase.
+type SyncProjectInput struct {
+	// Check only reports what is missing without changing anything.
+	Check bool
+	// AllowUntrusted works as in AddPluginInput.
+	AllowUntrusted bool
+	// Approve is asked when a plugin needs permissions that were not
+	// granted yet; nil or false skips that plugin.
+	Approve func(plugin dto.PluginInfo) bool
+}
+
+// projectStep is a declared plugin with where it must come from.
+type projectStep struct {
+	action   dto.SyncAction
+	declared entities.ProjectPlugin
+	source   entities.Source
+	origin   string // install origin the declared plugin is recorded with
+}
+
+// Execute compares the project's installed plugins with its manifest and,
+// unless Check is set, installs what is missing, moves remote plugins to a
+// changed ref and removes plugins the manifest no longer declares. Local
+// paths are linked in place, so edits in the repository take effect at once.
+func (uc *SyncProjectUseCase) Execute(input SyncProjectInput) (dto.SyncPluginsResult, error) {
+	result := dto.SyncPluginsResult{Actions: []dto.SyncAction{}}
+	if uc.project == nil {
+		return result, fmt.Errorf("not inside a project: no %s found", entities.ProjectFile)
+	}
+	result.Path = filepath.Join(uc.project.Root, entities.ProjectFile)
+
+	plugins, err := uc.pluginRepo.List()
+	if err != nil {
+		return result, fmt.Errorf("failed to list plugins: %w", err)
+	}
+
+	// 1. Plan
+	var steps []projectStep
+	kept := make(map[string]bool, len(plugins))
+	for _, d := range uc.project.Plugins {
+		step := projectStep{declared: d, action: dto.SyncAction{PluginName: d.Source, Status: "planned"}}
+		src, origin, err := declaredOrigin(uc.project, d)
+		if err != nil {
+			step.action.Action = "skip"
+			step.action.Status = "failed"
+			step.action.Message = err.Error()
+			steps = append(steps, step)
+			continue
+		}
+		step.source = src
+		step.origin = origin
+
+		installed := findDeclared(plugins, origin, d.Subdir)
+		switch {
+		case installed == nil:
+			step.action.Action = "install"
+			step.action.Message = "not installed"
+		case src.Ref != "" && installed.Install.Ref != src.Ref:
+			kept[installed.Name] = true
+			step.action.PluginName = installed.Name
+			step.action.Action = "update"
+			step.action.Message = fmt.Sprintf("ref %s -> %s", installed.Install.Ref, src.Ref)
+		default:
+			kept[installed.Name] = true
+			step.action.PluginName = installed.Name
+			step.action.Action = "ok"
+			step.action.Status = "ok"
+		}
+		steps = append(steps, step)
+	}
+	for _, p := range plugins {
+		if !kept[p.Name] {
+			steps = append(steps, projectStep{action: dto.SyncAction{PluginName: p.Name, Action: "remove", Status: "planned", Message: "no longer declared"}})
+		}
+	}
+
+	// 2. Apply
+	for _, s := range steps {
+		if s.action.Status == "planned" {
+			result.Drift = true
+			if !input.Check {
+				s.action = uc.apply(s, input)
+			}
+		}
+		result.Actions = append(result.Actions, s.action)
+	}
+	return result, nil
+}
+
+// apply carries out one planned step and returns its action with the outcome.
+func (uc *SyncProjectUseCase) apply(s projectStep, input SyncProjectInput) dto.SyncAction {
+	action := s.action
+	done := func(msg string, err error) dto.SyncAction {
+		action.Status = "done"
+		action.Message = msg
+		if err != nil {
+			action.Status = "failed"
+			if errors.Is(err, errNotApproved) {
+				action.Status = "skipped"
+			}
+			action.Message = err.Error()
+		}
+		return action
+	}
+
+	switch action.Action {
+	case "remove":
+		_, err := uc.deleteUC.Execute(DeletePluginInput{PluginName: action.PluginName})
+		return done("removed", err)
+
+	case "update":
+		res, err := updateWithConsent(uc.updateUC, UpdatePluginInput{PluginName: action.PluginName, Ref: s.source.Ref}, input.Approve)
+		return done(res.Message, err)
+
+	case "install":
+		add := AddPluginInput{Source: s.declared.Source, Subdir: s.declared.Subdir, AllowUntrusted: input.AllowUntrusted}
+		if s.source.Kind == entities.SourceLocal {
+			// Local directories are linked; local archives are unpacked.
+			add.Source = s.origin
+			add.Link = !s.source.IsArchive()
+		}
+		res, err := addWithConsent(uc.addUC, add, input.Approve)
+		if err != nil {
+			return done("", err)
+		}
+		action.PluginName = res.Plugin.Name
+		msg := "installed from " + s.declared.Source
+		if add.Link {
+			msg = "linked from " + s.declared.Source
+		}
+		return done(msg+loadDeps(uc.loadUC, res.Plugin.Name), nil)
+	}
+	return action
+}
+
+// declaredOrigin parses a declared plugin's source and returns the origin it
+// is recorded with once installed: the URL of a remote source or the
+// absolute path of a local one, taken relative to the project root.
+func declaredOrigin(project *entities.Project, d entities.ProjectPlugin) (entities.Source, string, error) {
+	src, err := entities.ParseSource(d.Source)
+	if err != nil {
+		return src, "", err
+	}
+	switch {
+	case src.Kind != entities.SourceLocal:
+		return src, src.URL(), nil
+	case filepath.IsAbs(src.Path):
+		return src, filepath.Clean(src.Path), nil
+	}
+	return src, filepath.Join(project.Root, filepath.FromSlash(src.Path)), nil
+}
+
+// findDeclared returns the installed plugin with the given origin and
+// subdir, or nil.
+func findDeclared(plugins []entities.Plugin, origin, subdir string) *entities.Plugin {
+	for i := range plugins {
+		p := &plugins[i]
+		if p.Install != nil && p.Install.Origin == origin && p.Install.Subdir == subdir {
+			return p
+		}
+	}
+	return nil
+}
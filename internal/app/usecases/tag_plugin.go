@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// TagPluginUseCase handles the tags a user assigns to a plugin locally, on
+// top of those its plugin.yml declares.
+type TagPluginUseCase struct {
+	pluginRepo ports.PluginRepository
+	stateStore ports.StateStore
+}
+
+// NewTagPluginUseCase creates a new TagPluginUseCase.
+func NewTagPluginUseCase(pluginRepo ports.PluginRepository, stateStore ports.StateStore) *TagPluginUseCase {
+	return &TagPluginUseCase{
+		pluginRepo: pluginRepo,
+		stateStore: stateStore,
+	}
+}
+
+// TagPluginInput represents the input for TagPluginUseCase. With neither
+// Add nor Remove, the current tags are returned.
+type TagPluginInput struct {
+	PluginName string
+	Add        []string
+	Remove     []string
+}
+
+// Execute adds and removes local tags. Tags plugin.yml already declares are
+// not added again, and cannot be removed.
+func (uc *TagPluginUseCase) Execute(input TagPluginInput) (dto.TagPluginResult, error) {
+	result := dto.TagPluginResult{PluginName: input.PluginName}
+	if input.PluginName == "" {
+		return result, fmt.Errorf("plugin name is required")
+	}
+	plugin, err := uc.pluginRepo.Get(input.PluginName)
+	if err != nil {
+		return result, err
+	}
+	result.ManifestTags = plugin.Tags
+
+	add := entities.NormalizeTags(input.Add)
+	remove := entities.NormalizeTags(input.Remove)
+	for _, tag := range add {
+		if strings.ContainsAny(tag, " \t,:") {
+			return result, fmt.Errorf("invalid tag %q: tags cannot contain spaces, commas or colons", tag)
+		}
+	}
+	for _, tag := range remove {
+		if slices.Contains(plugin.Tags, tag) {
+			return result, fmt.Errorf("tag %q comes from plugin.yml and cannot be removed", tag)
+		}
+	}
+
+	if len(add) == 0 && len(remove) == 0 {
+		state, err := uc.stateStore.Read(input.PluginName)
+		if err != nil {
+			return result, err
+		}
+		result.LocalTags = state.Tags
+		return result, nil
+	}
+
+	err = uc.stateStore.Update(input.PluginName, func(state *entities.PluginState) error {
+		tags := slices.DeleteFunc(slices.Clone(state.Tags), func(tag string) bool {
+			return slices.Contains(remove, tag)
+		})
+		for _, tag := range add {
+			if !slices.Contains(plugin.Tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		state.Tags = entities.NormalizeTags(tags)
+		result.LocalTags = state.Tags
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to save tags: %w", err)
+	}
+	return result, nil
+}
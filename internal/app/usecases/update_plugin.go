@@ -0,0 +1,287 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// errNeedsConsent aborts an update whose new version asks for permissions
+// that were not granted.
+var errNeedsConsent = errors.New("new permissions require approval")
+
+// UpdatePluginUseCase handles re-fetching an installed plugin from its origin.
+type UpdatePluginUseCase struct {
+	pluginRepo  ports.PluginRepository
+	stateStore  ports.StateStore
+	configStore ports.ConfigStore
+	installer   ports.DependencyInstaller
+}
+
+// NewUpdatePluginUseCase creates a new UpdatePluginUseCase.
+func NewUpdatePluginUseCase(
+	pluginRepo ports.PluginRepository,
+	stateStore ports.StateStore,
+	configStore ports.ConfigStore,
+	installer ports.DependencyInstaller,
+) *UpdatePluginUseCase {
+	return &UpdatePluginUseCase{
+		pluginRepo:  pluginRepo,
+		stateStore:  stateStore,
+		configStore: configStore,
+		installer:   installer,
+	}
+}
+
+// UpdatePluginInput represents the input for UpdatePluginUseCase.
+type UpdatePluginInput struct {
+	PluginName string
+	// Ref switches to another tag, branch or commit; empty keeps the recorded
+	// ref, i.e. the latest commit of a branch or the same tag or commit.
+	Ref string
+	// Checksum is the content hash the new files must have; empty skips the
+	// check.
+	Checksum string
+	// Approved and Granted work as in AddPluginInput and are only needed
+	// when the new version asks for more permissions.
+	Approved bool
+	Granted  *dto.PermissionsInfo
+	// Source switches the plugin to another origin (a path or URL) with
+	// Subdir inside it; empty keeps the recorded origin. The new origin has
+	// to pass the trust policy, as for AddPluginInput.
+	Source         string
+	Subdir         string
+	AllowUntrusted bool
+}
+
+// Execute updates a plugin, reinstalling dependencies only when dependency
+// files changed.
+func (uc *UpdatePluginUseCase) Execute(input UpdatePluginInput) (dto.UpdatePluginResult, error) {
+	result := dto.UpdatePluginResult{PluginName: input.PluginName, Status: "failed"}
+	fail := func(err error) (dto.UpdatePluginResult, error) {
+		result.Message = err.Error()
+		return result, err
+	}
+
+	if input.PluginName == "" {
+		return fail(fmt.Errorf("plugin name is required"))
+	}
+
+	// 1. Find where the plugin came from
+	current, err := uc.pluginRepo.Get(input.PluginName)
+	if err != nil {
+		return fail(fmt.Errorf("plugin not found: %s", input.PluginName))
+	}
+	if current.Install == nil && input.Source == "" {
+		return fail(fmt.Errorf("plugin %s has no recorded origin; delete and re-add it to enable updates", input.PluginName))
+	}
+	if current.IsLinked() {
+		return uc.refreshLink(current, input)
+	}
+	var source entities.Source
+	if input.Source != "" {
+		if source, err = entities.ParseSource(input.Source); err != nil {
+			return fail(err)
+		}
+		source.Subdir = input.Subdir
+	} else {
+		if source, err = entities.ParseSource(current.Install.Origin); err != nil {
+			return fail(err)
+		}
+		source.Subdir = current.Install.Subdir
+		source.Ref = current.Install.Ref
+		result.FromRef = current.Install.Ref
+		result.FromCommit = current.Install.Commit
+	}
+	source.Checksum = input.Checksum
+	if input.Ref != "" {
+		if !source.IsGit() {
+			return fail(fmt.Errorf("plugin %s was installed from a local path and has no refs", input.PluginName))
+		}
+		source.Ref = input.Ref
+	}
+
+	// Origins denied since the plugin was added are not fetched again, and a
+	// new origin is held to the same policy as kod add
+	config, err := uc.configStore.Read()
+	if err != nil {
+		return fail(fmt.Errorf("failed to read config: %w", err))
+	}
+	policy := entities.TrustPolicy{Allow: config.TrustedDomains, Deny: config.DeniedDomains}
+	if source.IsRemote() && policy.IsDenied(source) {
+		return fail(fmt.Errorf("%w: %s is denied", ErrUntrustedSource, source.Host))
+	}
+	if source.IsRemote() && input.Source != "" && !input.AllowUntrusted {
+		if trusted, reason := policy.Evaluate(source); !trusted {
+			return fail(fmt.Errorf("%w: %s", ErrUntrustedSource, reason))
+		}
+	}
+
+	// 2. Fetch the new version once and check its permissions against the
+	// stored consent before it replaces the installed one
+	state, err := uc.stateStore.Read(input.PluginName)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read plugin state: %w", err))
+	}
+	consent := state.Consent
+	if input.Approved {
+		consent = entities.NewConsent(fromPermissionsInfo(input.Granted))
+	}
+	var pending *entities.Plugin
+	accept := func(next *entities.Plugin) error {
+		if consent.Covers(next.Permissions) {
+			return nil
+		}
+		pending = next
+		return errNeedsConsent
+	}
+
+	// 3. Swap in the new version
+	updated, changes, err := uc.pluginRepo.Update(input.PluginName, source, accept)
+	if errors.Is(err, errNeedsConsent) {
+		result.Status = "needs_consent"
+		result.NeedsConsent = true
+		result.Message = err.Error()
+		result.Plugin = toPluginInfo(pending)
+		result.ManifestChanges = manifestChanges(current, pending)
+		return result, nil
+	}
+	if err != nil {
+		return fail(err)
+	}
+	result.Plugin = toPluginInfo(updated)
+	result.ToRef = updated.Install.Ref
+	result.ToCommit = updated.Install.Commit
+	result.ManifestChanges = manifestChanges(current, updated)
+	result.Added = changes.Added
+	result.Removed = changes.Removed
+	result.Modified = changes.Modified
+
+	if consent != state.Consent {
+		if err := uc.recordConsent(input.PluginName, consent); err != nil {
+			return fail(fmt.Errorf("plugin updated but failed to record consent: %w", err))
+		}
+	}
+
+	result.Success = true
+	if changes.IsEmpty() {
+		result.Status = "up_to_date"
+		result.Message = "already up to date"
+		return result, nil
+	}
+	result.Status = "updated"
+	result.Message = fmt.Sprintf("%d added, %d removed, %d modified", len(changes.Added), len(changes.Removed), len(changes.Modified))
+
+	// The replaced version was kept for rollback; drop the oldest ones
+	if err := uc.pluginRepo.PruneVersions(input.PluginName, config.KeptVersions()); err != nil {
+		result.Message += fmt.Sprintf("; failed to prune old versions: %v", err)
+	}
+
+	// 4. Dependencies only when their declarations changed
+	if changes.DependenciesChanged() {
+		if err := uc.installer.Install(updated); err != nil {
+			result.Message += fmt.Sprintf("; failed to reinstall dependencies: %v", err)
+			return result, nil
+		}
+		result.DepsReinstalled = true
+	}
+	return result, nil
+}
+
+// refreshLink handles "updating" a linked plugin: its code is always
+// current, but edits to plugin.yml may ask for permissions to approve.
+func (uc *UpdatePluginUseCase) refreshLink(plugin *entities.Plugin, input UpdatePluginInput) (dto.UpdatePluginResult, error) {
+	result := dto.UpdatePluginResult{
+		PluginName: plugin.Name,
+		Plugin:     toPluginInfo(plugin),
+		Status:     "linked",
+		Message:    "linked to " + plugin.Source + "; edits apply directly",
+		Success:    true,
+	}
+
+	fail := func(err error) (dto.UpdatePluginResult, error) {
+		result.Status = "failed"
+		result.Success = false
+		result.Message = err.Error()
+		return result, err
+	}
+
+	state, err := uc.stateStore.Read(plugin.Name)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read plugin state: %w", err))
+	}
+	consent := state.Consent
+	if input.Approved {
+		consent = entities.NewConsent(fromPermissionsInfo(input.Granted))
+	}
+	if !consent.Covers(plugin.Permissions) {
+		result.Status = "needs_consent"
+		result.Success = false
+		result.NeedsConsent = true
+		result.Message = "new permissions require approval"
+		return result, nil
+	}
+	if consent != state.Consent {
+		if err := uc.recordConsent(plugin.Name, consent); err != nil {
+			return fail(fmt.Errorf("failed to record consent: %w", err))
+		}
+		result.Message = "permissions approved"
+	}
+	return result, nil
+}
+
+// manifestChanges describes how plugin.yml differs between two versions.
+func manifestChanges(prev, next *entities.Plugin) []string {
+	var changes []string
+	field := func(name, from, to string) {
+		if from != to {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", name, from, to))
+		}
+	}
+	field("version", prev.Version, next.Version)
+	field("interpreter", prev.Interpreter, next.Interpreter)
+	field("entry", prev.Entry, next.Entry)
+	field("description", prev.Description, next.Description)
+	field("usage", prev.Usage, next.Usage)
+	field("category", prev.Category, next.Category)
+	if !slices.Equal(prev.Tags, next.Tags) {
+		changes = append(changes, fmt.Sprintf("tags: %q -> %q", strings.Join(prev.Tags, ","), strings.Join(next.Tags, ",")))
+	}
+	if prev.Limits != next.Limits {
+		changes = append(changes, "limits changed")
+	}
+	if !slices.Equal(toPermissionsInfo(prev.Permissions).Describe(), toPermissionsInfo(next.Permissions).Describe()) {
+		changes = append(changes, "permissions changed")
+	}
+	return changes
+}
+
+// ExecuteAll updates every installed plugin with a recorded origin. Plugins
+// whose new version needs more permissions are left as they are and
+// reported with NeedsConsent.
+func (uc *UpdatePluginUseCase) ExecuteAll() ([]dto.UpdatePluginResult, error) {
+	plugins, err := uc.pluginRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+
+	results := make([]dto.UpdatePluginResult, 0, len(plugins))
+	for _, plugin := range plugins {
+		res, _ := uc.Execute(UpdatePluginInput{PluginName: plugin.Name})
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// recordConsent stores the permissions the user approved for a plugin.
+func (uc *UpdatePluginUseCase) recordConsent(pluginName string, consent *entities.Consent) error {
+	return uc.stateStore.Update(pluginName, func(state *entities.PluginState) error {
+		state.Consent = consent
+		return nil
+	})
+}
@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"fmt"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/domain/ports"
+)
+
+// VerifyPluginUseCase handles detecting changes made to installed plugins
+// after they were installed.
+type VerifyPluginUseCase struct {
+	pluginRepo ports.PluginRepository
+}
+
+// NewVerifyPluginUseCase creates a new VerifyPluginUseCase.
+func NewVerifyPluginUseCase(pluginRepo ports.PluginRepository) *VerifyPluginUseCase {
+	return &VerifyPluginUseCase{pluginRepo: pluginRepo}
+}
+
+// VerifyPluginInput represents the input for VerifyPluginUseCase.
+type VerifyPluginInput struct {
+	PluginName string
+}
+
+// Execute compares a plugin's files with the checksum recorded when it was
+// installed or last updated.
+func (uc *VerifyPluginUseCase) Execute(input VerifyPluginInput) (dto.VerifyPluginResult, error) {
+	result := dto.VerifyPluginResult{PluginName: input.PluginName, Status: "failed"}
+	fail := func(err error) (dto.VerifyPluginResult, error) {
+		result.Message = err.Error()
+		return result, err
+	}
+
+	if input.PluginName == "" {
+		return fail(fmt.Errorf("plugin name is required"))
+	}
+	plugin, err := uc.pluginRepo.Get(input.PluginName)
+	if err != nil {
+		return fail(err)
+	}
+
+	switch {
+	case plugin.IsLinked():
+		// Linked plugins are edited in place on purpose
+		result.Success = true
+		result.Status = "linked"
+		result.Message = "linked to " + plugin.Source + ", not verified"
+		return result, nil
+	case plugin.Install == nil || plugin.Install.Checksum == "":
+		result.Status = "unrecorded"
+		result.Message = "no checksum recorded; update or re-add the plugin to record one"
+		return result, nil
+	}
+
+	actual, err := uc.pluginRepo.InstalledChecksum(plugin.Name)
+	if err != nil {
+		return fail(fmt.Errorf("failed to hash plugin: %w", err))
+	}
+	result.Expected = plugin.Install.Checksum
+	result.Actual = actual
+	result.Signer = plugin.Install.Signer
+	if actual != plugin.Install.Checksum {
+		result.Status = "modified"
+		result.Message = "files changed since install"
+		return result, nil
+	}
+
+	result.Success = true
+	result.Status = "ok"
+	result.Message = "files match the installed checksum"
+	if result.Signer != "" {
+		result.Message += ", signed by " + result.Signer
+	}
+	return result, nil
+}
+
+// ExecuteAll verifies every installed plugin.
+func (uc *VerifyPluginUseCase) ExecuteAll() ([]dto.VerifyPluginResult, error) {
+	plugins, err := uc.pluginRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+
+	results := make([]dto.VerifyPluginResult, 0, len(plugins))
+	for _, plugin := range plugins {
+		res, _ := uc.Execute(VerifyPluginInput{PluginName: plugin.Name})
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Checksum computes the content hash of a plugin directory, the message a
+// plugin author signs into plugin.sig.
+func (uc *VerifyPluginUseCase) Checksum(dir string) (string, error) {
+	return uc.pluginRepo.Checksum(dir)
+}
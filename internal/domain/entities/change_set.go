@@ -0,0 +1,49 @@
+package entities
+
+import "path"
+
+// dependencyFiles are the files whose changes require reinstalling a
+// plugin's dependencies, relative to the plugin directory.
+var dependencyFiles = map[string]bool{
+	"requirements.txt":  true,
+	"package.json":      true,
+	"package-lock.json": true,
+	"DESCRIPTION":       true,
+	"renv.lock":         true,
+}
+
+// DependencySnapshotFile records, inside an installed plugin copy, the exact
+// dependency versions installed for it, so a rollback can restore them. It
+// is kod's own metadata, like the install record: never copied, hashed or
+// compared.
+const DependencySnapshotFile = ".kodkafa-deps.json"
+
+// ChangeSet lists the files an update adds, removes or modifies, as
+// slash-separated paths relative to the plugin directory.
+type ChangeSet struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+// IsEmpty reports whether the update changes no files.
+func (c ChangeSet) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// DependenciesChanged reports whether any dependency file changed.
+func (c ChangeSet) DependenciesChanged() bool {
+	for _, files := range [][]string{c.Added, c.Removed, c.Modified} {
+		for _, f := range files {
+			if IsDependencyFile(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsDependencyFile reports whether a plugin-relative path declares dependencies.
+func IsDependencyFile(p string) bool {
+	return path.Dir(p) == "." && dependencyFiles[p]
+}
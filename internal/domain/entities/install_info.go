@@ -0,0 +1,35 @@
+package entities
+
+import "time"
+
+// InstallInfo records where an installed plugin's code came from.
+type InstallInfo struct {
+	Origin      string // clone URL or absolute local path
+	Ref         string // pinned ref, or the default branch it was cloned from
+	Commit      string // resolved commit SHA, git sources only
+	Subdir      string // plugin directory inside the source
+	Version     string // plugin.yml version at install time
+	Linked      bool   // registered by reference: Origin/Subdir is used in place
+	Checksum    string // content hash of the installed files, e.g. "sha256:..."
+	Signer      string // keyring principal whose signature was verified, if signed
+	InstalledAt time.Time
+}
+
+// PluginVersion is a previously installed copy of a plugin kept for rollback.
+type PluginVersion struct {
+	ID     string // directory name under .versions
+	Plugin Plugin
+}
+
+// Label names the version for display: the plugin.yml version, the short
+// commit, or the ID as a last resort.
+func (v PluginVersion) Label() string {
+	switch {
+	case v.Plugin.Version != "":
+		return v.Plugin.Version
+	case v.Plugin.Install != nil && len(v.Plugin.Install.Commit) >= 7:
+		return v.Plugin.Install.Commit[:7]
+	default:
+		return v.ID
+	}
+}
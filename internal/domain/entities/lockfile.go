@@ -0,0 +1,56 @@
+package entities
+
+// LockfileVersion is the format version written to kod.lock.
+const LockfileVersion = 1
+
+// Lockfile pins a whole set of plugins so it can be reproduced elsewhere.
+type Lockfile struct {
+	Version int
+	Plugins []LockedPlugin
+}
+
+// LockedPlugin is one plugin of a Lockfile.
+type LockedPlugin struct {
+	Name     string
+	Origin   string // as in InstallInfo.Origin
+	Ref      string
+	Commit   string // git sources only; takes precedence over Ref
+	Subdir   string
+	Checksum string // content hash the installed files must have
+}
+
+// Pin returns the ref to check out: the locked commit, else the locked ref.
+// It is empty for origins that are not git repositories.
+func (l LockedPlugin) Pin() string {
+	if src, err := ParseSource(l.Origin); err != nil || !src.IsGit() {
+		return ""
+	}
+	if l.Commit != "" {
+		return l.Commit
+	}
+	return l.Ref
+}
+
+// Source returns the source string to install the locked plugin from.
+func (l LockedPlugin) Source() string {
+	if pin := l.Pin(); pin != "" {
+		return l.Origin + "@" + pin
+	}
+	return l.Origin
+}
+
+// Matches reports whether an installed plugin is the locked one: same origin
+// and subdir, the same commit for git sources and the same files when both
+// checksums are known.
+func (l LockedPlugin) Matches(install *InstallInfo) bool {
+	if install == nil || install.Origin != l.Origin || install.Subdir != l.Subdir {
+		return false
+	}
+	if l.Commit != "" && install.Commit != l.Commit {
+		return false
+	}
+	if l.Checksum != "" && install.Checksum != "" && install.Checksum != l.Checksum {
+		return false
+	}
+	return true
+}
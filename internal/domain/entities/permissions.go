@@ -0,0 +1,81 @@
+package entities
+
+import (
+	"slices"
+	"time"
+)
+
+// Permissions describes what a plugin asks to be allowed to do at run time.
+// A plugin without a permissions block is unrestricted, represented by a nil
+// *Permissions.
+type Permissions struct {
+	// Sandbox runs the plugin in an isolated environment (Linux namespaces):
+	// read-only root, writable plugin data dir and caller cwd only.
+	Sandbox bool
+	// Network allows network access from inside the sandbox. Outside the
+	// sandbox nothing can deny it.
+	Network bool
+	// Filesystem lists extra writable paths inside the sandbox.
+	Filesystem []string
+	// Env lists environment variables passed through to the plugin.
+	Env []string
+	// Secrets lists sensitive environment variables (tokens, keys) passed through.
+	Secrets []string
+	// Subprocesses declares that the plugin spawns other programs. It is
+	// advisory: ResourceLimits.Processes is what actually caps them.
+	Subprocesses bool
+}
+
+// Covers reports whether the granted permissions p include everything in req.
+func (p Permissions) Covers(req Permissions) bool {
+	// Running outside the sandbox is the widest possible grant.
+	if !req.Sandbox && p.Sandbox {
+		return false
+	}
+	if p.Sandbox {
+		if req.Network && !p.Network {
+			return false
+		}
+		if !isSubset(req.Filesystem, p.Filesystem) {
+			return false
+		}
+	}
+	if req.Subprocesses && !p.Subprocesses {
+		return false
+	}
+	return isSubset(req.Env, p.Env) && isSubset(req.Secrets, p.Secrets)
+}
+
+// Consent records the permissions a user approved for a plugin.
+type Consent struct {
+	Permissions *Permissions // nil: approved to run unrestricted
+	GrantedAt   time.Time
+}
+
+// NewConsent records approval of the given permissions now.
+func NewConsent(granted *Permissions) *Consent {
+	return &Consent{Permissions: granted, GrantedAt: time.Now()}
+}
+
+// Covers reports whether the consent includes the requested permissions.
+func (c *Consent) Covers(requested *Permissions) bool {
+	if c == nil {
+		return false
+	}
+	if c.Permissions == nil {
+		return true
+	}
+	if requested == nil {
+		return false
+	}
+	return c.Permissions.Covers(*requested)
+}
+
+func isSubset(items, set []string) bool {
+	for _, item := range items {
+		if !slices.Contains(set, item) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package entities
+
+import "testing"
+
+func TestPermissionsCovers(t *testing.T) {
+	sandboxed := Permissions{
+		Sandbox:    true,
+		Network:    true,
+		Filesystem: []string{"~/backups", "/tmp/out"},
+		Env:        []string{"PGHOST", "PGUSER"},
+		Secrets:    []string{"PGPASSWORD"},
+	}
+	tests := []struct {
+		name    string
+		granted Permissions
+		req     Permissions
+		want    bool
+	}{
+		{name: "nothing requested", granted: Permissions{}, req: Permissions{}, want: true},
+		{name: "same permissions", granted: sandboxed, req: sandboxed, want: true},
+		{
+			name:    "fewer permissions",
+			granted: sandboxed,
+			req:     Permissions{Sandbox: true, Filesystem: []string{"/tmp/out"}, Env: []string{"PGHOST"}},
+			want:    true,
+		},
+		{name: "sandboxed grant, unsandboxed request", granted: sandboxed, req: Permissions{}, want: false},
+		{name: "unsandboxed grant, sandboxed request", granted: Permissions{}, req: Permissions{Sandbox: true}, want: true},
+		{
+			name:    "unsandboxed grant ignores network and paths",
+			granted: Permissions{},
+			req:     Permissions{Sandbox: true, Network: true, Filesystem: []string{"/"}},
+			want:    true,
+		},
+		{
+			name:    "network not granted",
+			granted: Permissions{Sandbox: true},
+			req:     Permissions{Sandbox: true, Network: true},
+			want:    false,
+		},
+		{
+			name:    "extra writable path",
+			granted: sandboxed,
+			req:     Permissions{Sandbox: true, Filesystem: []string{"/etc"}},
+			want:    false,
+		},
+		{
+			name:    "extra environment variable",
+			granted: Permissions{Env: []string{"PGHOST"}},
+			req:     Permissions{Env: []string{"PGHOST", "AWS_PROFILE"}},
+			want:    false,
+		},
+		{
+			name:    "extra secret",
+			granted: sandboxed,
+			req:     Permissions{Sandbox: true, Secrets: []string{"AWS_SECRET_ACCESS_KEY"}},
+			want:    false,
+		},
+		{
+			name:    "secret granted only as env",
+			granted: Permissions{Env: []string{"TOKEN"}},
+			req:     Permissions{Secrets: []string{"TOKEN"}},
+			want:    false,
+		},
+		{name: "subprocesses not granted", granted: Permissions{}, req: Permissions{Subprocesses: true}, want: false},
+		{name: "subprocesses granted", granted: Permissions{Subprocesses: true}, req: Permissions{Subprocesses: true}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.granted.Covers(tt.req); got != tt.want {
+				t.Errorf("Covers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsentCovers(t *testing.T) {
+	sandbox := &Permissions{Sandbox: true, Env: []string{"HOME"}}
+	tests := []struct {
+		name      string
+		consent   *Consent
+		requested *Permissions
+		want      bool
+	}{
+		{name: "no consent", consent: nil, requested: sandbox, want: false},
+		{name: "no consent, unrestricted plugin", consent: nil, requested: nil, want: false},
+		{name: "unrestricted consent", consent: NewConsent(nil), requested: sandbox, want: true},
+		{name: "unrestricted consent, unrestricted plugin", consent: NewConsent(nil), requested: nil, want: true},
+		{name: "restricted consent, unrestricted plugin", consent: NewConsent(sandbox), requested: nil, want: false},
+		{name: "restricted consent", consent: NewConsent(sandbox), requested: &Permissions{Sandbox: true}, want: true},
+		{
+			name:      "restricted consent, wider request",
+			consent:   NewConsent(sandbox),
+			requested: &Permissions{Sandbox: true, Network: true},
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.consent.Covers(tt.requested); got != tt.want {
+				t.Errorf("Covers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
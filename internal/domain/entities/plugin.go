@@ -1,6 +1,10 @@
 package entities
 
-import "time"
+import (
+	"slices"
+	"strings"
+	"time"
+)
 
 // Plugin represents a registered plugin with its metadata.
 type Plugin struct {
@@ -9,6 +13,46 @@ type Plugin struct {
 	Description string
 	Entry       string
 	Usage       string
+	Version     string   // from plugin.yml, may be empty
+	Tags        []string // from plugin.yml, lower case
+	Category    string   // from plugin.yml, may be empty
 	Source      string
 	AddedAt     time.Time
+	Limits      ResourceLimits
+	Permissions *Permissions // nil when plugin.yml declares none
+	Install     *InstallInfo // nil for plugins installed before it was recorded
+	Project     string       // name of the project declaring the plugin; empty for global plugins
+}
+
+// IsValidPluginName reports whether name can be used as a plugin name:
+// letters, digits, '-' and '_'. The name is a directory under plugins/, so
+// separators, "." and ".." are refused.
+func IsValidPluginName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// IsLinked reports whether the plugin runs from its source directory instead
+// of an installed copy.
+func (p *Plugin) IsLinked() bool {
+	return p.Install != nil && p.Install.Linked
+}
+
+// NormalizeTags lower-cases and trims tags, dropping empty and repeated ones.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
 }
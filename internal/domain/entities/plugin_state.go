@@ -9,16 +9,21 @@ type PluginState struct {
 	LastExecutedAt time.Time
 	RunCount       int
 	History        []RunRecord
-	MaxHistorySize int
+	MaxHistorySize int      // not persisted; set from the history_size config
+	Consent        *Consent // nil for plugins installed before consent was recorded
+	Tags           []string // assigned locally with `kod tag`, lower case
 }
 
+// DefaultHistorySize bounds run history when history_size is not configured.
+const DefaultHistorySize = 50
+
 // NewPluginState creates a new PluginState with default max history size.
 func NewPluginState(pluginName string) *PluginState {
 	return &PluginState{
 		PluginName:     pluginName,
 		AddedAt:        time.Now(),
 		History:        make([]RunRecord, 0),
-		MaxHistorySize: 50, // default, configurable
+		MaxHistorySize: DefaultHistorySize, // history_size from config applies when recording runs
 	}
 }
 
@@ -43,6 +48,35 @@ func (ps *PluginState) AddRunRecord(record RunRecord) {
 	}
 }
 
+// UpdateRunRecord replaces the history entry started at the same time as
+// record, e.g. to finish a running one. It reports false when the entry is
+// gone, superseded by a later run with the same args or trimmed.
+func (ps *PluginState) UpdateRunRecord(record RunRecord) bool {
+	for i := len(ps.History) - 1; i >= 0; i-- {
+		if ps.History[i].Timestamp.Equal(record.Timestamp) {
+			ps.History[i] = record
+			return true
+		}
+	}
+	return false
+}
+
+// LastFailure returns when the most recent failed run in the history
+// started, or the zero time.
+func (ps *PluginState) LastFailure() time.Time {
+	for i := len(ps.History) - 1; i >= 0; i-- {
+		if ps.History[i].Failed() {
+			return ps.History[i].Timestamp
+		}
+	}
+	return time.Time{}
+}
+
+// LastRunFailed reports whether the most recent run in the history failed.
+func (ps *PluginState) LastRunFailed() bool {
+	return len(ps.History) > 0 && ps.History[len(ps.History)-1].Failed()
+}
+
 // GetMostRecentArgs returns the args from the most recent run, or empty string.
 func (ps *PluginState) GetMostRecentArgs() string {
 	if len(ps.History) == 0 {
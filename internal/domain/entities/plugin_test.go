@@ -0,0 +1,27 @@
+package entities
+
+import "testing"
+
+func TestIsValidPluginName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"backup", true},
+		{"delta-db", true},
+		{"pg_dump2", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../x", false},
+		{"a/b", false},
+		{`a\b`, false},
+		{"tool.py", false},
+		{"my tool", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidPluginName(tt.name); got != tt.want {
+			t.Errorf("IsValidPluginName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
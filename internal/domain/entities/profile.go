@@ -0,0 +1,14 @@
+package entities
+
+import "regexp"
+
+// DefaultProfile is the profile that lives directly in the KODKAFA home.
+const DefaultProfile = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// IsValidProfileName reports whether name can be used as a profile name:
+// letters, digits, '-' and '_', not starting with a separator.
+func IsValidProfileName(name string) bool {
+	return profileNamePattern.MatchString(name)
+}
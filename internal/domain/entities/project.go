@@ -0,0 +1,21 @@
+package entities
+
+// ProjectFile is the manifest a repository uses to declare its plugins.
+const ProjectFile = ".kodkafa.yml"
+
+// Project is a repository declaring project-specific plugins in a
+// .kodkafa.yml at its root.
+type Project struct {
+	Name    string // from the manifest, or the root directory name
+	Root    string // absolute path of the directory holding .kodkafa.yml
+	ID      string // stable identifier of Root, used to keep its state apart
+	Plugins []ProjectPlugin
+}
+
+// ProjectPlugin is one plugin declared by a project.
+type ProjectPlugin struct {
+	// Source is a path relative to the project root, linked in place, or
+	// any remote source `kod add` accepts, installed for the project only.
+	Source string
+	Subdir string
+}
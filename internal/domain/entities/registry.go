@@ -0,0 +1,82 @@
+package entities
+
+import "strings"
+
+// RegistryEntry is a plugin listed in a registry index.
+type RegistryEntry struct {
+	Name        string
+	Description string
+	Tags        []string
+	Source      string // git URL, archive URL or path to install from
+	Subdir      string // plugin directory inside Source
+	Versions    []RegistryVersion
+	Registry    string // index the entry was listed in
+}
+
+// RegistryVersion is a published version of a registry entry.
+type RegistryVersion struct {
+	Version  string
+	Ref      string // git ref to check out; defaults to Version
+	Source   string // overrides the entry's Source, e.g. a release archive
+	Checksum string // expected content hash of the installed files
+}
+
+// Matches reports whether the entry's name, description or tags contain
+// query, ignoring case. An empty query matches everything.
+func (e RegistryEntry) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	if strings.Contains(strings.ToLower(e.Name), query) || strings.Contains(strings.ToLower(e.Description), query) {
+		return true
+	}
+	for _, tag := range e.Tags {
+		if strings.Contains(strings.ToLower(tag), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Latest returns the newest version; versions are listed newest first.
+func (e RegistryEntry) Latest() (RegistryVersion, bool) {
+	if len(e.Versions) == 0 {
+		return RegistryVersion{}, false
+	}
+	return e.Versions[0], true
+}
+
+// Resolve returns the source string to install the given version from, in
+// the form ParseSource accepts, and its expected checksum. An empty version
+// selects the latest one, or the entry's source as is if it lists none.
+func (e RegistryEntry) Resolve(version string) (source, checksum string, ok bool) {
+	v, found := e.Latest()
+	if version != "" {
+		found = false
+		for _, candidate := range e.Versions {
+			if candidate.Version == version {
+				v, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			return "", "", false
+		}
+	}
+
+	source = e.Source
+	if v.Source != "" {
+		source = v.Source
+	}
+	ref := v.Ref
+	if ref == "" {
+		ref = v.Version
+	}
+	if found && ref != "" {
+		if src, err := ParseSource(source); err == nil && src.IsGit() && src.Ref == "" {
+			source += "@" + ref
+		}
+	}
+	return source, v.Checksum, source != ""
+}
@@ -0,0 +1,124 @@
+package entities
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResourceLimits caps the resources a plugin process may consume.
+// A zero value for any field means "unlimited".
+type ResourceLimits struct {
+	MaxMemory int64 // bytes
+	CPUTime   time.Duration
+	OpenFiles int
+	Processes int
+}
+
+// IsZero reports whether no limit is set.
+func (l ResourceLimits) IsZero() bool {
+	return l == ResourceLimits{}
+}
+
+// Within returns the limits clamped to the given ceiling.
+// Fields unset on either side take the value of the other.
+func (l ResourceLimits) Within(ceiling ResourceLimits) ResourceLimits {
+	return ResourceLimits{
+		MaxMemory: minNonZero(l.MaxMemory, ceiling.MaxMemory),
+		CPUTime:   time.Duration(minNonZero(int64(l.CPUTime), int64(ceiling.CPUTime))),
+		OpenFiles: int(minNonZero(int64(l.OpenFiles), int64(ceiling.OpenFiles))),
+		Processes: int(minNonZero(int64(l.Processes), int64(ceiling.Processes))),
+	}
+}
+
+// ParseResourceLimits builds ResourceLimits from their textual form,
+// e.g. maxMemory "512M" and cpuTime "30s".
+func ParseResourceLimits(maxMemory, cpuTime string, openFiles, processes int) (ResourceLimits, error) {
+	var limits ResourceLimits
+
+	if maxMemory != "" {
+		size, err := ParseByteSize(maxMemory)
+		if err != nil {
+			return limits, fmt.Errorf("invalid max_memory: %w", err)
+		}
+		limits.MaxMemory = size
+	}
+
+	if cpuTime != "" {
+		d, err := time.ParseDuration(cpuTime)
+		if err != nil {
+			return limits, fmt.Errorf("invalid cpu_time: %w", err)
+		}
+		if d < 0 {
+			return limits, fmt.Errorf("invalid cpu_time: must not be negative")
+		}
+		limits.CPUTime = d
+	}
+
+	if openFiles < 0 {
+		return limits, fmt.Errorf("invalid open_files: must not be negative")
+	}
+	if processes < 0 {
+		return limits, fmt.Errorf("invalid processes: must not be negative")
+	}
+	limits.OpenFiles = openFiles
+	limits.Processes = processes
+
+	return limits, nil
+}
+
+// ParseByteSize parses sizes like "512", "64K", "512M" or "2G" (binary units).
+func ParseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	s = strings.TrimSuffix(s, "B")
+	s = strings.TrimSuffix(s, "I") // accept Ki/Mi/Gi
+
+	multiplier := int64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			s = s[:n-1]
+		}
+	}
+
+	value, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%q is not a valid size", s)
+	}
+	return value * multiplier, nil
+}
+
+// FormatByteSize renders a byte count using the largest whole binary unit.
+func FormatByteSize(n int64) string {
+	switch {
+	case n >= 1<<30 && n%(1<<30) == 0:
+		return fmt.Sprintf("%dG", n>>30)
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dM", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dK", n>>10)
+	default:
+		return strconv.FormatInt(n, 10)
+	}
+}
+
+func minNonZero(a, b int64) int64 {
+	switch {
+	case a == 0:
+		return b
+	case b == 0:
+		return a
+	case a < b:
+		return a
+	default:
+		return b
+	}
+}
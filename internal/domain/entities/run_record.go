@@ -19,4 +19,11 @@ type RunRecord struct {
 	ExitCode  int
 	Duration  time.Duration
 	Status    RunStatus
+	Reason    string // why a run failed, e.g. "limit exceeded: memory"
+}
+
+// Failed reports whether the run did not succeed: it was marked failed or
+// finished with a non-zero exit code.
+func (r RunRecord) Failed() bool {
+	return r.Status == RunStatusFailed || r.Status != RunStatusRunning && r.ExitCode != 0
 }
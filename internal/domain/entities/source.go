@@ -0,0 +1,177 @@
+package entities
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SourceKind classifies where a plugin is installed from.
+type SourceKind string
+
+const (
+	SourceLocal SourceKind = "local" // directory on disk
+	SourceFile  SourceKind = "file"  // file:// URL
+	SourceHTTPS SourceKind = "https"
+	SourceHTTP  SourceKind = "http"
+	SourceSSH   SourceKind = "ssh" // ssh:// URL or scp-like git@host:path
+)
+
+// Source is a parsed plugin source as given to `kod add`.
+type Source struct {
+	Raw  string
+	Kind SourceKind
+	Host string // lower-cased host name, remote sources only
+	Path string // repository path for remote sources, filesystem path otherwise
+	// Ref is the tag, branch or commit pinned with "<url>@<ref>"; empty for
+	// the default branch. Git sources only.
+	Ref string
+	// Subdir selects a plugin inside a repository holding several.
+	Subdir string
+	// Checksum is the content hash the installed files must have, e.g. as
+	// published in a registry; empty skips the check.
+	Checksum string
+}
+
+// IsRemote reports whether the source has to be fetched over the network.
+func (s Source) IsRemote() bool {
+	return s.Kind == SourceHTTPS || s.Kind == SourceHTTP || s.Kind == SourceSSH
+}
+
+// IsGit reports whether the source is a git repository to clone.
+func (s Source) IsGit() bool {
+	return (s.IsRemote() || s.Kind == SourceFile) && !s.IsArchive()
+}
+
+// IsArchive reports whether the source is a .tar.gz, .tgz or .zip archive.
+func (s Source) IsArchive() bool {
+	return isArchivePath(s.Path)
+}
+
+func isArchivePath(p string) bool {
+	p = strings.ToLower(p)
+	for _, suffix := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(p, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// URL returns the clone URL of a remote source, without the @ref suffix.
+func (s Source) URL() string {
+	if s.Ref == "" {
+		return s.Raw
+	}
+	return strings.TrimSuffix(s.Raw, "@"+s.Ref)
+}
+
+// RepoPath returns the remote path without leading slash, trailing slash or
+// ".git" suffix, e.g. "kodkafa/kod".
+func (s Source) RepoPath() string {
+	p := strings.Trim(s.Path, "/")
+	return strings.TrimSuffix(p, ".git")
+}
+
+// ParseSource classifies a raw source string. Recognised forms are
+// https:// and http:// URLs, ssh:// URLs, scp-like "[user@]host:path",
+// file:// URLs and plain local paths. Paths ending in .tar.gz, .tgz or .zip
+// are archives; other sources except plain paths are git repositories and
+// may pin a ref with an "@<tag|branch|commit>" suffix.
+func ParseSource(raw string) (Source, error) {
+	src := Source{Raw: raw}
+	if raw == "" {
+		return src, fmt.Errorf("source is required")
+	}
+
+	if scheme, _, ok := strings.Cut(raw, "://"); ok {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return src, fmt.Errorf("invalid source URL: %w", err)
+		}
+		switch strings.ToLower(scheme) {
+		case "https":
+			src.Kind = SourceHTTPS
+		case "http":
+			src.Kind = SourceHTTP
+		case "ssh", "git+ssh":
+			src.Kind = SourceSSH
+		case "file":
+			src.Kind = SourceFile
+			src.Path = u.Path
+			return src, src.splitRef()
+		default:
+			return src, fmt.Errorf("unsupported source scheme: %s", scheme)
+		}
+		src.Host = strings.ToLower(u.Hostname())
+		if src.Host == "" || strings.HasPrefix(src.Host, "-") {
+			return src, fmt.Errorf("invalid source URL: bad host %q", src.Host)
+		}
+		src.Path = u.Path
+		if err := checkSegments(src.Path); err != nil {
+			return src, err
+		}
+		return src, src.splitRef()
+	}
+
+	// scp-like syntax: a colon before the first slash, as git defines it.
+	// A single letter before the colon is a Windows drive, not a host.
+	if i := strings.Index(raw, ":"); i > 1 && !strings.Contains(raw[:i], "/") {
+		hostPart := raw[:i]
+		if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+			hostPart = hostPart[at+1:]
+		}
+		if hostPart == "" || strings.HasPrefix(hostPart, "-") {
+			return src, fmt.Errorf("invalid ssh source: bad host %q", hostPart)
+		}
+		src.Kind = SourceSSH
+		src.Host = strings.ToLower(hostPart)
+		src.Path = raw[i+1:]
+		if err := checkSegments(src.Path); err != nil {
+			return src, err
+		}
+		return src, src.splitRef()
+	}
+
+	src.Kind = SourceLocal
+	src.Path = raw
+	return src, nil
+}
+
+// checkSegments refuses "." and ".." in a remote repository path. The
+// server resolves them, so "kodkafa/../evil/repo" would match trust rules
+// for kodkafa/* while fetching evil/repo. url.Parse has already decoded
+// "%2e%2e" into "..".
+func checkSegments(path string) error {
+	for _, seg := range strings.Split(path, "/") {
+		if seg == "." || seg == ".." {
+			return fmt.Errorf("invalid source path %q: must not contain . or .. segments", path)
+		}
+	}
+	return nil
+}
+
+// splitRef moves an "@ref" suffix of the repository path into Ref.
+// Archives have no refs, so an "@" in their path is part of the name.
+func (s *Source) splitRef() error {
+	if s.IsArchive() {
+		return nil
+	}
+	path, ref, ok := cutLast(s.Path, "@")
+	if !ok {
+		return nil
+	}
+	if ref == "" || strings.HasPrefix(ref, "-") || strings.ContainsAny(ref, " \t\n~^:?*[\\") {
+		return fmt.Errorf("invalid ref %q", ref)
+	}
+	s.Path = path
+	s.Ref = ref
+	return nil
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,116 @@
+package entities
+
+import "testing"
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Source
+		wantErr bool
+	}{
+		{raw: "", wantErr: true},
+		{raw: "./plugins/backup", want: Source{Kind: SourceLocal, Path: "./plugins/backup"}},
+		{raw: "/opt/plugins/backup@2", want: Source{Kind: SourceLocal, Path: "/opt/plugins/backup@2"}},
+		{raw: `C:\plugins\backup`, want: Source{Kind: SourceLocal, Path: `C:\plugins\backup`}},
+		{
+			raw:  "https://GitHub.com/kodkafa/kod.git",
+			want: Source{Kind: SourceHTTPS, Host: "github.com", Path: "/kodkafa/kod.git"},
+		},
+		{
+			raw:  "https://github.com/kodkafa/kod@v1.2.0",
+			want: Source{Kind: SourceHTTPS, Host: "github.com", Path: "/kodkafa/kod", Ref: "v1.2.0"},
+		},
+		{
+			raw:  "http://git.local:8080/team/tools",
+			want: Source{Kind: SourceHTTP, Host: "git.local", Path: "/team/tools"},
+		},
+		{
+			raw:  "ssh://git@gitlab.com/team/tools.git@main",
+			want: Source{Kind: SourceSSH, Host: "gitlab.com", Path: "/team/tools.git", Ref: "main"},
+		},
+		{
+			raw:  "git+ssh://git@gitlab.com/team/tools",
+			want: Source{Kind: SourceSSH, Host: "gitlab.com", Path: "/team/tools"},
+		},
+		{
+			raw:  "git@github.com:kodkafa/kod.git@a1b2c3d",
+			want: Source{Kind: SourceSSH, Host: "github.com", Path: "kodkafa/kod.git", Ref: "a1b2c3d"},
+		},
+		{
+			raw:  "file:///srv/repos/tools@dev",
+			want: Source{Kind: SourceFile, Path: "/srv/repos/tools", Ref: "dev"},
+		},
+		{
+			raw:  "https://example.com/releases/tools@1.0.tar.gz",
+			want: Source{Kind: SourceHTTPS, Host: "example.com", Path: "/releases/tools@1.0.tar.gz"},
+		},
+		{raw: "ftp://example.com/tools", wantErr: true},
+		{raw: "https:///kodkafa/kod", wantErr: true},
+		{raw: "ssh://-oProxyCommand=evil/repo", wantErr: true},
+		{raw: "-oProxyCommand=evil:repo", wantErr: true},
+		{raw: "https://github.com/kodkafa/kod@", wantErr: true},
+		{raw: "https://github.com/kodkafa/kod@--upload-pack=evil", wantErr: true},
+		{raw: "git@github.com:kodkafa/kod@main~1", wantErr: true},
+		{raw: "https://github.com/kodkafa/../evil/repo", wantErr: true},
+		{raw: "https://github.com/kodkafa/%2e%2e/evil/repo", wantErr: true},
+		{raw: "https://github.com/kodkafa/%2E%2E%2Fevil/repo", wantErr: true},
+		{raw: "https://github.com/kodkafa/./kod", wantErr: true},
+		{raw: "ssh://git@github.com/kodkafa/../evil/repo", wantErr: true},
+		{raw: "git@github.com:kodkafa/../evil/repo", wantErr: true},
+		{
+			raw:  "https://github.com/kodkafa/kod..tools",
+			want: Source{Kind: SourceHTTPS, Host: "github.com", Path: "/kodkafa/kod..tools"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseSource(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSource(%q) = %+v, want an error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSource(%q): %v", tt.raw, err)
+			}
+			tt.want.Raw = tt.raw
+			if got != tt.want {
+				t.Errorf("ParseSource(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceKinds(t *testing.T) {
+	tests := []struct {
+		raw                  string
+		remote, git, archive bool
+		url, repoPath        string
+	}{
+		{raw: "/opt/tools", url: "/opt/tools", repoPath: "opt/tools"},
+		{raw: "https://github.com/kodkafa/kod.git@v1", remote: true, git: true, url: "https://github.com/kodkafa/kod.git", repoPath: "kodkafa/kod"},
+		{raw: "git@github.com:kodkafa/kod", remote: true, git: true, url: "git@github.com:kodkafa/kod", repoPath: "kodkafa/kod"},
+		{raw: "file:///srv/repos/tools", git: true, url: "file:///srv/repos/tools", repoPath: "srv/repos/tools"},
+		{raw: "https://example.com/tools.tgz", remote: true, archive: true, url: "https://example.com/tools.tgz", repoPath: "tools.tgz"},
+		{raw: "/opt/tools.ZIP", archive: true, url: "/opt/tools.ZIP", repoPath: "opt/tools.ZIP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			src, err := ParseSource(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseSource(%q): %v", tt.raw, err)
+			}
+			if src.IsRemote() != tt.remote || src.IsGit() != tt.git || src.IsArchive() != tt.archive {
+				t.Errorf("remote, git, archive = %v, %v, %v, want %v, %v, %v",
+					src.IsRemote(), src.IsGit(), src.IsArchive(), tt.remote, tt.git, tt.archive)
+			}
+			if got := src.URL(); got != tt.url {
+				t.Errorf("URL() = %q, want %q", got, tt.url)
+			}
+			if got := src.RepoPath(); got != tt.repoPath {
+				t.Errorf("RepoPath() = %q, want %q", got, tt.repoPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TrustPolicy decides whether a remote plugin source may be installed.
+//
+// Patterns are matched against the source host and, optionally, its
+// repository path:
+//
+//	github.com            exactly this host
+//	*.example.com         any subdomain of example.com
+//	github.com/kodkafa/*  any repository of the kodkafa organisation
+//	github.com/kodkafa/kod exactly this repository
+//
+// Deny patterns win over allow patterns. Local sources are always trusted.
+type TrustPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+// Evaluate reports whether the source is trusted, with a reason when it is not.
+func (p TrustPolicy) Evaluate(src Source) (bool, string) {
+	if !src.IsRemote() {
+		return true, ""
+	}
+	for _, pattern := range p.Deny {
+		if MatchSourcePattern(pattern, src) {
+			return false, fmt.Sprintf("%s is denied by %q", src.Host, pattern)
+		}
+	}
+	for _, pattern := range p.Allow {
+		if MatchSourcePattern(pattern, src) {
+			return true, ""
+		}
+	}
+	return false, fmt.Sprintf("%s is not in trusted_domains", src.Host)
+}
+
+// IsDenied reports whether the source matches a deny pattern.
+func (p TrustPolicy) IsDenied(src Source) bool {
+	for _, pattern := range p.Deny {
+		if MatchSourcePattern(pattern, src) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchSourcePattern matches a single trust pattern against a remote source.
+func MatchSourcePattern(pattern string, src Source) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" || src.Host == "" {
+		return false
+	}
+
+	hostPattern, pathPattern, hasPath := strings.Cut(pattern, "/")
+	if !matchHost(hostPattern, src.Host) {
+		return false
+	}
+	if !hasPath {
+		return true
+	}
+	if checkSegments(src.Path) != nil {
+		return false // the server resolves dot segments to another path
+	}
+
+	repoPath := strings.ToLower(src.RepoPath())
+	pathPattern = strings.TrimSuffix(strings.Trim(pathPattern, "/"), ".git")
+	if prefix, ok := strings.CutSuffix(pathPattern, "*"); ok {
+		return strings.HasPrefix(repoPath, prefix)
+	}
+	return repoPath == pathPattern
+}
+
+func matchHost(pattern, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
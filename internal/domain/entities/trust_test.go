@@ -0,0 +1,84 @@
+package entities
+
+import "testing"
+
+func TestTrustPolicyEvaluate(t *testing.T) {
+	policy := TrustPolicy{
+		Allow: []string{"github.com", "*.corp.example", "gitlab.com/team/*", "bitbucket.org/acme/tools.git"},
+		Deny:  []string{"github.com/evil/*", "mirror.corp.example"},
+	}
+	tests := []struct {
+		raw     string
+		trusted bool
+		denied  bool
+	}{
+		{raw: "./local/plugin", trusted: true},
+		{raw: "file:///srv/repos/tools", trusted: true},
+		{raw: "https://github.com/kodkafa/kod", trusted: true},
+		{raw: "git@GitHub.com:kodkafa/kod.git", trusted: true},
+		{raw: "https://github.com/evil/tool", denied: true},
+		{raw: "https://github.com/Evil/Tool", denied: true},
+		{raw: "https://git.corp.example/infra/tools", trusted: true},
+		{raw: "https://corp.example/infra/tools"},
+		{raw: "https://mirror.corp.example/infra/tools", denied: true},
+		{raw: "https://gitlab.com/team/tools", trusted: true},
+		{raw: "https://gitlab.com/team-b/tools"},
+		{raw: "https://gitlab.com/other/tools"},
+		{raw: "https://bitbucket.org/acme/tools", trusted: true},
+		{raw: "https://bitbucket.org/acme/tools-fork"},
+		{raw: "https://github.com.evil.example/kodkafa/kod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			src, err := ParseSource(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseSource(%q): %v", tt.raw, err)
+			}
+			trusted, reason := policy.Evaluate(src)
+			if trusted != tt.trusted {
+				t.Errorf("Evaluate() = %v (%s), want %v", trusted, reason, tt.trusted)
+			}
+			if trusted != (reason == "") {
+				t.Errorf("Evaluate() reason = %q with trusted %v", reason, trusted)
+			}
+			if denied := policy.IsDenied(src); denied != tt.denied {
+				t.Errorf("IsDenied() = %v, want %v", denied, tt.denied)
+			}
+		})
+	}
+}
+
+func TestMatchSourcePattern(t *testing.T) {
+	src := Source{Kind: SourceHTTPS, Host: "github.com", Path: "/kodkafa/kod.git"}
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"github.com", true},
+		{" GitHub.com ", true},
+		{"gitlab.com", false},
+		{"*.github.com", false},
+		{"github.com/kodkafa/*", true},
+		{"github.com/kodkafa/kod", true},
+		{"github.com/kodkafa/kod.git", true},
+		{"github.com/kodkafa/kod/", true},
+		{"github.com/kodkafa/ko", false},
+		{"github.com/other/*", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := MatchSourcePattern(tt.pattern, src); got != tt.want {
+			t.Errorf("MatchSourcePattern(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+	if MatchSourcePattern("github.com", Source{Kind: SourceLocal, Path: "github.com"}) {
+		t.Error("MatchSourcePattern matched a source without a host")
+	}
+
+	for _, path := range []string{"/kodkafa/../evil/repo", "/kodkafa/./../evil/repo", "kodkafa/.."} {
+		dotted := Source{Kind: SourceHTTPS, Host: "github.com", Path: path}
+		if MatchSourcePattern("github.com/kodkafa/*", dotted) {
+			t.Errorf("MatchSourcePattern matched %q against github.com/kodkafa/*", path)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package entities
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 // UsageEntry represents a single usage entry.
 type UsageEntry struct {
@@ -13,6 +16,7 @@ type UsageEntry struct {
 type UsageStats struct {
 	RecentlyUsed []UsageEntry
 	MostUsed     []UsageEntry
+	Pinned       []UsageEntry // in the order they are shown; Timestamp is when pinned
 	MaxRecent    int
 	MaxMostUsed  int
 }
@@ -103,3 +107,37 @@ func (us *UsageStats) updateMostUsed(pluginName string, limit int) {
 		us.MostUsed = us.MostUsed[:limit]
 	}
 }
+
+// IsPinned reports whether the plugin is pinned.
+func (us *UsageStats) IsPinned(pluginName string) bool {
+	return us.pinIndex(pluginName) >= 0
+}
+
+// Pin adds the plugin to the pinned ones, at the 0-based position or last
+// when position is out of range. A pinned plugin is moved there instead.
+func (us *UsageStats) Pin(pluginName string, position int) {
+	entry := UsageEntry{PluginName: pluginName, Timestamp: time.Now()}
+	if i := us.pinIndex(pluginName); i >= 0 {
+		entry = us.Pinned[i]
+		us.Pinned = slices.Delete(us.Pinned, i, i+1)
+	}
+	if position < 0 || position > len(us.Pinned) {
+		position = len(us.Pinned)
+	}
+	us.Pinned = slices.Insert(us.Pinned, position, entry)
+}
+
+// Unpin removes the plugin from the pinned ones and reports whether it was
+// pinned.
+func (us *UsageStats) Unpin(pluginName string) bool {
+	i := us.pinIndex(pluginName)
+	if i < 0 {
+		return false
+	}
+	us.Pinned = slices.Delete(us.Pinned, i, i+1)
+	return true
+}
+
+func (us *UsageStats) pinIndex(pluginName string) int {
+	return slices.IndexFunc(us.Pinned, func(e UsageEntry) bool { return e.PluginName == pluginName })
+}
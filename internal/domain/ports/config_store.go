@@ -1,10 +1,21 @@
 package ports
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"kodkafa/internal/domain/entities"
+)
+
 // Config represents the global configuration.
 type Config struct {
+	SchemaVersion      int               `json:"schema_version"`
 	TrustedDomains     []string          `json:"trusted_domains"`
+	DeniedDomains      []string          `json:"denied_domains"`
 	RuntimePaths       map[string]string `json:"runtime_paths"`
 	SortBy             string            `json:"sort_by"`
+	GroupByCategory    bool              `json:"group_by_category"`
 	ItemsPerPage       int               `json:"items_per_page"`
 	ShowLastRuns       bool              `json:"show_last_runs"`
 	FavLimit           int               `json:"fav_limit"`
@@ -14,6 +25,130 @@ type Config struct {
 	DependencySettings map[string]any    `json:"dependency_settings"`
 	SupportedRuntimes  map[string]string `json:"supported_runtimes"`
 	Splash             bool              `json:"splash"`
+	ResourceLimits     LimitsConfig      `json:"resource_limits"`
+	KeepVersions       *int              `json:"keep_versions,omitempty"` // nil: DefaultKeepVersions
+	WatchLinked        bool              `json:"watch_linked"`
+	SymlinkPolicy      SymlinkPolicy     `json:"symlink_policy"`
+	RequireSignatures  bool              `json:"require_signatures"`
+	Registries         []string          `json:"registries"` // index URLs or paths, searched in order
+	Storage            StorageBackend    `json:"storage"`
+}
+
+// StorageBackend selects where plugin state, run history and usage are kept.
+type StorageBackend string
+
+const (
+	// StorageJSON keeps one JSON file per plugin plus usage.json, with
+	// history bounded by history_size. It is the default.
+	StorageJSON StorageBackend = "json"
+	// StorageSQLite keeps everything in kodkafa.db with unbounded history.
+	StorageSQLite StorageBackend = "sqlite"
+)
+
+// Sort modes for the dashboard's main list (sort_by).
+const (
+	SortByName        = "name"
+	SortByRecent      = "recent"
+	SortByMostUsed    = "most_used"
+	SortByAdded       = "added"
+	SortByInterpreter = "interpreter"
+	SortByFailed      = "last_failure"
+)
+
+// SortModes lists the valid sort_by values in the order the dashboard
+// cycles through them.
+var SortModes = []string{SortByName, SortByRecent, SortByMostUsed, SortByAdded, SortByInterpreter, SortByFailed}
+
+// Orders for the dashboard's top list (last_run_order).
+const (
+	LastRunOrderLast = "last"
+	LastRunOrderMost = "most"
+)
+
+// SymlinkPolicy decides what happens to symlinks in a plugin being installed.
+type SymlinkPolicy string
+
+const (
+	// SymlinkPreserve keeps links that point inside the plugin and refuses
+	// the install if one points outside. It is the default.
+	SymlinkPreserve SymlinkPolicy = "preserve"
+	// SymlinkReject refuses plugins containing any symlink.
+	SymlinkReject SymlinkPolicy = "reject"
+	// SymlinkSkip leaves symlinks out of the installed copy.
+	SymlinkSkip SymlinkPolicy = "skip"
+)
+
+// DefaultKeepVersions is how many previous versions of a plugin are kept
+// for rollback when keep_versions is not set.
+const DefaultKeepVersions = 3
+
+// KeptVersions returns the number of previous plugin versions to keep.
+func (c *Config) KeptVersions() int {
+	if c == nil || c.KeepVersions == nil {
+		return DefaultKeepVersions
+	}
+	return max(*c.KeepVersions, 0)
+}
+
+// FieldError is a configuration value that failed validation. Key is the
+// dotted path of the setting, e.g. "resource_limits.max_memory".
+type FieldError struct {
+	Key string
+	Msg string
+}
+
+func (e FieldError) Error() string {
+	return e.Key + ": " + e.Msg
+}
+
+// Validate checks settings that take one of a fixed set of values or must
+// stay within a range. It returns nil when the configuration is usable.
+func (c *Config) Validate() []FieldError {
+	var errs []FieldError
+	oneOf := func(key, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			errs = append(errs, FieldError{key, fmt.Sprintf("must be one of %s, got %q", strings.Join(allowed, ", "), value)})
+		}
+	}
+	atLeast := func(key string, value, min int) {
+		if value < min {
+			errs = append(errs, FieldError{key, fmt.Sprintf("must be at least %d, got %d", min, value)})
+		}
+	}
+
+	oneOf("sort_by", c.SortBy, SortModes...)
+	oneOf("last_run_order", c.LastRunOrder, LastRunOrderLast, LastRunOrderMost)
+	oneOf("symlink_policy", string(c.SymlinkPolicy), string(SymlinkPreserve), string(SymlinkReject), string(SymlinkSkip))
+	oneOf("storage", string(c.Storage), string(StorageJSON), string(StorageSQLite))
+	atLeast("items_per_page", c.ItemsPerPage, 1)
+	atLeast("fav_limit", c.FavLimit, 0)
+	atLeast("last_run_limit", c.LastRunLimit, 0)
+	atLeast("history_size", c.HistorySize, 0)
+	if c.KeepVersions != nil {
+		atLeast("keep_versions", *c.KeepVersions, 0)
+	}
+	for name, cmd := range c.SupportedRuntimes {
+		if strings.TrimSpace(cmd) == "" {
+			errs = append(errs, FieldError{"supported_runtimes." + name, "must name an interpreter command"})
+		}
+	}
+	if _, err := c.ResourceLimits.Parse(); err != nil {
+		errs = append(errs, FieldError{"resource_limits", err.Error()})
+	}
+	return errs
+}
+
+// LimitsConfig holds resource limits as written in config.json or plugin.yml.
+type LimitsConfig struct {
+	MaxMemory string `json:"max_memory,omitempty" yaml:"max_memory"`
+	CPUTime   string `json:"cpu_time,omitempty" yaml:"cpu_time"`
+	OpenFiles int    `json:"open_files,omitempty" yaml:"open_files"`
+	Processes int    `json:"processes,omitempty" yaml:"processes"`
+}
+
+// Parse converts the textual limits into entities.ResourceLimits.
+func (lc LimitsConfig) Parse() (entities.ResourceLimits, error) {
+	return entities.ParseResourceLimits(lc.MaxMemory, lc.CPUTime, lc.OpenFiles, lc.Processes)
 }
 
 // ConfigStore defines the interface for configuration persistence.
@@ -22,4 +157,15 @@ type ConfigStore interface {
 	Read() (*Config, error)
 	// Write persists the global configuration.
 	Write(config *Config) error
+	// Update applies fn to the current configuration and persists the
+	// result atomically with respect to other updates. A missing
+	// configuration starts from the defaults.
+	Update(fn func(config *Config) error) error
+	// Check reports problems in the stored configuration: warnings for keys
+	// kod does not know, and an error for values it cannot use.
+	Check() (warnings []string, err error)
+	// Defaults returns the configuration used when no setting is changed.
+	Defaults() (*Config, error)
+	// Path returns the location of the configuration file.
+	Path() string
 }
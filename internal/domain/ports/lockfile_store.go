@@ -0,0 +1,11 @@
+package ports
+
+import "kodkafa/internal/domain/entities"
+
+// LockfileStore defines the interface for reading and writing kod.lock files.
+type LockfileStore interface {
+	// Read loads the lockfile at path.
+	Read(path string) (*entities.Lockfile, error)
+	// Write stores the lockfile at path.
+	Write(path string, lock *entities.Lockfile) error
+}
@@ -0,0 +1,9 @@
+package ports
+
+// Migrator upgrades documents persisted by older versions of kod.
+type Migrator interface {
+	// Migrate brings every outdated document up to the current schema,
+	// copying each original into backupDir first, and returns the files it
+	// changed. It fails on documents written by a newer version.
+	Migrate() (migrated []string, backupDir string, err error)
+}
@@ -8,12 +8,48 @@ type PluginRepository interface {
 	List() ([]entities.Plugin, error)
 	// Get returns a plugin by name, or an error if not found.
 	Get(name string) (*entities.Plugin, error)
-	// Add registers a new plugin from a local path or remote URL.
-	Add(source string) (*entities.Plugin, error)
+	// Inspect reads the manifest of a local path or remote URL without installing it.
+	Inspect(source entities.Source) (*entities.Plugin, error)
+	// Stage fetches a plugin from a local path or remote URL and prepares it
+	// for install without installing it. The caller must Discard the result.
+	Stage(source entities.Source) (StagedPlugin, error)
+	// Link registers a local plugin directory by reference, without copying it.
+	Link(source entities.Source) (*entities.Plugin, error)
+	// Update replaces an installed plugin with a fresh copy of source and
+	// reports which files changed. The replaced copy is kept as a version.
+	// Nothing is replaced when no file changed. accept, if not nil, sees the
+	// fetched version before anything is replaced; an error from it aborts
+	// the update and is returned as is.
+	Update(name string, source entities.Source, accept func(next *entities.Plugin) error) (*entities.Plugin, entities.ChangeSet, error)
+	// Versions lists the previous versions kept for a plugin, newest first.
+	Versions(name string) ([]entities.PluginVersion, error)
+	// Rollback makes a kept version active, keeping the current one as a
+	// version in its place, and reports which files changed.
+	Rollback(name, versionID string) (*entities.Plugin, entities.ChangeSet, error)
+	// PruneVersions deletes all but the newest keep versions of a plugin.
+	PruneVersions(name string, keep int) error
 	// Remove removes a plugin by name.
 	Remove(name string) error
 	// RemoveDeps deletes plugin dependencies
 	RemoveDeps(name string) error
+	// Checksum computes the content hash of the plugin source tree in dir,
+	// honouring its .kodignore: the hash its installed copy will have.
+	Checksum(dir string) (string, error)
+	// InstalledChecksum hashes the installed copy of a plugin the way
+	// InstallInfo.Checksum was recorded. Ignore rules inside the copy are not
+	// read, so nothing added to it after install can be hidden.
+	InstalledChecksum(name string) (string, error)
 	// Exists checks if a plugin with the given name exists.
 	Exists(name string) (bool, error)
 }
+
+// StagedPlugin is a fetched plugin waiting to be installed. It is fetched
+// once, so the plugin reviewed before install is the one installed.
+type StagedPlugin interface {
+	// Plugin returns the staged plugin as it will be installed.
+	Plugin() *entities.Plugin
+	// Install moves the staged copy into place.
+	Install() (*entities.Plugin, error)
+	// Discard removes the staged copy. It does nothing after Install.
+	Discard()
+}
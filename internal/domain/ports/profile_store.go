@@ -0,0 +1,16 @@
+package ports
+
+// ProfileStore defines the interface for the named profiles inside the
+// KODKAFA home. Every profile has its own plugins, state and config.
+type ProfileStore interface {
+	// List returns the names of all profiles, the default one first.
+	List() ([]string, error)
+	// Create adds an empty profile; it is initialized on first use.
+	Create(name string) error
+	// Dir returns the base directory of an existing profile.
+	Dir(name string) (string, error)
+	// Active returns the profile used when none is given on the command line.
+	Active() (string, error)
+	// SetActive makes an existing profile the active one.
+	SetActive(name string) error
+}
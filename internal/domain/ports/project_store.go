@@ -0,0 +1,10 @@
+package ports
+
+import "kodkafa/internal/domain/entities"
+
+// ProjectStore defines the interface for discovering project manifests.
+type ProjectStore interface {
+	// Find returns the project whose .kodkafa.yml is in dir or the nearest
+	// parent directory, or nil when there is none.
+	Find(dir string) (*entities.Project, error)
+}
@@ -0,0 +1,9 @@
+package ports
+
+import "kodkafa/internal/domain/entities"
+
+// Registry defines the interface for reading plugin registry indexes.
+type Registry interface {
+	// Fetch loads the index at location, a http(s) URL or a file path.
+	Fetch(location string) ([]entities.RegistryEntry, error)
+}
@@ -18,6 +18,8 @@ type RunResult struct {
 	Duration int64 // nanoseconds
 	Status   string
 	Output   string
+	// LimitExceeded names the resource limit that stopped the process, if any.
+	LimitExceeded string
 }
 
 // OutputChunk represents a chunk of output from a running process.
@@ -8,6 +8,17 @@ type StateStore interface {
 	Read(pluginName string) (*entities.PluginState, error)
 	// Write persists the state for a plugin.
 	Write(state *entities.PluginState) error
+	// Update reads the state for a plugin, or a new one, applies fn and
+	// persists the result as one step that concurrent runs cannot
+	// interleave with. Nothing is written when fn returns an error.
+	Update(pluginName string, fn func(state *entities.PluginState) error) error
 	// Delete removes the state for a plugin.
 	Delete(pluginName string) error
 }
+
+// RunHistoryReader is implemented by state stores that keep every run of a
+// plugin rather than the latest one per set of arguments that Read returns.
+type RunHistoryReader interface {
+	// RunHistory returns every recorded run of a plugin, oldest first.
+	RunHistory(pluginName string) ([]entities.RunRecord, error)
+}
@@ -8,4 +8,7 @@ type UsageStore interface {
 	Read() (*entities.UsageStats, error)
 	// Write persists the global usage statistics.
 	Write(stats *entities.UsageStats) error
+	// Update applies fn to the current statistics and persists the result
+	// atomically with respect to other updates.
+	Update(fn func(stats *entities.UsageStats) error) error
 }
@@ -0,0 +1,316 @@
+//go:build linux
+
+package exec
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"kodkafa/internal/domain/entities"
+
+	"golang.org/x/sys/unix"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// LimitInitArg is the hidden argument that turns the kod binary into a small
+// stub which sets the plugin's rlimits on itself and then execs the plugin, so
+// the limits are in place before the plugin's first instruction runs.
+const LimitInitArg = "__limit-init"
+
+const limitSpecEnv = "KODKAFA_LIMIT_SPEC"
+
+// limitSpec carries the rlimits from the runner to the stub (or to the
+// sandbox init process, which applies them right before its own exec).
+type limitSpec struct {
+	CPUSeconds   uint64 `json:"cpu_seconds,omitempty"`
+	OpenFiles    uint64 `json:"open_files,omitempty"`
+	AddressSpace uint64 `json:"address_space,omitempty"` // without a cgroup only
+	Processes    uint64 `json:"processes,omitempty"`     // without a cgroup only
+}
+
+// limiter enforces entities.ResourceLimits on a single plugin process.
+// Memory and process counts are enforced through a cgroup v2 child group
+// when one can be created, which also covers every process the plugin
+// starts, and through RLIMIT_AS and RLIMIT_NPROC otherwise. CPU time and
+// open files are always per-process rlimits set before exec.
+type limiter struct {
+	limits    entities.ResourceLimits
+	cgroupDir string
+	cgroupFD  int
+}
+
+// newLimiter prepares the limits for a run. Memory and process limits use a
+// cgroup where kod's own cgroup delegates the memory and pids controllers,
+// and fall back to rlimits everywhere else, such as most desktop and CI
+// sessions.
+func newLimiter(pluginName string, limits entities.ResourceLimits) (*limiter, error) {
+	l := &limiter{limits: limits, cgroupFD: -1}
+	if limits.MaxMemory > 0 || limits.Processes > 0 {
+		// On failure configure hands the limits to the stub as rlimits
+		_ = l.setupCgroup(pluginName)
+	}
+	return l, nil
+}
+
+// setupCgroup creates a child cgroup for the run below kod's own cgroup,
+// which must enable the needed controllers for its children.
+func (l *limiter) setupCgroup(pluginName string) error {
+	current, err := currentCgroup()
+	if err != nil {
+		return err
+	}
+	parent := filepath.Join(cgroupRoot, current)
+	var controllers []string
+	if l.limits.MaxMemory > 0 {
+		controllers = append(controllers, "memory")
+	}
+	if l.limits.Processes > 0 {
+		controllers = append(controllers, "pids")
+	}
+	if err := checkControllers(parent, controllers); err != nil {
+		return err
+	}
+
+	dir := filepath.Join(parent, fmt.Sprintf("kodkafa-%s-%d", pluginName, time.Now().UnixNano()))
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+
+	if l.limits.MaxMemory > 0 {
+		if err := writeCgroupFile(dir, "memory.max", strconv.FormatInt(l.limits.MaxMemory, 10)); err != nil {
+			_ = os.Remove(dir)
+			return fmt.Errorf("memory controller unavailable: %w", err)
+		}
+		_ = writeCgroupFile(dir, "memory.swap.max", "0")
+	}
+	if l.limits.Processes > 0 {
+		if err := writeCgroupFile(dir, "pids.max", strconv.Itoa(l.limits.Processes)); err != nil {
+			_ = os.Remove(dir)
+			return fmt.Errorf("pids controller unavailable: %w", err)
+		}
+	}
+
+	fd, err := unix.Open(dir, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		_ = os.Remove(dir)
+		return err
+	}
+	l.cgroupDir = dir
+	l.cgroupFD = fd
+	return nil
+}
+
+// configure prepares the command before it is started: the child is cloned
+// straight into the cgroup, and rlimits are handed to the process that execs
+// the plugin. Sandboxed commands already re-enter kod and apply them there;
+// everything else is routed through the LimitInitArg stub.
+func (l *limiter) configure(cmd *exec.Cmd) error {
+	if l.cgroupFD >= 0 {
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = l.cgroupFD
+	}
+
+	spec := limitSpec{OpenFiles: uint64(l.limits.OpenFiles)}
+	if l.limits.CPUTime > 0 {
+		spec.CPUSeconds = uint64((l.limits.CPUTime + time.Second - 1) / time.Second)
+	}
+	if l.cgroupFD < 0 {
+		spec.AddressSpace = uint64(l.limits.MaxMemory)
+		spec.Processes = uint64(l.limits.Processes)
+	}
+	if spec == (limitSpec{}) {
+		return nil
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, limitSpecEnv+"="+string(data))
+
+	if len(cmd.Args) > 1 && cmd.Args[1] == SandboxInitArg {
+		return nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("limits: cannot locate kod binary: %w", err)
+	}
+	cmd.Args = append([]string{self, LimitInitArg, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = self
+	return nil
+}
+
+// LimitInit runs in the stub started by configure. It applies the rlimits
+// from the spec and replaces itself with the plugin command in args.
+// It only returns on error.
+func LimitInit(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("limits: missing command")
+	}
+	if err := applyLimitSpec(); err != nil {
+		return err
+	}
+	return syscall.Exec(args[0], args, os.Environ())
+}
+
+// applyLimitSpec sets the rlimits passed through the environment, if any, on
+// the current process. They survive the exec that follows.
+func applyLimitSpec() error {
+	raw, ok := os.LookupEnv(limitSpecEnv)
+	if !ok {
+		return nil
+	}
+	os.Unsetenv(limitSpecEnv)
+
+	var spec limitSpec
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		return fmt.Errorf("limits: invalid spec: %w", err)
+	}
+	if spec.CPUSeconds > 0 {
+		// Soft limit delivers SIGXCPU, the hard limit a second later SIGKILL.
+		if err := syscall.Setrlimit(syscall.RLIMIT_CPU, &syscall.Rlimit{Cur: spec.CPUSeconds, Max: spec.CPUSeconds + 1}); err != nil {
+			return fmt.Errorf("failed to set cpu time limit: %w", err)
+		}
+	}
+	if spec.OpenFiles > 0 {
+		// syscall.Setrlimit, unlike unix.Setrlimit, keeps Go from restoring
+		// the original open files limit on exec.
+		if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &syscall.Rlimit{Cur: spec.OpenFiles, Max: spec.OpenFiles}); err != nil {
+			return fmt.Errorf("failed to set open files limit: %w", err)
+		}
+	}
+	if spec.AddressSpace > 0 {
+		// Caps virtual memory, which exceeds what the process actually uses
+		if err := syscall.Setrlimit(syscall.RLIMIT_AS, &syscall.Rlimit{Cur: spec.AddressSpace, Max: spec.AddressSpace}); err != nil {
+			return fmt.Errorf("failed to set memory limit: %w", err)
+		}
+	}
+	if spec.Processes > 0 {
+		// Counts every process of the user, not only the plugin's
+		if err := syscall.Setrlimit(unix.RLIMIT_NPROC, &syscall.Rlimit{Cur: spec.Processes, Max: spec.Processes}); err != nil {
+			return fmt.Errorf("failed to set process limit: %w", err)
+		}
+	}
+	return nil
+}
+
+// exceeded reports which limit, if any, terminated the process.
+func (l *limiter) exceeded(state *os.ProcessState) string {
+	if l.limits.IsZero() || state == nil {
+		return ""
+	}
+
+	if l.cgroupDir != "" {
+		if l.limits.MaxMemory > 0 && cgroupEventCount(l.cgroupDir, "memory.events", "oom_kill") > 0 {
+			return fmt.Sprintf("memory (%s)", entities.FormatByteSize(l.limits.MaxMemory))
+		}
+		if l.limits.Processes > 0 && cgroupEventCount(l.cgroupDir, "pids.events", "max") > 0 {
+			return fmt.Sprintf("processes (%d)", l.limits.Processes)
+		}
+	}
+
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return ""
+	}
+	if l.limits.CPUTime > 0 && (ws.Signal() == syscall.SIGXCPU || (ws.Signal() == syscall.SIGKILL && state.UserTime()+state.SystemTime() >= l.limits.CPUTime)) {
+		return fmt.Sprintf("cpu time (%s)", l.limits.CPUTime)
+	}
+	return ""
+}
+
+// cleanup releases the cgroup once the process has exited.
+func (l *limiter) cleanup() {
+	if l.cgroupFD >= 0 {
+		_ = unix.Close(l.cgroupFD)
+		l.cgroupFD = -1
+	}
+	if l.cgroupDir != "" {
+		_ = os.Remove(l.cgroupDir)
+		l.cgroupDir = ""
+	}
+}
+
+// currentCgroup returns the unified (v2) cgroup path of this process.
+func currentCgroup() (string, error) {
+	var fs unix.Statfs_t
+	if err := unix.Statfs(cgroupRoot, &fs); err != nil || fs.Type != unix.CGROUP2_SUPER_MAGIC {
+		return "", fmt.Errorf("cgroup v2 not mounted at %s", cgroupRoot)
+	}
+
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("cgroup v2 not available")
+}
+
+// checkControllers reports an error unless the cgroup at dir enables every
+// one of controllers for its children in cgroup.subtree_control; without
+// that, a child's memory.max and pids.max do not exist.
+func checkControllers(dir string, controllers []string) error {
+	data, err := os.ReadFile(filepath.Join(dir, "cgroup.subtree_control"))
+	if err != nil {
+		return err
+	}
+	enabled := strings.Fields(string(data))
+	for _, c := range controllers {
+		if !slices.Contains(enabled, c) {
+			return fmt.Errorf("cgroup %s does not delegate the %s controller", dir, c)
+		}
+	}
+	return nil
+}
+
+// writeCgroupFile writes an existing cgroup interface file; it never creates one.
+func writeCgroupFile(dir, name, value string) error {
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// cgroupEventCount reads a counter such as "oom_kill 1" from a cgroup events file.
+func cgroupEventCount(dir, file, key string) int {
+	f, err := os.Open(filepath.Join(dir, file))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == key {
+			n, _ := strconv.Atoi(fields[1])
+			return n
+		}
+	}
+	return 0
+}
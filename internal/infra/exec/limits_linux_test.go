@@ -0,0 +1,153 @@
+//go:build linux
+
+package exec
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"kodkafa/internal/domain/entities"
+
+	"golang.org/x/sys/unix"
+)
+
+// limitHelperEnv makes TestApplyLimitSpecHelperProcess apply the spec in
+// limitSpecEnv and check the resulting rlimits.
+const limitHelperEnv = "KODKAFA_LIMIT_HELPER"
+
+func TestCheckControllers(t *testing.T) {
+	tests := []struct {
+		name        string
+		subtree     string // cgroup.subtree_control; missing when empty
+		controllers []string
+		wantErr     bool
+	}{
+		{name: "both delegated", subtree: "cpu memory pids\n", controllers: []string{"memory", "pids"}},
+		{name: "memory only needed", subtree: "memory\n", controllers: []string{"memory"}},
+		{name: "pids missing", subtree: "cpu memory\n", controllers: []string{"memory", "pids"}, wantErr: true},
+		{name: "nothing delegated", subtree: "\n", controllers: []string{"memory"}, wantErr: true},
+		{name: "no cgroup", controllers: []string{"pids"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.subtree != "" {
+				if err := os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte(tt.subtree), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := checkControllers(dir, tt.controllers); (err != nil) != tt.wantErr {
+				t.Errorf("checkControllers() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// configuredSpec returns the limit spec configure hands to the stub.
+func configuredSpec(t *testing.T, l *limiter) (limitSpec, *exec.Cmd) {
+	t.Helper()
+	cmd := exec.Command("/bin/true")
+	if err := l.configure(cmd); err != nil {
+		t.Fatalf("configure(): %v", err)
+	}
+	var spec limitSpec
+	for _, kv := range cmd.Env {
+		if raw, ok := strings.CutPrefix(kv, limitSpecEnv+"="); ok {
+			if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return spec, cmd
+}
+
+func TestLimiterFallsBackToRlimits(t *testing.T) {
+	limits := entities.ResourceLimits{MaxMemory: 256 << 20, Processes: 32, CPUTime: 1500 * time.Millisecond}
+
+	l, err := newLimiter("demo", limits)
+	if err != nil {
+		t.Fatalf("newLimiter() = %v; runs must not be refused without a cgroup", err)
+	}
+	defer l.cleanup()
+	if l.cgroupFD >= 0 {
+		t.Skip("this session delegates a cgroup; the fallback is covered below")
+	}
+
+	spec, cmd := configuredSpec(t, l)
+	want := limitSpec{CPUSeconds: 2, AddressSpace: 256 << 20, Processes: 32}
+	if spec != want {
+		t.Errorf("spec = %+v, want %+v", spec, want)
+	}
+	if len(cmd.Args) < 3 || cmd.Args[1] != LimitInitArg || cmd.Args[2] != "/bin/true" {
+		t.Errorf("command not routed through the limit stub: %q", cmd.Args)
+	}
+}
+
+func TestLimiterWithCgroupKeepsRlimitsForCPUAndFiles(t *testing.T) {
+	// A limiter holding a cgroup leaves memory and processes to it
+	l := &limiter{limits: entities.ResourceLimits{MaxMemory: 256 << 20, Processes: 32, OpenFiles: 64}, cgroupFD: 100}
+	spec, cmd := configuredSpec(t, l)
+	if want := (limitSpec{OpenFiles: 64}); spec != want {
+		t.Errorf("spec = %+v, want %+v", spec, want)
+	}
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.UseCgroupFD || cmd.SysProcAttr.CgroupFD != 100 {
+		t.Errorf("command not started in the cgroup: %+v", cmd.SysProcAttr)
+	}
+}
+
+// TestApplyLimitSpecHelperProcess runs in the process started by
+// TestApplyLimitSpec, so the rlimits it sets stay out of the test binary.
+func TestApplyLimitSpecHelperProcess(t *testing.T) {
+	if os.Getenv(limitHelperEnv) == "" {
+		t.Skip("helper process for TestApplyLimitSpec")
+	}
+	var want limitSpec
+	if err := json.Unmarshal([]byte(os.Getenv(limitSpecEnv)), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyLimitSpec(); err != nil {
+		t.Fatalf("applyLimitSpec(): %v", err)
+	}
+	if _, ok := os.LookupEnv(limitSpecEnv); ok {
+		t.Error("the spec is still in the environment")
+	}
+	for _, rl := range []struct {
+		name     string
+		resource int
+		want     uint64
+	}{
+		{"RLIMIT_CPU", unix.RLIMIT_CPU, want.CPUSeconds},
+		{"RLIMIT_NOFILE", unix.RLIMIT_NOFILE, want.OpenFiles},
+		{"RLIMIT_AS", unix.RLIMIT_AS, want.AddressSpace},
+		{"RLIMIT_NPROC", unix.RLIMIT_NPROC, want.Processes},
+	} {
+		var got syscall.Rlimit
+		if err := syscall.Getrlimit(rl.resource, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Cur != rl.want {
+			t.Errorf("%s = %d, want %d", rl.name, got.Cur, rl.want)
+		}
+	}
+}
+
+func TestApplyLimitSpec(t *testing.T) {
+	// Generous values: the helper is a Go program that has to keep running
+	spec := limitSpec{CPUSeconds: 60, OpenFiles: 256, AddressSpace: 64 << 30, Processes: 4096}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestApplyLimitSpecHelperProcess$", "-test.v")
+	cmd.Env = append(os.Environ(), limitHelperEnv+"=1", limitSpecEnv+"="+string(data))
+	out, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "--- PASS: TestApplyLimitSpecHelperProcess") {
+		t.Fatalf("helper process failed: %v\n%s", err, out)
+	}
+}
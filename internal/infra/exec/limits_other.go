@@ -0,0 +1,32 @@
+//go:build !linux
+
+package exec
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"kodkafa/internal/domain/entities"
+)
+
+// limiter is a no-op outside Linux: resource limits are only enforced
+// by the Linux process runner.
+type limiter struct{}
+
+// LimitInitArg is the hidden argument of the rlimit stub used on Linux.
+const LimitInitArg = "__limit-init"
+
+func newLimiter(pluginName string, limits entities.ResourceLimits) (*limiter, error) {
+	return &limiter{}, nil
+}
+
+func (l *limiter) configure(cmd *exec.Cmd) error { return nil }
+
+func LimitInit(args []string) error {
+	return fmt.Errorf("resource limits are only supported on Linux")
+}
+
+func (l *limiter) exceeded(state *os.ProcessState) string { return "" }
+
+func (l *limiter) cleanup() {}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -27,6 +28,7 @@ func (r *ProcessRunner) Run(plugin *entities.Plugin, args string, mode ports.Run
 	var outputBuilder strings.Builder
 
 	var cmd *exec.Cmd
+	var extraEnv []string
 	cmdArgs := parseArgs(args)
 
 	switch plugin.Interpreter {
@@ -35,13 +37,15 @@ func (r *ProcessRunner) Run(plugin *entities.Plugin, args string, mode ports.Run
 		entryPath := filepath.Join(plugin.Source, plugin.Entry)
 		fullArgs := append([]string{entryPath}, cmdArgs...)
 		cmd = exec.Command(pythonPath, fullArgs...)
+		// Bytecode caches written next to the plugin would fail kod verify
+		extraEnv = append(extraEnv, "PYTHONDONTWRITEBYTECODE=1")
 	case "node":
 		nodeCoreDir := filepath.Join(r.baseDir, "core", "node")
 		entryPath := filepath.Join(plugin.Source, plugin.Entry)
 		fullArgs := append([]string{entryPath}, cmdArgs...)
 		cmd = exec.Command("node", fullArgs...)
 		// Set NODE_PATH to use core/node/node_modules
-		cmd.Env = append(os.Environ(), "NODE_PATH="+filepath.Join(nodeCoreDir, "node_modules"))
+		extraEnv = append(extraEnv, "NODE_PATH="+filepath.Join(nodeCoreDir, "node_modules"))
 	case "r":
 		entryPath := filepath.Join(plugin.Source, plugin.Entry)
 		fullArgs := append([]string{entryPath}, cmdArgs...)
@@ -52,11 +56,44 @@ func (r *ProcessRunner) Run(plugin *entities.Plugin, args string, mode ports.Run
 
 	cmd.Dir = plugin.Source
 
+	dataDir := filepath.Join(r.baseDir, "data", plugin.Name)
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create plugin data dir: %w", err)
+	}
+	cmd.Env = append(pluginEnv(plugin.Permissions), extraEnv...)
+	cmd.Env = append(cmd.Env, "KODKAFA_DATA_DIR="+dataDir)
+
+	if plugin.Permissions != nil && plugin.Permissions.Sandbox {
+		if err := sandboxCommand(cmd, plugin.Permissions, sandboxWritablePaths(plugin.Permissions, dataDir)); err != nil {
+			if outputChan != nil {
+				close(outputChan)
+			}
+			return nil, err
+		}
+	}
+
+	lim, err := newLimiter(plugin.Name, plugin.Limits)
+	if err != nil {
+		if outputChan != nil {
+			close(outputChan)
+		}
+		return nil, err
+	}
+	defer lim.cleanup()
+	if err := lim.configure(cmd); err != nil {
+		if outputChan != nil {
+			close(outputChan)
+		}
+		return nil, err
+	}
+
 	if outputChan != nil {
 		stdout, _ := cmd.StdoutPipe()
 		stderr, _ := cmd.StderrPipe()
-		// Start and streaming logic remains same...
-		_ = cmd.Start()
+		if err := r.start(cmd); err != nil {
+			close(outputChan)
+			return nil, err
+		}
 
 		var wg sync.WaitGroup
 		var mu sync.Mutex // Protects outputBuilder
@@ -95,27 +132,31 @@ func (r *ProcessRunner) Run(plugin *entities.Plugin, args string, mode ports.Run
 		}()
 
 		err := cmd.Wait()
-		exitCode := 0
-		if err != nil {
-			if exitError, ok := err.(*exec.ExitError); ok {
-				exitCode = exitError.ExitCode()
-			} else {
-				exitCode = 1
-			}
-		}
-
-		return &ports.RunResult{
-			ExitCode: exitCode,
-			Duration: time.Since(start).Nanoseconds(),
-			Status:   "completed",
-			Output:   outputBuilder.String(),
-		}, nil
+		result := finish(cmd, err, lim, start)
+		result.Output = outputBuilder.String()
+		return result, nil
 	}
 
 	// CLI Direct mode
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	err := cmd.Run()
+	if err := r.start(cmd); err != nil {
+		return nil, err
+	}
+	err = cmd.Wait()
+	return finish(cmd, err, lim, start), nil
+}
+
+// start launches the command; resource limits are already attached to it.
+func (r *ProcessRunner) start(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin: %w", err)
+	}
+	return nil
+}
+
+// finish builds the run result from the exited command.
+func finish(cmd *exec.Cmd, err error, lim *limiter, start time.Time) *ports.RunResult {
 	exitCode := 0
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
@@ -126,10 +167,54 @@ func (r *ProcessRunner) Run(plugin *entities.Plugin, args string, mode ports.Run
 	}
 
 	return &ports.RunResult{
-		ExitCode: exitCode,
-		Duration: time.Since(start).Nanoseconds(),
-		Status:   "completed",
-	}, nil
+		ExitCode:      exitCode,
+		Duration:      time.Since(start).Nanoseconds(),
+		Status:        "completed",
+		LimitExceeded: lim.exceeded(cmd.ProcessState),
+	}
+}
+
+// baseEnv lists variables every plugin gets, even with a restricted environment.
+var baseEnv = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "LANG", "TERM", "TZ", "TMPDIR"}
+
+// pluginEnv returns the environment for a plugin: everything for unrestricted
+// plugins, otherwise the base set plus the env vars and secrets it declared.
+func pluginEnv(perms *entities.Permissions) []string {
+	environ := os.Environ()
+	if perms == nil {
+		return environ
+	}
+
+	allowed := slices.Concat(baseEnv, perms.Env, perms.Secrets)
+	var env []string
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if slices.Contains(allowed, name) || strings.HasPrefix(name, "LC_") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// sandboxWritablePaths resolves the paths a sandboxed plugin may write to:
+// its data dir, the caller's cwd and any existing paths it was granted.
+func sandboxWritablePaths(perms *entities.Permissions, dataDir string) []string {
+	paths := []string{dataDir}
+	if cwd, err := os.Getwd(); err == nil {
+		paths = append(paths, cwd)
+	}
+	home, _ := os.UserHomeDir()
+	for _, p := range perms.Filesystem {
+		if rest, ok := strings.CutPrefix(p, "~"); ok && home != "" {
+			p = filepath.Join(home, rest)
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			if _, err := os.Stat(abs); err == nil {
+				paths = append(paths, abs)
+			}
+		}
+	}
+	return paths
 }
 
 // parseArgs parses a command line string into arguments, respecting quotes.
@@ -0,0 +1,227 @@
+//go:build linux
+
+package exec
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"kodkafa/internal/domain/entities"
+
+	"golang.org/x/sys/unix"
+)
+
+// SandboxInitArg is the hidden argument that turns the kod binary into the
+// sandbox init process. The runner re-executes itself with it inside fresh
+// namespaces; SandboxInit then prepares the filesystem and execs the plugin.
+const SandboxInitArg = "__sandbox-init"
+
+const sandboxSpecEnv = "KODKAFA_SANDBOX_SPEC"
+
+// sandboxSpec is handed from the runner to the sandbox init process.
+type sandboxSpec struct {
+	Writable []string `json:"writable"`
+	Dir      string   `json:"dir"`
+}
+
+// sandboxCommand rewrites cmd to run through the sandbox init process in new
+// user, mount and pid namespaces, plus a network namespace unless the plugin
+// was granted network access.
+func sandboxCommand(cmd *exec.Cmd, perms *entities.Permissions, writable []string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("sandbox: cannot locate kod binary: %w", err)
+	}
+
+	spec, err := json.Marshal(sandboxSpec{Writable: writable, Dir: cmd.Dir})
+	if err != nil {
+		return fmt.Errorf("sandbox: %w", err)
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env, sandboxSpecEnv+"="+string(spec))
+	cmd.Args = append([]string{self, SandboxInitArg, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = self
+
+	flags := uintptr(syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID)
+	if !perms.Network {
+		flags |= syscall.CLONE_NEWNET
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Cloneflags = flags
+	cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+	cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	cmd.SysProcAttr.GidMappingsEnableSetgroups = false
+	return nil
+}
+
+// SandboxInit runs inside the namespaces created by sandboxCommand. It makes
+// the root filesystem read-only except for the writable paths in the spec,
+// applies any rlimits handed over by the limiter and then replaces itself with the plugin command in args. It only returns on error.
+func SandboxInit(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("sandbox: missing command")
+	}
+
+	var spec sandboxSpec
+	if err := json.Unmarshal([]byte(os.Getenv(sandboxSpecEnv)), &spec); err != nil {
+		return fmt.Errorf("sandbox: invalid spec: %w", err)
+	}
+	os.Unsetenv(sandboxSpecEnv)
+
+	if err := setupSandboxFS(spec.Writable); err != nil {
+		return fmt.Errorf("sandbox: %w", err)
+	}
+	if spec.Dir != "" {
+		if err := os.Chdir(spec.Dir); err != nil {
+			return fmt.Errorf("sandbox: %w", err)
+		}
+	}
+	if err := applyLimitSpec(); err != nil {
+		return err
+	}
+
+	return syscall.Exec(args[0], args, os.Environ())
+}
+
+func setupSandboxFS(writable []string) error {
+	// Keep our mount changes out of the parent namespace.
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+
+	mountPoints, err := readMountPoints()
+	if err != nil {
+		return err
+	}
+
+	for _, mp := range mountPoints {
+		if isPseudoFS(mp) {
+			continue
+		}
+		if err := remount(mp, unix.MS_RDONLY); err != nil {
+			if errors.Is(err, unix.ENOENT) && mp != "/" {
+				continue
+			}
+			return fmt.Errorf("failed to make %s read-only: %w", mp, err)
+		}
+	}
+
+	// A private /tmp and /dev/shm, unless a writable path lives under them.
+	// /dev is left alone above, so the host's /dev/shm must not stay
+	// shared: when no tmpfs can be mounted over it, it is made read-only.
+	if !underAny("/tmp", writable) {
+		_ = unix.Mount("tmpfs", "/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777")
+	}
+	if !underAny("/dev/shm", writable) {
+		err := unix.Mount("tmpfs", "/dev/shm", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777")
+		if err != nil && !errors.Is(err, unix.ENOENT) {
+			if err := remount("/dev/shm", unix.MS_RDONLY); err != nil {
+				return fmt.Errorf("failed to make /dev/shm private or read-only: %w", err)
+			}
+		}
+	}
+
+	for _, p := range writable {
+		if err := unix.Mount(p, p, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind %s: %w", p, err)
+		}
+		if err := remount(p, 0); err != nil {
+			return fmt.Errorf("failed to make %s writable: %w", p, err)
+		}
+	}
+
+	// Fresh /proc for the new pid namespace; not fatal if the kernel refuses.
+	_ = unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+	return nil
+}
+
+// remount changes the per-mount flags of path, preserving the flags that a
+// user namespace is not allowed to clear.
+func remount(path string, flags uintptr) error {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return err
+	}
+	locked := map[int64]uintptr{
+		unix.ST_NOSUID:     unix.MS_NOSUID,
+		unix.ST_NODEV:      unix.MS_NODEV,
+		unix.ST_NOEXEC:     unix.MS_NOEXEC,
+		unix.ST_NOATIME:    unix.MS_NOATIME,
+		unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+		unix.ST_RELATIME:   unix.MS_RELATIME,
+	}
+	for stFlag, msFlag := range locked {
+		if int64(st.Flags)&stFlag != 0 {
+			flags |= msFlag
+		}
+	}
+	return unix.Mount("", path, "", unix.MS_BIND|unix.MS_REMOUNT|flags, "")
+}
+
+// readMountPoints lists mount points from /proc/self/mountinfo, parents first.
+func readMountPoints() ([]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mountinfo: %w", err)
+	}
+	defer f.Close()
+
+	var points []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		points = append(points, unescapeMountPath(fields[4]))
+	}
+	return points, scanner.Err()
+}
+
+// unescapeMountPath decodes the octal escapes (e.g. \040) used in mountinfo.
+func unescapeMountPath(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// underAny reports whether one of paths is dir or lies below it.
+func underAny(dir string, paths []string) bool {
+	for _, p := range paths {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isPseudoFS(path string) bool {
+	for _, prefix := range []string{"/proc", "/sys", "/dev"} {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package exec
+
+import (
+	"fmt"
+	"os/exec"
+
+	"kodkafa/internal/domain/entities"
+)
+
+// SandboxInitArg is the hidden argument that turns the kod binary into the
+// sandbox init process. Sandboxing is only available on Linux.
+const SandboxInitArg = "__sandbox-init"
+
+func sandboxCommand(cmd *exec.Cmd, perms *entities.Permissions, writable []string) error {
+	return fmt.Errorf("sandbox mode is only supported on Linux")
+}
+
+// SandboxInit is only meaningful on Linux.
+func SandboxInit(args []string) error {
+	return fmt.Errorf("sandbox mode is only supported on Linux")
+}
@@ -0,0 +1,147 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxIndexSize caps downloaded indexes.
+	maxIndexSize = 16 << 20
+	fetchTimeout = 30 * time.Second
+)
+
+// indexFile is the on-disk form of a registry index. YAML is a superset of
+// JSON, so the same struct reads both formats.
+type indexFile struct {
+	Plugins []indexEntry `yaml:"plugins"`
+}
+
+type indexEntry struct {
+	Name        string         `yaml:"name"`
+	Description string         `yaml:"description"`
+	Tags        []string       `yaml:"tags"`
+	Source      string         `yaml:"source"`
+	Subdir      string         `yaml:"subdir"`
+	Versions    []indexVersion `yaml:"versions"`
+}
+
+type indexVersion struct {
+	Version  string `yaml:"version"`
+	Ref      string `yaml:"ref"`
+	Source   string `yaml:"source"`
+	Checksum string `yaml:"checksum"`
+}
+
+// IndexRegistry implements ports.Registry for index files and URLs. Remote
+// indexes are cached under cache/registries so search keeps working offline.
+type IndexRegistry struct {
+	cacheDir string
+}
+
+// NewIndexRegistry creates a new Registry implementation.
+func NewIndexRegistry(baseDir string) ports.Registry {
+	return &IndexRegistry{cacheDir: filepath.Join(baseDir, "cache", "registries")}
+}
+
+// Fetch loads and parses the index at location. Entries without a name or
+// source are skipped.
+func (r *IndexRegistry) Fetch(location string) ([]entities.RegistryEntry, error) {
+	data, err := r.read(location)
+	if err != nil {
+		return nil, err
+	}
+
+	var index indexFile
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse registry %s: %w", location, err)
+	}
+
+	entries := make([]entities.RegistryEntry, 0, len(index.Plugins))
+	for _, p := range index.Plugins {
+		if p.Name == "" || (p.Source == "" && !hasVersionSource(p.Versions)) {
+			continue
+		}
+		entry := entities.RegistryEntry{
+			Name:        p.Name,
+			Description: p.Description,
+			Tags:        p.Tags,
+			Source:      p.Source,
+			Subdir:      p.Subdir,
+			Registry:    location,
+		}
+		for _, v := range p.Versions {
+			entry.Versions = append(entry.Versions, entities.RegistryVersion(v))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func hasVersionSource(versions []indexVersion) bool {
+	for _, v := range versions {
+		if v.Source != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// read returns the raw index, falling back to the cached copy of a remote
+// index when it cannot be downloaded.
+func (r *IndexRegistry) read(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "https://") && !strings.HasPrefix(location, "http://") {
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read registry: %w", err)
+		}
+		return data, nil
+	}
+
+	sum := sha256.Sum256([]byte(location))
+	cached := filepath.Join(r.cacheDir, hex.EncodeToString(sum[:8])+".yml")
+	data, err := download(location)
+	if err != nil {
+		if data, cacheErr := os.ReadFile(cached); cacheErr == nil {
+			return data, nil
+		}
+		return nil, err
+	}
+	if err := os.MkdirAll(r.cacheDir, 0755); err == nil {
+		_ = os.WriteFile(cached, data, 0644)
+	}
+	return data, nil
+}
+
+func download(url string) ([]byte, error) {
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch registry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch registry %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIndexSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch registry: %w", err)
+	}
+	if len(data) > maxIndexSize {
+		return nil, fmt.Errorf("registry %s is larger than %d bytes", url, maxIndexSize)
+	}
+	return data, nil
+}
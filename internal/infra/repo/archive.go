@@ -0,0 +1,289 @@
+package repo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// maxArchiveSize caps downloads and the total size of extracted files,
+	// so a hostile archive cannot fill the disk.
+	maxArchiveSize  = 1 << 30
+	downloadTimeout = 5 * time.Minute
+	// maxLinkDepth bounds how many links a symlink target is followed
+	// through, as the kernel's ELOOP limit does.
+	maxLinkDepth = 40
+)
+
+var errUnsafeArchive = errors.New("unsafe archive")
+
+// downloadArchive fetches url into a file inside dir and returns its path.
+func downloadArchive(url, dir string) (string, error) {
+	client := &http.Client{Timeout: downloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	dst := filepath.Join(dir, "archive")
+	f, err := os.Create(dst)
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, io.LimitReader(resp.Body, maxArchiveSize+1))
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	if n > maxArchiveSize {
+		return "", fmt.Errorf("download failed: archive larger than %d bytes", maxArchiveSize)
+	}
+	return dst, nil
+}
+
+// extractArchive unpacks the archive at src into dst. name decides the
+// format by its suffix. Entries that would land outside dst, absolute or
+// escaping symlinks, and special files are rejected.
+func extractArchive(src, name, dst string) error {
+	x := &extractor{root: dst, budget: maxArchiveSize}
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return x.zip(src)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return x.tarGz(src)
+	default:
+		return fmt.Errorf("unsupported archive format: %s", name)
+	}
+}
+
+// extractor writes archive entries below root.
+type extractor struct {
+	root   string
+	budget int64 // bytes left to extract
+}
+
+func (x *extractor) tarGz(src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("invalid archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid archive: %w", err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(hdr.Name)
+		case tar.TypeReg:
+			err = x.file(hdr.Name, fs.FileMode(hdr.Mode), tr)
+		case tar.TypeSymlink:
+			err = x.symlink(hdr.Name, hdr.Linkname)
+		case tar.TypeXGlobalHeader:
+			// pax metadata such as git's commit comment; nothing to write
+		default:
+			err = fmt.Errorf("%w: %s has unsupported type %q", errUnsafeArchive, hdr.Name, hdr.Typeflag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (x *extractor) zip(src string) error {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("invalid archive: %w", err)
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		if err := x.zipEntry(zf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *extractor) zipEntry(zf *zip.File) error {
+	mode := zf.Mode()
+	if mode.IsDir() {
+		return x.dir(zf.Name)
+	}
+	if mode&^fs.ModePerm != 0 && mode&fs.ModeSymlink == 0 {
+		return fmt.Errorf("%w: %s is not a regular file", errUnsafeArchive, zf.Name)
+	}
+
+	rc, err := zf.Open()
+	if err != nil {
+		return fmt.Errorf("invalid archive: %w", err)
+	}
+	defer rc.Close()
+
+	if mode&fs.ModeSymlink != 0 {
+		target, err := io.ReadAll(io.LimitReader(rc, 4096))
+		if err != nil {
+			return fmt.Errorf("invalid archive: %w", err)
+		}
+		return x.symlink(zf.Name, string(target))
+	}
+	return x.file(zf.Name, mode, rc)
+}
+
+// path validates an entry name and returns where it goes on disk. Every
+// existing parent must be a real directory, so earlier symlinks in the
+// archive cannot redirect later entries.
+func (x *extractor) path(name string) (string, error) {
+	clean := path.Clean(strings.TrimSuffix(filepath.ToSlash(name), "/"))
+	if !filepath.IsLocal(filepath.FromSlash(clean)) {
+		return "", fmt.Errorf("%w: %s points outside the archive", errUnsafeArchive, name)
+	}
+
+	dst := x.root
+	parts := strings.Split(clean, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dst = filepath.Join(dst, part)
+		info, err := os.Lstat(dst)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("%w: %s is inside a symlink or file", errUnsafeArchive, name)
+		}
+	}
+	return filepath.Join(x.root, filepath.FromSlash(clean)), nil
+}
+
+func (x *extractor) dir(name string) error {
+	dst, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(dst, 0755)
+}
+
+func (x *extractor) file(name string, mode fs.FileMode, r io.Reader) error {
+	dst, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	// O_EXCL: never write through an existing entry, symlink or not
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm()|0600)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", errUnsafeArchive, name, err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, io.LimitReader(r, x.budget+1))
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	x.budget -= n
+	if x.budget < 0 {
+		return fmt.Errorf("%w: extracted size exceeds %d bytes", errUnsafeArchive, maxArchiveSize)
+	}
+	return f.Close()
+}
+
+func (x *extractor) symlink(name, target string) error {
+	if target == "" || path.IsAbs(filepath.ToSlash(target)) || filepath.IsAbs(target) {
+		return fmt.Errorf("%w: %s links to absolute path %s", errUnsafeArchive, name, target)
+	}
+	dst, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if _, err := x.resolve(filepath.Dir(dst), target, 0); err != nil {
+		return fmt.Errorf("%w: %s %v", errUnsafeArchive, name, err)
+	}
+	return os.Symlink(target, dst)
+}
+
+// resolve follows target from dir through the links extracted so far, the
+// way the kernel will, and returns where it ends up. It fails if that, or
+// any step on the way, is outside root: a lexical check is not enough, as
+// with q -> "." the target "q/.." is the parent of root. ".." is only
+// followed out of an existing directory, since a missing one could still be
+// extracted as a link and change where the target points.
+func (x *extractor) resolve(dir, target string, depth int) (string, error) {
+	if depth > maxLinkDepth {
+		return "", fmt.Errorf("has too many levels of links")
+	}
+	cur := dir
+	for _, part := range strings.Split(filepath.ToSlash(target), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if info, err := os.Lstat(cur); err != nil || !info.IsDir() {
+				return "", fmt.Errorf("links out of %s before it is extracted", cur)
+			}
+			cur = filepath.Dir(cur)
+		default:
+			cur = filepath.Join(cur, part)
+			// Absolute links were refused when they were extracted
+			if next, err := os.Readlink(cur); err == nil {
+				if cur, err = x.resolve(filepath.Dir(cur), next, depth+1); err != nil {
+					return "", err
+				}
+			}
+		}
+		if rel, err := filepath.Rel(x.root, cur); err != nil || !filepath.IsLocal(rel) && rel != "." {
+			return "", fmt.Errorf("links outside the archive")
+		}
+	}
+	return cur, nil
+}
+
+// archiveRoot returns the directory holding plugin.yml in an extracted
+// archive: dir itself, or its only subdirectory as in GitHub tarballs.
+func archiveRoot(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "plugin.yml")); err == nil {
+		return dir
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
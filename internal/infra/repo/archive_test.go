@@ -0,0 +1,293 @@
+package repo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// archiveEntry is a file, directory or symlink to put in a test archive.
+type archiveEntry struct {
+	name string
+	body string // file contents
+	link string // symlink target
+	dir  bool
+}
+
+func writeTarGz(t *testing.T, entries []archiveEntry) string {
+	t.Helper()
+	dst := filepath.Join(t.TempDir(), "plugin.tar.gz")
+	f, err := os.Create(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		switch {
+		case e.dir:
+			hdr.Typeflag, hdr.Mode, hdr.Size = tar.TypeDir, 0755, 0
+		case e.link != "":
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.link, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+func writeZip(t *testing.T, entries []archiveEntry) string {
+	t.Helper()
+	dst := filepath.Join(t.TempDir(), "plugin.zip")
+	f, err := os.Create(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		switch {
+		case e.dir:
+			hdr.Name += "/"
+			hdr.SetMode(fs.ModeDir | 0755)
+		case e.link != "":
+			hdr.SetMode(fs.ModeSymlink | 0777)
+			body = e.link
+		default:
+			hdr.SetMode(0644)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+func TestExtractArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []archiveEntry
+		unsafe  bool
+		files   map[string]string // extracted files and their contents
+		links   map[string]string // extracted symlinks and their targets
+	}{
+		{
+			name: "plugin",
+			entries: []archiveEntry{
+				{name: "tool", dir: true},
+				{name: "tool/plugin.yml", body: "name: tool\n"},
+				{name: "tool/lib/util.py", body: "pass\n"},
+			},
+			files: map[string]string{"tool/plugin.yml": "name: tool\n", "tool/lib/util.py": "pass\n"},
+		},
+		{
+			name: "symlink inside the archive",
+			entries: []archiveEntry{
+				{name: "lib/util.py", body: "pass\n"},
+				{name: "util.py", link: "lib/util.py"},
+				{name: "lib/self", link: "../lib"},
+			},
+			files: map[string]string{"lib/util.py": "pass\n"},
+			links: map[string]string{"util.py": "lib/util.py", "lib/self": "../lib"},
+		},
+		{name: "parent traversal", entries: []archiveEntry{{name: "../evil.sh", body: "x"}}, unsafe: true},
+		{name: "nested traversal", entries: []archiveEntry{{name: "tool/../../evil.sh", body: "x"}}, unsafe: true},
+		{name: "absolute path", entries: []archiveEntry{{name: "/tmp/evil.sh", body: "x"}}, unsafe: true},
+		{name: "absolute symlink", entries: []archiveEntry{{name: "passwd", link: "/etc/passwd"}}, unsafe: true},
+		{name: "escaping symlink", entries: []archiveEntry{{name: "tool/up", link: "../../outside"}}, unsafe: true},
+		{
+			name: "symlink chain out of the archive",
+			entries: []archiveEntry{
+				{name: "q", link: "."},
+				{name: "p", link: "q/.."},
+				{name: "p2", link: "p/.."},
+			},
+			unsafe: true,
+		},
+		{
+			name: "symlink through a later symlink",
+			entries: []archiveEntry{
+				{name: "a", link: "b/.."},
+				{name: "b", link: "."},
+			},
+			unsafe: true,
+		},
+		{
+			name: "file through a symlinked directory",
+			entries: []archiveEntry{
+				{name: "lib", dir: true},
+				{name: "alias", link: "lib"},
+				{name: "alias/evil.sh", body: "x"},
+			},
+			unsafe: true,
+		},
+		{
+			name: "file over a symlink",
+			entries: []archiveEntry{
+				{name: "target", body: "keep"},
+				{name: "link", link: "target"},
+				{name: "link", body: "overwrite"},
+			},
+			unsafe: true,
+		},
+		{
+			name: "duplicate file",
+			entries: []archiveEntry{
+				{name: "plugin.yml", body: "name: a\n"},
+				{name: "plugin.yml", body: "name: b\n"},
+			},
+			unsafe: true,
+		},
+	}
+	formats := []struct {
+		suffix string
+		write  func(*testing.T, []archiveEntry) string
+	}{
+		{".tar.gz", writeTarGz},
+		{".zip", writeZip},
+	}
+	for _, format := range formats {
+		for _, tt := range tests {
+			t.Run(format.suffix+"/"+tt.name, func(t *testing.T) {
+				src := format.write(t, tt.entries)
+				dst := t.TempDir()
+				err := extractArchive(src, "plugin"+format.suffix, dst)
+				if tt.unsafe {
+					if !errors.Is(err, errUnsafeArchive) {
+						t.Fatalf("extractArchive() error = %v, want %v", err, errUnsafeArchive)
+					}
+					if _, err := os.Lstat(filepath.Join(filepath.Dir(dst), "evil.sh")); err == nil {
+						t.Error("an entry was written outside the destination")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("extractArchive(): %v", err)
+				}
+				for name, want := range tt.files {
+					got, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+					if err != nil {
+						t.Errorf("%s: %v", name, err)
+					} else if string(got) != want {
+						t.Errorf("%s = %q, want %q", name, got, want)
+					}
+				}
+				for name, want := range tt.links {
+					got, err := os.Readlink(filepath.Join(dst, filepath.FromSlash(name)))
+					if err != nil {
+						t.Errorf("%s: %v", name, err)
+					} else if got != want {
+						t.Errorf("%s links to %q, want %q", name, got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestExtractArchiveSpecialFiles(t *testing.T) {
+	for _, typ := range []byte{tar.TypeLink, tar.TypeChar, tar.TypeBlock, tar.TypeFifo} {
+		dst := filepath.Join(t.TempDir(), "special.tar.gz")
+		f, err := os.Create(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz := gzip.NewWriter(f)
+		tw := tar.NewWriter(gz)
+		hdr := &tar.Header{Name: "special", Typeflag: typ, Mode: 0644}
+		if typ == tar.TypeLink {
+			hdr.Linkname = "/etc/passwd"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Close()
+		gz.Close()
+		f.Close()
+
+		if err := extractArchive(dst, "special.tar.gz", t.TempDir()); !errors.Is(err, errUnsafeArchive) {
+			t.Errorf("type %q: extractArchive() error = %v, want %v", typ, err, errUnsafeArchive)
+		}
+	}
+
+	dst := filepath.Join(t.TempDir(), "special.zip")
+	f, err := os.Create(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	hdr := &zip.FileHeader{Name: "pipe"}
+	hdr.SetMode(fs.ModeNamedPipe | 0644)
+	if _, err := zw.CreateHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	f.Close()
+	if err := extractArchive(dst, "special.zip", t.TempDir()); !errors.Is(err, errUnsafeArchive) {
+		t.Errorf("named pipe: extractArchive() error = %v, want %v", err, errUnsafeArchive)
+	}
+}
+
+func TestExtractArchiveUnsupportedFormat(t *testing.T) {
+	if err := extractArchive(writeZip(t, nil), "plugin.rar", t.TempDir()); err == nil {
+		t.Error("extractArchive() accepted a .rar archive")
+	}
+}
+
+func TestArchiveRoot(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{name: "manifest at the top", files: []string{"plugin.yml", "src/main.py"}, want: "."},
+		{name: "single wrapping directory", files: []string{"kod-1.0/plugin.yml"}, want: "kod-1.0"},
+		{name: "several directories", files: []string{"a/plugin.yml", "b/plugin.yml"}, want: "."},
+		{name: "single file", files: []string{"README.md"}, want: "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				p := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, want := archiveRoot(dir), filepath.Join(dir, tt.want); got != want {
+				t.Errorf("archiveRoot() = %q, want %q", got, want)
+			}
+		})
+	}
+}
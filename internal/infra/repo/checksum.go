@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kodkafa/internal/domain/entities"
+)
+
+// checksumPrefix names the hash function in recorded checksums.
+const checksumPrefix = "sha256:"
+
+// installedIgnores are the only paths left out of the hash of an installed
+// copy: kod's own metadata. Everything else in the copy is hashed, whatever
+// a .kodignore inside it says, so files added after install cannot hide.
+var installedIgnores = ignoreRules{
+	parseIgnoreRule("/" + installInfoFile),
+	parseIgnoreRule("/" + entities.DependencySnapshotFile),
+	parseIgnoreRule("/" + versionsDir + "/"),
+}
+
+// contentHash returns the Merkle hash of the plugin source tree in dir. Every
+// directory hashes the sorted list of its entries (type, executable bit,
+// hash and name), files hash their content and symlinks their target, so
+// any added, removed, renamed or edited file changes the root hash. Paths
+// that are never installed (see copyTree) and the signature file are left
+// out, so a source tree hashes the same as its installed copy does with
+// installedHash.
+func contentHash(dir string) (string, error) {
+	rules, err := readIgnoreRules(dir)
+	if err != nil {
+		return "", err
+	}
+	return treeHash(dir, rules)
+}
+
+// installedHash returns the hash of an installed plugin copy, as recorded in
+// InstallInfo.Checksum. Only installedIgnores and the signature file are
+// left out.
+func installedHash(dir string) (string, error) {
+	return treeHash(dir, installedIgnores)
+}
+
+func treeHash(dir string, rules ignoreRules) (string, error) {
+	sum, err := hashTree(dir, "", rules)
+	if err != nil {
+		return "", err
+	}
+	return checksumPrefix + hex.EncodeToString(sum), nil
+}
+
+func hashTree(root, rel string, rules ignoreRules) ([]byte, error) {
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, e := range entries {
+		name := path.Join(rel, e.Name())
+		if name == signatureFile || rules.ignored(name, e.IsDir()) {
+			continue
+		}
+		full := filepath.Join(root, filepath.FromSlash(name))
+
+		var kind string
+		var sum []byte
+		switch {
+		case e.IsDir():
+			kind = "tree"
+			sum, err = hashTree(root, name, rules)
+		case e.Type()&os.ModeSymlink != 0:
+			kind = "link"
+			var target string
+			if target, err = os.Readlink(full); err == nil {
+				h := sha256.Sum256([]byte(filepath.ToSlash(target)))
+				sum = h[:]
+			}
+		case e.Type().IsRegular():
+			kind = "file"
+			info, infoErr := e.Info()
+			if infoErr != nil {
+				return nil, infoErr
+			}
+			if info.Mode()&0111 != 0 {
+				kind = "exec"
+			}
+			var h [sha256.Size]byte
+			h, err = hashFile(full)
+			sum = h[:]
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, fmt.Sprintf("%s %x %s\n", kind, sum, e.Name()))
+	}
+
+	sort.Strings(lines)
+	h := sha256.Sum256([]byte(strings.Join(lines, "")))
+	return h[:], nil
+}
@@ -0,0 +1,199 @@
+package repo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// ignoreFile lists extra paths to leave out of an installed plugin, one
+// pattern per line, in a subset of .gitignore syntax.
+const ignoreFile = ".kodignore"
+
+// defaultIgnores are never installed: VCS metadata, dependencies that kod
+// installs itself, bytecode caches and kod's own metadata.
+var defaultIgnores = []string{".git/", "node_modules/", "__pycache__/", "/" + installInfoFile, "/" + entities.DependencySnapshotFile, "/" + versionsDir + "/"}
+
+// copyTree copies the plugin files in src into the empty directory dst.
+// Files are streamed, paths matching defaultIgnores or src/.kodignore are
+// skipped and symlinks are handled according to policy. Special files such
+// as sockets and FIFOs are skipped.
+func copyTree(src, dst string, policy ports.SymlinkPolicy) error {
+	rules, err := readIgnoreRules(src)
+	if err != nil {
+		return err
+	}
+	realSrc, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		slashRel := filepath.ToSlash(rel)
+		if rules.ignored(slashRel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.Mkdir(target, 0755)
+		case d.Type()&fs.ModeSymlink != 0:
+			return copySymlink(realSrc, p, slashRel, target, policy)
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return copyFile(p, target, info.Mode().Perm())
+		default:
+			return nil
+		}
+	})
+}
+
+// copyFile streams src to a new file dst.
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return out.Close()
+}
+
+// copySymlink recreates the link at p (rel inside the source) if policy and
+// its target allow. A link may only point inside the plugin, both as written
+// and after resolving every link along the way.
+func copySymlink(realSrc, p, rel, dst string, policy ports.SymlinkPolicy) error {
+	switch policy {
+	case ports.SymlinkSkip:
+		return nil
+	case ports.SymlinkReject:
+		return fmt.Errorf("plugin contains a symlink (%s) and symlink_policy is %q", rel, policy)
+	}
+
+	link, err := os.Readlink(p)
+	if err != nil {
+		return err
+	}
+	escape := fmt.Errorf("symlink %s points outside the plugin (%s)", rel, link)
+	if filepath.IsAbs(link) || !filepath.IsLocal(path.Join(path.Dir(rel), filepath.ToSlash(link))) {
+		return escape
+	}
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return fmt.Errorf("symlink %s is broken: %w", rel, err)
+	}
+	if r, err := filepath.Rel(realSrc, resolved); err != nil || !filepath.IsLocal(r) {
+		return escape
+	}
+	return os.Symlink(link, dst)
+}
+
+// ignoreRule is one .kodignore pattern.
+type ignoreRule struct {
+	pattern  string
+	negate   bool // "!pattern" re-includes a path
+	dirOnly  bool // "pattern/" matches directories only
+	anchored bool // a pattern with a slash matches from the plugin root
+}
+
+type ignoreRules []ignoreRule
+
+func readIgnoreRules(src string) (ignoreRules, error) {
+	var rules ignoreRules
+	for _, p := range defaultIgnores {
+		rules = append(rules, parseIgnoreRule(p))
+	}
+
+	f, err := os.Open(filepath.Join(src, ignoreFile))
+	if os.IsNotExist(err) {
+		return rules, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ignoreFile, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := parseIgnoreRule(line)
+		if _, err := path.Match(rule.pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %w", ignoreFile, line, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+func parseIgnoreRule(line string) ignoreRule {
+	var rule ignoreRule
+	if rest, ok := strings.CutPrefix(line, "!"); ok {
+		rule.negate = true
+		line = rest
+	}
+	if rest, ok := strings.CutSuffix(line, "/"); ok {
+		rule.dirOnly = true
+		line = rest
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	rule.pattern = line
+	return rule
+}
+
+// ignored reports whether the slash-separated path rel is excluded; the last
+// matching rule wins, as in .gitignore.
+func (rules ignoreRules) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		name := rel
+		if !rule.anchored {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(rule.pattern, name); ok {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
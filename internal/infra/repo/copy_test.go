@@ -0,0 +1,167 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		line string
+		want ignoreRule
+	}{
+		{"*.log", ignoreRule{pattern: "*.log"}},
+		{"build/", ignoreRule{pattern: "build", dirOnly: true}},
+		{"/dist", ignoreRule{pattern: "dist", anchored: true}},
+		{"docs/*.md", ignoreRule{pattern: "docs/*.md", anchored: true}},
+		{"/cache/", ignoreRule{pattern: "cache", dirOnly: true, anchored: true}},
+		{"!keep.log", ignoreRule{pattern: "keep.log", negate: true}},
+		{"!/docs/", ignoreRule{pattern: "docs", negate: true, dirOnly: true, anchored: true}},
+	}
+	for _, tt := range tests {
+		if got := parseIgnoreRule(tt.line); got != tt.want {
+			t.Errorf("parseIgnoreRule(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreRulesIgnored(t *testing.T) {
+	var rules ignoreRules
+	for _, line := range append(slices.Clone(defaultIgnores), "*.log", "!keep.log", "build/", "/dist", "docs/*.md", "tmp?") {
+		rules = append(rules, parseIgnoreRule(line))
+	}
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{".git", true, true},
+		{"sub/.git", true, true},
+		{"node_modules", true, true},
+		{"lib/__pycache__", true, true},
+		{installInfoFile, false, true},
+		{"sub/" + installInfoFile, false, false},
+		{entities.DependencySnapshotFile, false, true},
+		{versionsDir, true, true},
+		{"main.py", false, false},
+		{"error.log", false, true},
+		{"logs/error.log", false, true},
+		{"keep.log", false, false},
+		{"logs/keep.log", false, false},
+		{"build", true, true},
+		{"src/build", true, true},
+		{"build", false, false},
+		{"dist", true, true},
+		{"dist", false, true},
+		{"src/dist", true, false},
+		{"docs/guide.md", false, true},
+		{"docs/api/guide.md", false, false},
+		{"guide.md", false, false},
+		{"tmp1", false, true},
+		{"tmp12", false, false},
+	}
+	for _, tt := range tests {
+		if got := rules.ignored(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, dir %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestReadIgnoreRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // no .kodignore when empty
+		extra   int    // rules read from .kodignore
+		wantErr bool
+	}{
+		{name: "no file"},
+		{name: "comments and blank lines", content: "# build output\n\n  build/  \n*.log\n", extra: 2},
+		{name: "invalid pattern", content: "[unclosed\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.content != "" {
+				if err := os.WriteFile(filepath.Join(dir, ignoreFile), []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			rules, err := readIgnoreRules(dir)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("readIgnoreRules() accepted an invalid pattern")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readIgnoreRules(): %v", err)
+			}
+			if got, want := len(rules), len(defaultIgnores)+tt.extra; got != want {
+				t.Errorf("readIgnoreRules() returned %d rules, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestCopyTreeKodignore(t *testing.T) {
+	src := t.TempDir()
+	files := []string{
+		"plugin.yml",
+		"main.py",
+		".kodignore",
+		"debug.log",
+		"keep.log",
+		"build/out.bin",
+		"src/build/out.bin",
+		"src/util.py",
+		".git/HEAD",
+		"node_modules/dep/index.js",
+		"docs/guide.md",
+		"docs/api/ref.md",
+	}
+	for _, name := range files {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := name
+		if name == ".kodignore" {
+			content = "*.log\n!keep.log\nbuild/\n/docs/*.md\n"
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst := filepath.Join(t.TempDir(), "plugin")
+	if err := os.Mkdir(dst, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyTree(src, dst, ports.SymlinkPreserve); err != nil {
+		t.Fatalf("copyTree(): %v", err)
+	}
+
+	var got []string
+	err := filepath.WalkDir(dst, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dst, p)
+		got = append(got, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".kodignore", "docs/api/ref.md", "keep.log", "main.py", "plugin.yml", "src/util.py"}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("copied %s, want %s", strings.Join(got, ", "), strings.Join(want, ", "))
+	}
+}
@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"kodkafa/internal/domain/entities"
+)
+
+// diffDirectories compares the plugin files in oldDir and newDir by content.
+func diffDirectories(oldDir, newDir string) (entities.ChangeSet, error) {
+	var changes entities.ChangeSet
+
+	oldFiles, err := hashFiles(oldDir)
+	if err != nil {
+		return changes, err
+	}
+	newFiles, err := hashFiles(newDir)
+	if err != nil {
+		return changes, err
+	}
+
+	for path, sum := range newFiles {
+		oldSum, ok := oldFiles[path]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, path)
+		case oldSum != sum:
+			changes.Modified = append(changes.Modified, path)
+		}
+	}
+	for path := range oldFiles {
+		if _, ok := newFiles[path]; !ok {
+			changes.Removed = append(changes.Removed, path)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Modified)
+	return changes, nil
+}
+
+// hashFiles maps slash-separated relative paths of regular files to their
+// SHA-256, skipping git metadata, kept versions and kod's install metadata.
+func hashFiles(root string) (map[string][sha256.Size]byte, error) {
+	sums := make(map[string][sha256.Size]byte)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == ".git" || path == filepath.Join(root, versionsDir)) {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == installInfoFile || rel == entities.DependencySnapshotFile {
+			return nil
+		}
+
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		sums[rel] = sum
+		return nil
+	})
+	return sums, err
+}
+
+func hashFile(path string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	f, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitClone clones url into dir and checks out ref, or stays on the default
+// branch when ref is empty. It returns the ref that was checked out and the
+// resolved commit SHA.
+func gitClone(url, ref, dir string) (string, string, error) {
+	if _, err := git("", "clone", "--quiet", "--", url, dir); err != nil {
+		return "", "", fmt.Errorf("git clone failed: %w", err)
+	}
+
+	if ref == "" {
+		branch, err := git(dir, "symbolic-ref", "--quiet", "--short", "HEAD")
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve default branch: %w", err)
+		}
+		ref = branch
+	} else {
+		commit, err := gitResolve(dir, ref)
+		if err != nil {
+			return "", "", err
+		}
+		if _, err := git(dir, "checkout", "--quiet", "--detach", commit); err != nil {
+			return "", "", fmt.Errorf("git checkout %s failed: %w", ref, err)
+		}
+	}
+
+	commit, err := git(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve commit: %w", err)
+	}
+	return ref, commit, nil
+}
+
+// gitResolve finds the commit a tag, remote branch or (abbreviated) SHA
+// points to, in that order of preference.
+func gitResolve(dir, ref string) (string, error) {
+	for _, candidate := range []string{"refs/tags/" + ref, "refs/remotes/origin/" + ref, ref} {
+		if commit, err := git(dir, "rev-parse", "--verify", "--quiet", "--end-of-options", candidate+"^{commit}"); err == nil {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("ref %s not found in repository", ref)
+}
+
+// git runs a git command, in dir when set, and returns its trimmed stdout.
+func git(dir string, args ...string) (string, error) {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.Command("git", args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w", msg, err)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
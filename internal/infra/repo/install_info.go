@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kodkafa/internal/domain/entities"
+)
+
+// installInfoFile holds install metadata inside each plugin directory.
+const installInfoFile = ".kodkafa-install.json"
+
+// installSchemaVersion is the current schema_version of install metadata.
+const installSchemaVersion = 1
+
+// installRecord is the on-disk form of entities.InstallInfo.
+type installRecord struct {
+	SchemaVersion int `json:"schema_version"`
+	installFields
+}
+
+// installFields mirrors entities.InstallInfo field for field, so the two
+// convert directly.
+type installFields struct {
+	Origin      string    `json:"origin"`
+	Ref         string    `json:"ref,omitempty"`
+	Commit      string    `json:"commit,omitempty"`
+	Subdir      string    `json:"subdir,omitempty"`
+	Version     string    `json:"version,omitempty"`
+	Linked      bool      `json:"linked,omitempty"`
+	Checksum    string    `json:"checksum,omitempty"`
+	Signer      string    `json:"signer,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// writeInstallInfo stores install metadata in the plugin directory.
+func writeInstallInfo(pluginDir string, info *entities.InstallInfo) error {
+	if info == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(installRecord{SchemaVersion: installSchemaVersion, installFields: installFields(*info)}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode install metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginDir, installInfoFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write install metadata: %w", err)
+	}
+	return nil
+}
+
+// readInstallInfo loads install metadata, returning nil for plugins
+// installed before it was recorded.
+func readInstallInfo(pluginDir string) (*entities.InstallInfo, error) {
+	data, err := os.ReadFile(filepath.Join(pluginDir, installInfoFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read install metadata: %w", err)
+	}
+
+	var record installRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse install metadata: %w", err)
+	}
+	if record.SchemaVersion > installSchemaVersion {
+		return nil, fmt.Errorf("install metadata has schema version %d, newer than this kod supports (%d); please upgrade kod", record.SchemaVersion, installSchemaVersion)
+	}
+	info := entities.InstallInfo(record.installFields)
+	return &info, nil
+}
@@ -3,7 +3,6 @@ package repo
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -16,24 +15,75 @@ import (
 
 // PluginManifest represents the plugin.yml structure.
 type PluginManifest struct {
-	Name        string `yaml:"name"`
-	Interpreter string `yaml:"interpreter"`
-	Description string `yaml:"description"`
-	Entry       string `yaml:"entry"`
-	Usage       string `yaml:"usage"`
+	Name        string   `yaml:"name"`
+	Interpreter string   `yaml:"interpreter"`
+	Description string   `yaml:"description"`
+	Entry       string   `yaml:"entry"`
+	Usage       string   `yaml:"usage"`
+	Version     string   `yaml:"version"`
+	Tags        []string `yaml:"tags"`
+	Category    string   `yaml:"category"`
+
+	Limits      ports.LimitsConfig   `yaml:"limits"`
+	Permissions *PermissionsManifest `yaml:"permissions"`
+}
+
+// PermissionsManifest represents the permissions block of plugin.yml.
+type PermissionsManifest struct {
+	Sandbox      bool     `yaml:"sandbox"`
+	Network      bool     `yaml:"network"`
+	Filesystem   []string `yaml:"filesystem"`
+	Env          []string `yaml:"env"`
+	Secrets      []string `yaml:"secrets"`
+	Subprocesses bool     `yaml:"subprocesses"`
+}
+
+// toPlugin converts the manifest into a plugin entity located at path.
+func (m *PluginManifest) toPlugin(path string, addedAt time.Time) (*entities.Plugin, error) {
+	limits, err := m.Limits.Parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: limits: %w", err)
+	}
+
+	plugin := &entities.Plugin{
+		Name:        m.Name,
+		Interpreter: m.Interpreter,
+		Description: m.Description,
+		Entry:       m.Entry,
+		Usage:       m.Usage,
+		Version:     m.Version,
+		Tags:        entities.NormalizeTags(m.Tags),
+		Category:    strings.TrimSpace(m.Category),
+		Source:      path,
+		AddedAt:     addedAt,
+		Limits:      limits,
+	}
+	if m.Permissions != nil {
+		plugin.Permissions = &entities.Permissions{
+			Sandbox:      m.Permissions.Sandbox,
+			Network:      m.Permissions.Network,
+			Filesystem:   m.Permissions.Filesystem,
+			Env:          m.Permissions.Env,
+			Secrets:      m.Permissions.Secrets,
+			Subprocesses: m.Permissions.Subprocesses,
+		}
+	}
+	return plugin, nil
 }
 
 // PluginRepositoryImpl implements ports.PluginRepository using the filesystem.
 type PluginRepositoryImpl struct {
-	baseDir    string
-	pluginsDir string
+	baseDir     string
+	pluginsDir  string
+	configStore ports.ConfigStore
 }
 
 // NewPluginRepository creates a new PluginRepository implementation.
-func NewPluginRepository(baseDir string) ports.PluginRepository {
+func NewPluginRepository(baseDir string, configStore ports.ConfigStore) ports.PluginRepository {
 	return &PluginRepositoryImpl{
-		baseDir:    baseDir,
-		pluginsDir: filepath.Join(baseDir, "plugins"),
+		baseDir:     baseDir,
+		pluginsDir:  filepath.Join(baseDir, "plugins"),
+		configStore: configStore,
 	}
 }
 
@@ -51,7 +101,8 @@ func (pr *PluginRepositoryImpl) List() ([]entities.Plugin, error) {
 	}
 
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		// Hidden directories are staging areas, not plugins
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
 			continue
 		}
 
@@ -73,49 +124,367 @@ func (pr *PluginRepositoryImpl) Get(name string) (*entities.Plugin, error) {
 	return pr.readPlugin(pluginPath)
 }
 
-// Add registers a new plugin from a local path or remote URL.
-// For now, only local paths are supported.
-func (pr *PluginRepositoryImpl) Add(source string) (*entities.Plugin, error) {
-	// Check if source is a remote URL
-	if isURL(source) {
-		// Create temp directory for cloning
-		tempDir, err := os.MkdirTemp("", "kodkafa-plugin-*")
+// Inspect reads and validates the manifest of a local path or remote URL
+// without installing the plugin.
+func (pr *PluginRepositoryImpl) Inspect(source entities.Source) (*entities.Plugin, error) {
+	dir, install, cleanup, err := pr.fetch(source)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	manifest, err := pr.loadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	plugin, err := manifest.toPlugin(source.Raw, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	plugin.Install = install
+	return plugin, nil
+}
+
+// Stage fetches a plugin from a local path or remote URL and copies it next
+// to plugins/ without installing it. The returned plugin is read from the
+// staged copy, which is what Install later moves into place, so the plugin
+// reviewed before install is the one installed.
+func (pr *PluginRepositoryImpl) Stage(source entities.Source) (ports.StagedPlugin, error) {
+	dir, install, cleanup, err := pr.fetch(source)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	manifest, err := pr.loadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(filepath.Join(pr.pluginsDir, manifest.Name)); err == nil {
+		return nil, fmt.Errorf("plugin %s already exists", manifest.Name)
+	}
+
+	// Stage the copy next to plugins/<name> so a failed copy never leaves a
+	// half-installed plugin behind
+	staged, err := pr.stage(manifest.Name, dir)
+	if err != nil {
+		return nil, err
+	}
+	plugin, err := pr.readStaged(staged, install, source.Checksum)
+	if err != nil {
+		os.RemoveAll(staged)
+		return nil, err
+	}
+	return &stagedPlugin{dir: staged, plugin: plugin}, nil
+}
+
+// readStaged reads the plugin back from its staged copy, since a local
+// source may have changed while it was copied, and seals the copy.
+func (pr *PluginRepositoryImpl) readStaged(staged string, install *entities.InstallInfo, expected string) (*entities.Plugin, error) {
+	manifest, err := pr.loadManifest(staged)
+	if err != nil {
+		return nil, err
+	}
+	plugin, err := manifest.toPlugin(filepath.Join(pr.pluginsDir, manifest.Name), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	install.Version = manifest.Version
+	if err := pr.seal(staged, install, expected); err != nil {
+		return nil, err
+	}
+	plugin.Install = install
+	return plugin, nil
+}
+
+// stagedPlugin is a plugin copied to a temp dir inside plugins/.
+type stagedPlugin struct {
+	dir    string
+	plugin *entities.Plugin
+}
+
+// Plugin returns the staged plugin as it will be installed.
+func (s *stagedPlugin) Plugin() *entities.Plugin {
+	return s.plugin
+}
+
+// Install moves the staged copy to plugins/<name>.
+func (s *stagedPlugin) Install() (*entities.Plugin, error) {
+	if _, err := os.Stat(s.plugin.Source); err == nil {
+		return nil, fmt.Errorf("plugin %s already exists", s.plugin.Name)
+	}
+	if err := writeInstallInfo(s.dir, s.plugin.Install); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(s.dir, s.plugin.Source); err != nil {
+		return nil, fmt.Errorf("failed to install plugin: %w", err)
+	}
+	return s.plugin, nil
+}
+
+// Discard removes the staged copy. It does nothing after Install.
+func (s *stagedPlugin) Discard() {
+	os.RemoveAll(s.dir)
+}
+
+// Link registers a local plugin directory by reference. Only install
+// metadata is written to plugins/<name>; the manifest and code are read from
+// the source directory every time, so edits take effect immediately.
+func (pr *PluginRepositoryImpl) Link(source entities.Source) (*entities.Plugin, error) {
+	if source.Kind != entities.SourceLocal || source.IsArchive() {
+		return nil, fmt.Errorf("only local directories can be linked")
+	}
+	root, err := filepath.Abs(source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source path: %w", err)
+	}
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		return nil, fmt.Errorf("only local directories can be linked")
+	}
+
+	dir, install, cleanup, err := pr.fetch(entities.Source{Raw: root, Kind: entities.SourceLocal, Path: root, Subdir: source.Subdir})
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	manifest, err := pr.loadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	targetDir := filepath.Join(pr.pluginsDir, manifest.Name)
+	plugin, err := manifest.toPlugin(dir, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(targetDir); err == nil {
+		return nil, fmt.Errorf("plugin %s already exists", manifest.Name)
+	}
+
+	install.Version = manifest.Version
+	install.Linked = true
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create plugin directory: %w", err)
+	}
+	if err := writeInstallInfo(targetDir, install); err != nil {
+		os.RemoveAll(targetDir)
+		return nil, err
+	}
+
+	plugin.Install = install
+	return plugin, nil
+}
+
+// Update replaces an installed plugin with a fresh copy of source. The new
+// copy is staged next to the plugin and swapped in by rename, so a failed
+// update leaves the installed version untouched; the replaced copy is kept
+// under .versions for rollback. accept is called with the fetched version
+// before anything is staged.
+func (pr *PluginRepositoryImpl) Update(name string, source entities.Source, accept func(next *entities.Plugin) error) (*entities.Plugin, entities.ChangeSet, error) {
+	var changes entities.ChangeSet
+
+	targetDir := filepath.Join(pr.pluginsDir, name)
+	if _, err := os.Stat(targetDir); err != nil {
+		return nil, changes, fmt.Errorf("plugin %s not found", name)
+	}
+
+	dir, install, cleanup, err := pr.fetch(source)
+	if err != nil {
+		return nil, changes, err
+	}
+	defer cleanup()
+
+	manifest, err := pr.loadManifest(dir)
+	if err != nil {
+		return nil, changes, err
+	}
+	if manifest.Name != name {
+		return nil, changes, fmt.Errorf("source now provides plugin %s, not %s", manifest.Name, name)
+	}
+	plugin, err := manifest.toPlugin(targetDir, time.Now())
+	if err != nil {
+		return nil, changes, err
+	}
+	if accept != nil {
+		if err := accept(plugin); err != nil {
+			return nil, changes, err
+		}
+	}
+
+	staged, err := pr.stage(name, dir)
+	if err != nil {
+		return nil, changes, err
+	}
+	defer os.RemoveAll(staged)
+
+	changes, err = diffDirectories(targetDir, staged)
+	if err != nil {
+		return nil, changes, fmt.Errorf("failed to compare plugin files: %w", err)
+	}
+	install.Version = manifest.Version
+	plugin.Install = install
+	if err := pr.seal(staged, install, source.Checksum); err != nil {
+		return nil, changes, err
+	}
+	if changes.IsEmpty() {
+		// Same code, possibly from a new commit: just record where it came from
+		if install.Checksum, err = installedHash(targetDir); err != nil {
+			return nil, changes, fmt.Errorf("failed to hash plugin: %w", err)
+		}
+		return plugin, changes, writeInstallInfo(targetDir, install)
+	}
+
+	if err := writeInstallInfo(staged, install); err != nil {
+		return nil, changes, err
+	}
+	if err := pr.activate(name, staged); err != nil {
+		return nil, changes, err
+	}
+	return plugin, changes, nil
+}
+
+// fetch resolves a source to a local plugin directory: git sources are
+// cloned at the requested ref, archives downloaded and unpacked, and single
+// scripts wrapped with a generated plugin.yml, all in a temp dir. The
+// returned cleanup func removes anything fetch created.
+func (pr *PluginRepositoryImpl) fetch(src entities.Source) (dir string, install *entities.InstallInfo, cleanup func(), err error) {
+	cleanup = func() {}
+	install = &entities.InstallInfo{Subdir: src.Subdir, InstalledAt: time.Now()}
+	root := src.Path
+
+	var tempDir string
+	newTempDir := func() error {
+		tempDir, err = os.MkdirTemp("", "kodkafa-plugin-*")
 		if err != nil {
-			return nil, fmt.Errorf("failed to create temp dir: %w", err)
+			return fmt.Errorf("failed to create temp dir: %w", err)
+		}
+		return nil
+	}
+	defer func() {
+		if err != nil && tempDir != "" {
+			os.RemoveAll(tempDir)
+		}
+	}()
+
+	switch {
+	case src.IsArchive():
+		if src.Kind == entities.SourceSSH {
+			return "", nil, func() {}, fmt.Errorf("archives can only be downloaded over http or https")
+		}
+		if err := newTempDir(); err != nil {
+			return "", nil, func() {}, err
+		}
+		archive := src.Path
+		if src.IsRemote() {
+			install.Origin = src.URL()
+			if archive, err = downloadArchive(src.URL(), tempDir); err != nil {
+				return "", nil, func() {}, err
+			}
+		} else if install.Origin, err = filepath.Abs(src.Path); err != nil {
+			return "", nil, func() {}, fmt.Errorf("invalid source path: %w", err)
+		}
+
+		extracted := filepath.Join(tempDir, "src")
+		if err = os.Mkdir(extracted, 0755); err != nil {
+			return "", nil, func() {}, fmt.Errorf("failed to create temp dir: %w", err)
+		}
+		if err = extractArchive(archive, src.Path, extracted); err != nil {
+			return "", nil, func() {}, err
 		}
-		defer os.RemoveAll(tempDir) // Clean up
+		root = archiveRoot(extracted)
 
-		// Clone repository
-		cmd := exec.Command("git", "clone", source, tempDir)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			return nil, fmt.Errorf("git clone failed: %s: %w", string(output), err)
+	case src.IsGit():
+		if err := newTempDir(); err != nil {
+			return "", nil, func() {}, err
+		}
+		ref, commit, err := gitClone(src.URL(), src.Ref, tempDir)
+		if err != nil {
+			return "", nil, func() {}, err
 		}
+		install.Origin = src.URL()
+		install.Ref = ref
+		install.Commit = commit
+		root = tempDir
+
+	default:
+		if install.Origin, err = filepath.Abs(root); err != nil {
+			return "", nil, func() {}, fmt.Errorf("invalid source path: %w", err)
+		}
+		if info, statErr := os.Stat(root); statErr == nil && !info.IsDir() {
+			if src.Subdir != "" {
+				return "", nil, func() {}, fmt.Errorf("subdir needs a directory or archive source")
+			}
+			if err := newTempDir(); err != nil {
+				return "", nil, func() {}, err
+			}
+			if err = wrapScript(root, tempDir); err != nil {
+				return "", nil, func() {}, err
+			}
+			root = tempDir
+		}
+	}
 
-		// Update source to point to temp dir
-		source = tempDir
+	dir, err = resolveSubdir(root, src.Subdir)
+	if err != nil {
+		return "", nil, func() {}, err
 	}
 
 	// Check if source is a local path
-	sourceInfo, err := os.Stat(source)
+	sourceInfo, err := os.Stat(dir)
 	if err != nil {
-		return nil, fmt.Errorf("source path does not exist: %w", err)
+		return "", nil, func() {}, fmt.Errorf("source path does not exist: %w", err)
 	}
-
 	if !sourceInfo.IsDir() {
-		return nil, fmt.Errorf("source must be a directory")
+		return "", nil, func() {}, fmt.Errorf("source must be a directory")
+	}
+
+	if tempDir != "" {
+		cleanup = func() { os.RemoveAll(tempDir) }
 	}
+	return dir, install, cleanup, nil
+}
 
-	// Read plugin manifest
-	manifestPath := filepath.Join(source, "plugin.yml")
+// resolveSubdir joins subdir onto root, refusing paths that leave root.
+func resolveSubdir(root, subdir string) (string, error) {
+	if subdir == "" {
+		return root, nil
+	}
+	if !filepath.IsLocal(subdir) {
+		return "", fmt.Errorf("invalid subdir %q: must be a relative path inside the source", subdir)
+	}
+
+	dir := filepath.Join(root, subdir)
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("source path does not exist: %w", err)
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("subdir %s not found in source", subdir)
+	}
+	if rel, err := filepath.Rel(realRoot, realDir); err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return "", fmt.Errorf("invalid subdir %q: must be a relative path inside the source", subdir)
+	}
+	return dir, nil
+}
+
+// loadManifest reads and validates plugin.yml from a plugin source directory.
+func (pr *PluginRepositoryImpl) loadManifest(dir string) (*PluginManifest, error) {
+	manifestPath := filepath.Join(dir, "plugin.yml")
 	manifest, err := pr.readManifest(manifestPath)
 	if err != nil {
-		return nil, fmt.Errorf("this does not look like a KODKAFA plugin. plugin.yml NOT FOUND at %s", source)
+		return nil, fmt.Errorf("this does not look like a KODKAFA plugin. plugin.yml NOT FOUND at %s", dir)
 	}
 
 	// Validate manifest
 	if manifest.Name == "" {
 		return nil, fmt.Errorf("missing config: name is required")
 	}
+	if !entities.IsValidPluginName(manifest.Name) {
+		return nil, fmt.Errorf("invalid config: name %q may only contain letters, digits, '-' and '_'", manifest.Name)
+	}
 	if manifest.Interpreter == "" {
 		return nil, fmt.Errorf("missing config: interpreter is required")
 	}
@@ -123,29 +492,7 @@ func (pr *PluginRepositoryImpl) Add(source string) (*entities.Plugin, error) {
 		return nil, fmt.Errorf("missing config: entry is required")
 	}
 
-	// Check if plugin already exists in plugins/
-	targetDir := filepath.Join(pr.pluginsDir, manifest.Name)
-	if _, err := os.Stat(targetDir); err == nil {
-		return nil, fmt.Errorf("plugin %s already exists", manifest.Name)
-	}
-
-	// Copy plugin directory to plugins/
-	if err := pr.copyDirectory(source, targetDir); err != nil {
-		return nil, fmt.Errorf("failed to copy plugin: %w", err)
-	}
-
-	// Create plugin entity
-	plugin := &entities.Plugin{
-		Name:        manifest.Name,
-		Interpreter: manifest.Interpreter,
-		Description: manifest.Description,
-		Entry:       manifest.Entry,
-		Usage:       manifest.Usage,
-		Source:      targetDir,
-		AddedAt:     time.Now(),
-	}
-
-	return plugin, nil
+	return manifest, nil
 }
 
 // Remove removes a plugin by name.
@@ -163,6 +510,10 @@ func (r *PluginRepositoryImpl) RemoveDeps(name string) error {
 
 	// Shared Node/Python cleanup is now handled by DependencyInstaller.Uninstall
 	// This method remains for local (within-plugin-folder) cleanup if any.
+	// A linked plugin's folder is the developer's working copy: leave it alone.
+	if plugin.IsLinked() {
+		return nil
+	}
 
 	var depsFolders []string
 	switch plugin.Interpreter {
@@ -206,21 +557,36 @@ func (pr *PluginRepositoryImpl) readPlugin(path string) (*entities.Plugin, error
 		return nil, fmt.Errorf("failed to get plugin info: %w", err)
 	}
 
-	manifestPath := filepath.Join(path, "plugin.yml")
+	install, err := readInstallInfo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// A linked plugin lives in its source directory
+	dir := path
+	if install != nil && install.Linked {
+		dir = filepath.Join(install.Origin, install.Subdir)
+		if info, err = os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("linked plugin source is gone: %w", err)
+		}
+	}
+
+	manifestPath := filepath.Join(dir, "plugin.yml")
 	manifest, err := pr.readManifest(manifestPath)
 	if err != nil {
 		return nil, err
 	}
 
-	return &entities.Plugin{
-		Name:        manifest.Name,
-		Interpreter: manifest.Interpreter,
-		Description: manifest.Description,
-		Entry:       manifest.Entry,
-		Usage:       manifest.Usage,
-		Source:      path,
-		AddedAt:     info.ModTime(),
-	}, nil
+	plugin, err := manifest.toPlugin(dir, info.ModTime())
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", manifestPath, err)
+	}
+	plugin.Install = install
+	if plugin.IsLinked() {
+		// The link keeps the name it was registered under
+		plugin.Name = filepath.Base(path)
+	}
+	return plugin, nil
 }
 
 // readManifest reads and parses a plugin.yml file.
@@ -238,43 +604,79 @@ func (pr *PluginRepositoryImpl) readManifest(path string) (*PluginManifest, erro
 	return &manifest, nil
 }
 
-// copyDirectory copies a directory recursively.
-func (pr *PluginRepositoryImpl) copyDirectory(src, dst string) error {
-	// Create destination directory
-	if err := os.MkdirAll(dst, 0755); err != nil {
-		return fmt.Errorf("failed to create destination directory: %w", err)
+// stage copies the plugin in dir to a temp dir inside plugins/, on the same
+// filesystem as the final location so it can be moved into place by rename.
+// The caller removes the staged dir once it is no longer needed.
+func (pr *PluginRepositoryImpl) stage(name, dir string) (string, error) {
+	policy := pr.config().SymlinkPolicy
+	if policy == "" {
+		policy = ports.SymlinkPreserve
 	}
 
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
-		}
-
-		dstPath := filepath.Join(dst, relPath)
+	if err := os.MkdirAll(pr.pluginsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create plugins directory: %w", err)
+	}
+	staged, err := os.MkdirTemp(pr.pluginsDir, "."+name+"-stage-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to stage plugin: %w", err)
+	}
+	if err := os.Chmod(staged, 0755); err != nil {
+		os.RemoveAll(staged)
+		return "", err
+	}
+	if err := copyTree(dir, staged, policy); err != nil {
+		os.RemoveAll(staged)
+		return "", fmt.Errorf("failed to copy plugin: %w", err)
+	}
+	return staged, nil
+}
 
-		if info.IsDir() {
-			return os.MkdirAll(dstPath, info.Mode())
-		}
+// seal records the content hash of the staged plugin in install, compares
+// it with the expected checksum if there is one and checks the signature
+// the plugin ships against the keyring. Unsigned plugins are refused when
+// require_signatures is set.
+func (pr *PluginRepositoryImpl) seal(staged string, install *entities.InstallInfo, expected string) error {
+	checksum, err := installedHash(staged)
+	if err != nil {
+		return fmt.Errorf("failed to hash plugin: %w", err)
+	}
+	if expected != "" && checksum != expected {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, checksum)
+	}
+	signer, err := pr.verifySignature(staged, checksum)
+	if err != nil {
+		return fmt.Errorf("signature verification failed: %w", err)
+	}
+	if signer == "" && pr.config().RequireSignatures {
+		return fmt.Errorf("plugin is not signed and require_signatures is set")
+	}
+	install.Checksum = checksum
+	install.Signer = signer
+	return nil
+}
 
-		// Read source file
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
+// Checksum computes the content hash of the plugin source tree in dir.
+func (pr *PluginRepositoryImpl) Checksum(dir string) (string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return "", err
+	}
+	return contentHash(dir)
+}
 
-		// Write destination file
-		return os.WriteFile(dstPath, data, info.Mode())
-	})
+// InstalledChecksum hashes the installed copy of a plugin.
+func (pr *PluginRepositoryImpl) InstalledChecksum(name string) (string, error) {
+	dir := filepath.Join(pr.pluginsDir, name)
+	if _, err := os.Stat(dir); err != nil {
+		return "", err
+	}
+	return installedHash(dir)
 }
 
-// isURL checks if the string looks like a URL or git connection string
-func isURL(s string) bool {
-	return strings.HasPrefix(s, "http://") ||
-		strings.HasPrefix(s, "https://") ||
-		strings.HasPrefix(s, "git@")
+// config returns the current configuration, or the zero config when it
+// cannot be read.
+func (pr *PluginRepositoryImpl) config() *ports.Config {
+	if config, err := pr.configStore.Read(); err == nil && config != nil {
+		return config
+	}
+	return &ports.Config{}
 }
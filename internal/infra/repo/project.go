@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+
+	"gopkg.in/yaml.v3"
+)
+
+// projectManifest is the on-disk form of a .kodkafa.yml.
+type projectManifest struct {
+	Name    string          `yaml:"name"`
+	Plugins []projectPlugin `yaml:"plugins"`
+}
+
+// projectPlugin accepts a plain source string or a mapping with a subdir.
+type projectPlugin struct {
+	Source string `yaml:"source"`
+	Subdir string `yaml:"subdir"`
+}
+
+func (p *projectPlugin) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&p.Source)
+	}
+	type plain projectPlugin
+	return node.Decode((*plain)(p))
+}
+
+var unsafeIDChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// ProjectStoreImpl implements ports.ProjectStore using .kodkafa.yml files.
+type ProjectStoreImpl struct{}
+
+// NewProjectStore creates a new ProjectStore implementation.
+func NewProjectStore() ports.ProjectStore {
+	return &ProjectStoreImpl{}
+}
+
+// Find walks up from dir to the nearest .kodkafa.yml.
+func (ps *ProjectStoreImpl) Find(dir string) (*entities.Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, entities.ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return loadProject(dir, path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func loadProject(root, path string) (*entities.Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var manifest projectManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	project := &entities.Project{Name: manifest.Name, Root: root}
+	if project.Name == "" {
+		project.Name = filepath.Base(root)
+	}
+	// The ID only depends on the root, so renaming a project keeps its state.
+	sum := sha256.Sum256([]byte(root))
+	slug := strings.Trim(unsafeIDChars.ReplaceAllString(strings.ToLower(filepath.Base(root)), "-"), "-")
+	project.ID = slug + "-" + hex.EncodeToString(sum[:4])
+
+	for i, p := range manifest.Plugins {
+		if strings.TrimSpace(p.Source) == "" {
+			return nil, fmt.Errorf("%s: plugin %d has no source", path, i+1)
+		}
+		project.Plugins = append(project.Plugins, entities.ProjectPlugin(p))
+	}
+	return project, nil
+}
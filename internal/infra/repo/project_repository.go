@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// ProjectPluginRepository layers a project's plugins over the global ones.
+// Project plugins shadow global plugins of the same name and are marked
+// with the project name; new plugins are added globally, project plugins
+// are installed through the project repository itself.
+type ProjectPluginRepository struct {
+	ports.PluginRepository // global plugins
+	project                ports.PluginRepository
+	name                   string
+}
+
+// NewProjectPluginRepository creates a repository combining the global
+// plugins with those of the named project.
+func NewProjectPluginRepository(global, project ports.PluginRepository, name string) ports.PluginRepository {
+	return &ProjectPluginRepository{PluginRepository: global, project: project, name: name}
+}
+
+// scope returns the repository holding the named plugin.
+func (pr *ProjectPluginRepository) scope(name string) ports.PluginRepository {
+	if ok, err := pr.project.Exists(name); err == nil && ok {
+		return pr.project
+	}
+	return pr.PluginRepository
+}
+
+func (pr *ProjectPluginRepository) mark(p *entities.Plugin, repo ports.PluginRepository) *entities.Plugin {
+	if p != nil && repo == pr.project {
+		p.Project = pr.name
+	}
+	return p
+}
+
+// List returns the project plugins followed by the global plugins they do
+// not shadow.
+func (pr *ProjectPluginRepository) List() ([]entities.Plugin, error) {
+	projectPlugins, err := pr.project.List()
+	if err != nil {
+		return nil, err
+	}
+	globalPlugins, err := pr.PluginRepository.List()
+	if err != nil {
+		return nil, err
+	}
+
+	shadowed := make(map[string]bool, len(projectPlugins))
+	plugins := make([]entities.Plugin, 0, len(projectPlugins)+len(globalPlugins))
+	for _, p := range projectPlugins {
+		p.Project = pr.name
+		shadowed[p.Name] = true
+		plugins = append(plugins, p)
+	}
+	for _, p := range globalPlugins {
+		if !shadowed[p.Name] {
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins, nil
+}
+
+// Get returns a plugin by name, project plugins first.
+func (pr *ProjectPluginRepository) Get(name string) (*entities.Plugin, error) {
+	repo := pr.scope(name)
+	p, err := repo.Get(name)
+	return pr.mark(p, repo), err
+}
+
+// Update replaces a project or global plugin.
+func (pr *ProjectPluginRepository) Update(name string, source entities.Source, accept func(next *entities.Plugin) error) (*entities.Plugin, entities.ChangeSet, error) {
+	repo := pr.scope(name)
+	p, changes, err := repo.Update(name, source, accept)
+	return pr.mark(p, repo), changes, err
+}
+
+// Versions lists the kept versions of a project or global plugin.
+func (pr *ProjectPluginRepository) Versions(name string) ([]entities.PluginVersion, error) {
+	return pr.scope(name).Versions(name)
+}
+
+// Rollback restores a kept version of a project or global plugin.
+func (pr *ProjectPluginRepository) Rollback(name, versionID string) (*entities.Plugin, entities.ChangeSet, error) {
+	repo := pr.scope(name)
+	p, changes, err := repo.Rollback(name, versionID)
+	return pr.mark(p, repo), changes, err
+}
+
+// PruneVersions prunes the kept versions of a project or global plugin.
+func (pr *ProjectPluginRepository) PruneVersions(name string, keep int) error {
+	return pr.scope(name).PruneVersions(name, keep)
+}
+
+// InstalledChecksum hashes the installed copy of a project or global plugin.
+func (pr *ProjectPluginRepository) InstalledChecksum(name string) (string, error) {
+	return pr.scope(name).InstalledChecksum(name)
+}
+
+// Remove removes a project plugin, or the global plugin if there is none.
+func (pr *ProjectPluginRepository) Remove(name string) error {
+	return pr.scope(name).Remove(name)
+}
+
+// RemoveDeps deletes the dependencies of a project or global plugin.
+func (pr *ProjectPluginRepository) RemoveDeps(name string) error {
+	return pr.scope(name).RemoveDeps(name)
+}
+
+// Exists checks both the project and the global plugins.
+func (pr *ProjectPluginRepository) Exists(name string) (bool, error) {
+	return pr.scope(name).Exists(name)
+}
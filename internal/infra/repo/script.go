@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// scriptExtensions maps single-file plugin extensions to interpreters.
+var scriptExtensions = map[string]string{
+	".py":  "python",
+	".js":  "node",
+	".mjs": "node",
+	".cjs": "node",
+	".r":   "r",
+}
+
+// shebangInterpreters maps shebang programs, without version suffix, to
+// interpreters.
+var shebangInterpreters = map[string]string{
+	"python":  "python",
+	"node":    "node",
+	"nodejs":  "node",
+	"rscript": "r",
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// wrapScript turns a single script file into a plugin directory inside dir,
+// next to a plugin.yml generated from the file name, extension and shebang.
+func wrapScript(file, dir string) error {
+	interpreter, err := scriptInterpreter(file)
+	if err != nil {
+		return err
+	}
+
+	base := filepath.Base(file)
+	name := strings.Trim(unsafeNameChars.ReplaceAllString(strings.ToLower(strings.TrimSuffix(base, filepath.Ext(base))), "-"), "-")
+	if name == "" {
+		return fmt.Errorf("cannot derive a plugin name from %s", base)
+	}
+
+	manifest := struct {
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+		Interpreter string `yaml:"interpreter"`
+		Entry       string `yaml:"entry"`
+		Usage       string `yaml:"usage"`
+	}{
+		Name:        name,
+		Description: "Single-file plugin generated from " + base,
+		Interpreter: interpreter,
+		Entry:       base,
+		Usage:       "kod run " + name,
+	}
+	data, err := yaml.Marshal(&manifest)
+	if err != nil {
+		return fmt.Errorf("failed to generate plugin.yml: %w", err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read script: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, base), content, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to copy script: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, "plugin.yml"), data, 0644)
+}
+
+// scriptInterpreter infers the interpreter from the shebang, falling back
+// to the file extension.
+func scriptInterpreter(file string) (string, error) {
+	if interpreter := shebangInterpreter(file); interpreter != "" {
+		return interpreter, nil
+	}
+	if interpreter, ok := scriptExtensions[strings.ToLower(filepath.Ext(file))]; ok {
+		return interpreter, nil
+	}
+	return "", fmt.Errorf("cannot tell the interpreter of %s: use a .py, .js or .R file, or a shebang", filepath.Base(file))
+}
+
+// shebangInterpreter reads "#!/usr/bin/env python3" style first lines.
+func shebangInterpreter(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	rest, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return ""
+	}
+	program := filepath.Base(fields[0])
+	if program == "env" {
+		// Skip env options such as -S
+		program = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				program = f
+				break
+			}
+		}
+	}
+	// python3, python3.12 -> python
+	program = strings.TrimRight(strings.ToLower(program), "0123456789.")
+	return shebangInterpreters[program]
+}
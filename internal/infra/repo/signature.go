@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// signatureFile is the detached signature a plugin may ship at its root.
+	// It signs the plugin's content hash (see contentHash), either as an SSH
+	// signature (ssh-keygen -Y sign -n kodkafa) or as a base64 ed25519
+	// signature.
+	signatureFile = "plugin.sig"
+	// keyringFile lists trusted signing keys in ssh allowed_signers format.
+	keyringFile = "keyring"
+	// signatureNamespace is the ssh-keygen -Y namespace of plugin signatures.
+	signatureNamespace = "kodkafa"
+)
+
+// keyringEntry is one line of the keyring: who signs with which key.
+type keyringEntry struct {
+	principal string
+	keyType   string
+	key       []byte // SSH wire format public key
+}
+
+// verifySignature checks the signature shipped in dir against the keyring
+// for the given content hash and returns the signer. It returns an empty
+// signer and no error when dir has no signature.
+func (pr *PluginRepositoryImpl) verifySignature(dir, checksum string) (string, error) {
+	sig, err := os.ReadFile(filepath.Join(dir, signatureFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", signatureFile, err)
+	}
+
+	keyring := filepath.Join(pr.baseDir, keyringFile)
+	entries, err := readKeyring(keyring)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("plugin is signed but the keyring (%s) has no keys", keyring)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN SSH SIGNATURE-----")) {
+		return verifySSHSignature(keyring, filepath.Join(dir, signatureFile), checksum)
+	}
+	return verifyEd25519Signature(entries, sig, checksum)
+}
+
+func verifyEd25519Signature(entries []keyringEntry, sig []byte, checksum string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || len(raw) != ed25519.SignatureSize {
+		return "", fmt.Errorf("%s is neither an SSH signature nor a base64 ed25519 signature", signatureFile)
+	}
+	for _, e := range entries {
+		if e.keyType != "ssh-ed25519" {
+			continue
+		}
+		pub, err := ed25519Key(e.key)
+		if err != nil {
+			continue
+		}
+		if ed25519.Verify(pub, []byte(checksum), raw) {
+			return e.principal, nil
+		}
+	}
+	return "", fmt.Errorf("signature does not match any key in the keyring")
+}
+
+func verifySSHSignature(keyring, sigPath, checksum string) (string, error) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		return "", fmt.Errorf("ssh-keygen is required to verify SSH signatures")
+	}
+
+	out, err := exec.Command("ssh-keygen", "-Y", "find-principals", "-f", keyring, "-s", sigPath).Output()
+	if err != nil {
+		return "", fmt.Errorf("signature does not match any key in the keyring")
+	}
+	for _, principal := range strings.Fields(string(out)) {
+		cmd := exec.Command("ssh-keygen", "-Y", "verify", "-f", keyring, "-I", principal, "-n", signatureNamespace, "-s", sigPath)
+		cmd.Stdin = strings.NewReader(checksum)
+		if cmd.Run() == nil {
+			return principal, nil
+		}
+	}
+	return "", fmt.Errorf("signature is not valid for this plugin's content")
+}
+
+// readKeyring parses an allowed_signers file: principals, optional options
+// and a public key per line. A missing keyring is empty.
+func readKeyring(path string) ([]keyringEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+	defer f.Close()
+
+	var entries []keyringEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for i := 1; i+1 < len(fields); i++ {
+			if !strings.HasPrefix(fields[i], "ssh-") && !strings.HasPrefix(fields[i], "ecdsa-") && !strings.HasPrefix(fields[i], "sk-") {
+				continue
+			}
+			key, err := base64.StdEncoding.DecodeString(fields[i+1])
+			if err != nil {
+				break
+			}
+			entries = append(entries, keyringEntry{principal: fields[0], keyType: fields[i], key: key})
+			break
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// ed25519Key extracts the raw key from an SSH wire format ed25519 public key:
+// the string "ssh-ed25519" followed by the 32 key bytes, each length-prefixed.
+func ed25519Key(blob []byte) (ed25519.PublicKey, error) {
+	var parts [][]byte
+	for len(blob) > 0 && len(parts) < 2 {
+		if len(blob) < 4 {
+			return nil, fmt.Errorf("malformed key")
+		}
+		n := binary.BigEndian.Uint32(blob)
+		if uint32(len(blob)-4) < n {
+			return nil, fmt.Errorf("malformed key")
+		}
+		parts = append(parts, blob[4:4+n])
+		blob = blob[4+n:]
+	}
+	if len(parts) != 2 || string(parts[0]) != "ssh-ed25519" || len(parts[1]) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("not an ed25519 key")
+	}
+	return ed25519.PublicKey(parts[1]), nil
+}
@@ -0,0 +1,179 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"kodkafa/internal/domain/entities"
+)
+
+// versionsDir holds previous versions inside each plugin directory:
+// plugins/<name>/.versions/<id>/
+const versionsDir = ".versions"
+
+// versionIDFormat names a kept version after the time it was installed.
+const versionIDFormat = "20060102-150405"
+
+// Versions lists the previous versions kept for a plugin, newest first.
+func (pr *PluginRepositoryImpl) Versions(name string) ([]entities.PluginVersion, error) {
+	dir := filepath.Join(pr.pluginsDir, name, versionsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions of %s: %w", name, err)
+	}
+
+	var versions []entities.PluginVersion
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		plugin, err := pr.readPlugin(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			// Skip broken copies but continue
+			continue
+		}
+		versions = append(versions, entities.PluginVersion{ID: entry.Name(), Plugin: *plugin})
+	}
+
+	// IDs are timestamps, so they sort chronologically
+	sort.Slice(versions, func(i, j int) bool { return versions[i].ID > versions[j].ID })
+	return versions, nil
+}
+
+// Rollback makes a kept version active again. The current version is kept
+// in its place so the rollback can itself be undone.
+func (pr *PluginRepositoryImpl) Rollback(name, versionID string) (*entities.Plugin, entities.ChangeSet, error) {
+	var changes entities.ChangeSet
+
+	if !filepath.IsLocal(versionID) || filepath.Base(versionID) != versionID {
+		return nil, changes, fmt.Errorf("invalid version %q", versionID)
+	}
+	targetDir := filepath.Join(pr.pluginsDir, name)
+	versionDir := filepath.Join(targetDir, versionsDir, versionID)
+	if _, err := pr.readPlugin(versionDir); err != nil {
+		return nil, changes, fmt.Errorf("version %s of %s not found", versionID, name)
+	}
+
+	changes, err := diffDirectories(targetDir, versionDir)
+	if err != nil {
+		return nil, changes, fmt.Errorf("failed to compare plugin files: %w", err)
+	}
+
+	// Move the version out of the plugin dir so it can be swapped in
+	staged, err := os.MkdirTemp(pr.pluginsDir, "."+name+"-rollback-*")
+	if err != nil {
+		return nil, changes, fmt.Errorf("failed to stage rollback: %w", err)
+	}
+	os.Remove(staged)
+	if err := os.Rename(versionDir, staged); err != nil {
+		return nil, changes, fmt.Errorf("failed to stage rollback: %w", err)
+	}
+	if err := pr.activate(name, staged); err != nil {
+		if restoreErr := os.Rename(staged, versionDir); restoreErr != nil {
+			return nil, changes, fmt.Errorf("%w (version %s left at %s)", err, versionID, staged)
+		}
+		return nil, changes, err
+	}
+
+	plugin, err := pr.readPlugin(targetDir)
+	if err != nil {
+		return nil, changes, err
+	}
+	return plugin, changes, nil
+}
+
+// PruneVersions deletes all but the newest keep versions of a plugin.
+func (pr *PluginRepositoryImpl) PruneVersions(name string, keep int) error {
+	dir := filepath.Join(pr.pluginsDir, name, versionsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read versions of %s: %w", name, err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+
+	var errs []error
+	for _, id := range ids[min(keep, len(ids)):] {
+		if err := os.RemoveAll(filepath.Join(dir, id)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// activate makes staged, a complete plugin directory inside pluginsDir, the
+// active copy of the plugin. The current copy moves to .versions and the
+// existing versions move along with it. On failure the current copy stays
+// active.
+func (pr *PluginRepositoryImpl) activate(name, staged string) error {
+	targetDir := filepath.Join(pr.pluginsDir, name)
+	oldVersions := filepath.Join(targetDir, versionsDir)
+	newVersions := filepath.Join(staged, versionsDir)
+
+	// 1. Carry the kept versions over to the staged copy
+	if err := os.RemoveAll(newVersions); err != nil {
+		return fmt.Errorf("failed to stage plugin: %w", err)
+	}
+	if _, err := os.Stat(oldVersions); err == nil {
+		if err := os.Rename(oldVersions, newVersions); err != nil {
+			return fmt.Errorf("failed to move versions: %w", err)
+		}
+	} else if err := os.Mkdir(newVersions, 0755); err != nil {
+		return fmt.Errorf("failed to create versions dir: %w", err)
+	}
+	restoreVersions := func() { os.Rename(newVersions, oldVersions) }
+
+	// 2. Retire the current copy into the versions
+	retired := filepath.Join(newVersions, pr.versionID(targetDir, newVersions))
+	if err := os.Rename(targetDir, retired); err != nil {
+		restoreVersions()
+		return fmt.Errorf("failed to replace plugin: %w", err)
+	}
+
+	// 3. Swap the staged copy in
+	if err := os.Rename(staged, targetDir); err != nil {
+		if restoreErr := os.Rename(retired, targetDir); restoreErr != nil {
+			return fmt.Errorf("failed to replace plugin: %w (previous version left at %s)", err, retired)
+		}
+		restoreVersions()
+		return fmt.Errorf("failed to replace plugin: %w", err)
+	}
+	return nil
+}
+
+// versionID names the retired copy in pluginDir after its install time,
+// adding a counter when that name is taken in versions.
+func (pr *PluginRepositoryImpl) versionID(pluginDir, versions string) string {
+	installedAt := time.Now()
+	if info, err := readInstallInfo(pluginDir); err == nil && info != nil {
+		installedAt = info.InstalledAt
+	} else if stat, err := os.Stat(pluginDir); err == nil {
+		installedAt = stat.ModTime()
+	}
+
+	base := installedAt.UTC().Format(versionIDFormat)
+	id := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(versions, id)); os.IsNotExist(err) {
+			return id
+		}
+		id = base + "-" + strconv.Itoa(n)
+	}
+}
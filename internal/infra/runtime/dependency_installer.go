@@ -6,6 +6,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"kodkafa/internal/domain/entities"
 	"kodkafa/internal/domain/ports"
@@ -55,12 +58,85 @@ func (i *FSInstaller) installPython(plugin *entities.Plugin) error {
 	venvPath := filepath.Join(i.baseDir, "core", "python", "venv")
 	pipPath := filepath.Join(venvPath, "bin", "pip")
 
-	cmd := exec.Command(pipPath, "install", "-r", "requirements.txt")
+	// Pinning the versions this copy was installed with makes pip go back
+	// to them in the shared venv, e.g. after a rollback
+	args := append([]string{"install", "-r", "requirements.txt"}, readSnapshot(plugin)...)
+	cmd := exec.Command(pipPath, args...)
 	cmd.Dir = plugin.Source
 	if out, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("core pip install failed: %w (output: %s)", err, string(out))
 	}
-	return nil
+
+	names, err := requirementNames(reqPath)
+	if err != nil {
+		return err
+	}
+	pins, err := pythonPins(pipPath, names)
+	if err != nil {
+		return fmt.Errorf("dependencies installed but their versions could not be recorded: %w", err)
+	}
+	return writeSnapshot(plugin, pins)
+}
+
+var (
+	// requirementName matches the project name at the start of a requirement.
+	requirementName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*`)
+	// nameSeparators are the runs pip folds into '-' when comparing names.
+	nameSeparators = regexp.MustCompile(`[-_.]+`)
+)
+
+// requirementNames returns the normalized names of the projects a
+// requirements file lists. Options, includes and URL requirements have no
+// name to pin and are skipped.
+func requirementNames(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read requirements.txt: %w", err)
+	}
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		if name := requirementName.FindString(line); name != "" {
+			names = append(names, normalizePythonName(name))
+		}
+	}
+	return names, nil
+}
+
+// normalizePythonName folds case and runs of '-', '_' and '.', which pip
+// treats as the same name.
+func normalizePythonName(name string) string {
+	return strings.ToLower(nameSeparators.ReplaceAllString(name, "-"))
+}
+
+// pythonPins returns "name==version" for each of names installed in the venv.
+func pythonPins(pipPath string, names []string) ([]string, error) {
+	out, err := exec.Command(pipPath, "list", "--format=json", "--disable-pip-version-check").Output()
+	if err != nil {
+		return nil, fmt.Errorf("pip list failed: %w", err)
+	}
+	var installed []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(out, &installed); err != nil {
+		return nil, fmt.Errorf("failed to parse pip list: %w", err)
+	}
+	versions := make(map[string]string, len(installed))
+	for _, p := range installed {
+		versions[normalizePythonName(p.Name)] = p.Version
+	}
+
+	var pins []string
+	for _, name := range names {
+		if version, ok := versions[name]; ok {
+			pins = append(pins, name+"=="+version)
+		}
+	}
+	return pins, nil
 }
 
 func (i *FSInstaller) uninstallPython(plugin *entities.Plugin) error {
@@ -100,11 +176,22 @@ func (i *FSInstaller) installNode(plugin *entities.Plugin) error {
 		return fmt.Errorf("failed to create core node dir: %w", err)
 	}
 
-	// Construct list of packages to install (e.g. "axios@^1.0.0")
+	// Construct list of packages to install (e.g. "axios@^1.0.0"), pinned
+	// to the versions this copy was installed with, if recorded
+	pinned := make(map[string]string)
+	for _, pin := range readSnapshot(plugin) {
+		if at := strings.LastIndex(pin, "@"); at > 0 {
+			pinned[pin[:at]] = pin
+		}
+	}
 	var installArgs []string
 	installArgs = append(installArgs, "install")
 	for name, version := range pkg.Dependencies {
-		installArgs = append(installArgs, fmt.Sprintf("%s@%s", name, version))
+		spec, ok := pinned[name]
+		if !ok {
+			spec = fmt.Sprintf("%s@%s", name, version)
+		}
+		installArgs = append(installArgs, spec)
 	}
 
 	cmd := exec.Command("npm", installArgs...)
@@ -113,16 +200,77 @@ func (i *FSInstaller) installNode(plugin *entities.Plugin) error {
 		return fmt.Errorf("npm install in core failed: %w (output: %s)", err, string(out))
 	}
 
-	// 3. Cleanup: Ensure no node_modules in plugin dir
-	pluginNodeModules := filepath.Join(plugin.Source, "node_modules")
-	_ = os.RemoveAll(pluginNodeModules)
+	// 3. Cleanup: Ensure no node_modules in plugin dir (unless it is a
+	// linked working copy, which the developer manages)
+	if !plugin.IsLinked() {
+		pluginNodeModules := filepath.Join(plugin.Source, "node_modules")
+		_ = os.RemoveAll(pluginNodeModules)
+	}
 
-	return nil
+	// 4. Record the versions npm picked
+	var pins []string
+	for name := range pkg.Dependencies {
+		data, err := os.ReadFile(filepath.Join(nodeCoreDir, "node_modules", filepath.FromSlash(name), "package.json"))
+		if err != nil {
+			continue
+		}
+		var installed struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(data, &installed) == nil && installed.Version != "" {
+			pins = append(pins, name+"@"+installed.Version)
+		}
+	}
+	sort.Strings(pins)
+	return writeSnapshot(plugin, pins)
 }
 
 func (i *FSInstaller) uninstallNode(plugin *entities.Plugin) error {
 	// For now, we don't prune the central node_modules to avoid breaking other plugins.
 	// Just remove the symlink in the plugin directory.
+	if plugin.IsLinked() {
+		return nil
+	}
 	pluginNodeModules := filepath.Join(plugin.Source, "node_modules")
 	return os.RemoveAll(pluginNodeModules)
 }
+
+// depsSnapshot is the on-disk form of entities.DependencySnapshotFile.
+type depsSnapshot struct {
+	Packages []string `json:"packages"` // pip or npm specs, e.g. "requests==2.31.0"
+}
+
+// readSnapshot returns the exact versions recorded for the plugin copy, or
+// nil if none were. Linked plugins are never pinned: their dependency files
+// change while they are developed.
+func readSnapshot(plugin *entities.Plugin) []string {
+	if plugin.IsLinked() {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(plugin.Source, entities.DependencySnapshotFile))
+	if err != nil {
+		return nil
+	}
+	var snapshot depsSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot.Packages
+}
+
+// writeSnapshot records the exact versions installed for the plugin copy.
+// It moves with the copy when the plugin is updated, so rolling back to it
+// restores these versions.
+func writeSnapshot(plugin *entities.Plugin, pins []string) error {
+	if plugin.IsLinked() {
+		return nil
+	}
+	data, err := json.MarshalIndent(depsSnapshot{Packages: pins}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(plugin.Source, entities.DependencySnapshotFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to record dependency versions: %w", err)
+	}
+	return nil
+}
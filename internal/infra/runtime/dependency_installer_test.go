@@ -0,0 +1,57 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"kodkafa/internal/domain/entities"
+)
+
+func TestRequirementNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requirements.txt")
+	content := `# pinned by the plugin
+requests>=2.31
+Flask_SQLAlchemy[async]==3.1 ; python_version >= "3.8"
+ruamel.yaml
+  numpy  # indented, with a comment
+-r other.txt
+--find-links ./wheels
+-e ./vendored
+pkg @ https://example.com/pkg-1.0.tar.gz
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := requirementNames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"requests", "flask-sqlalchemy", "ruamel-yaml", "numpy"}
+	if !slices.Equal(got, want) {
+		t.Errorf("requirementNames() = %q, want %q", got, want)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	installed := &entities.Plugin{Source: t.TempDir()}
+	if got := readSnapshot(installed); got != nil {
+		t.Errorf("readSnapshot() = %q without a snapshot, want nil", got)
+	}
+	pins := []string{"requests==2.31.0", "numpy==1.26.4"}
+	if err := writeSnapshot(installed, pins); err != nil {
+		t.Fatal(err)
+	}
+	if got := readSnapshot(installed); !slices.Equal(got, pins) {
+		t.Errorf("readSnapshot() = %q, want %q", got, pins)
+	}
+
+	linked := &entities.Plugin{Source: t.TempDir(), Install: &entities.InstallInfo{Linked: true}}
+	if err := writeSnapshot(linked, pins); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(linked.Source, entities.DependencySnapshotFile)); err == nil {
+		t.Error("writeSnapshot() wrote into a linked plugin's source")
+	}
+}
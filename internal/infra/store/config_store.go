@@ -1,32 +1,306 @@
 package store
 
 import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
 	"kodkafa/internal/domain/ports"
 )
 
+// defaultConfig holds every setting with its default value. A user's
+// config.json is merged over it, so settings added in newer versions apply
+// without editing existing files.
+//
+//go:embed default_config.json
+var defaultConfig []byte
+
+const configFile = "config.json"
+
 // ConfigStoreImpl implements ports.ConfigStore using JSON files.
 type ConfigStoreImpl struct {
 	store *JSONStore
+	path  string
 }
 
 // NewConfigStore creates a new ConfigStore implementation.
 func NewConfigStore(baseDir string) ports.ConfigStore {
 	return &ConfigStoreImpl{
 		store: NewJSONStore(baseDir),
+		path:  filepath.Join(baseDir, configFile),
 	}
 }
 
-// Read reads the global configuration.
+// Read reads the global configuration merged over the defaults.
 func (cs *ConfigStoreImpl) Read() (*ports.Config, error) {
-	var config ports.Config
-	err := cs.store.Read("config.json", &config)
+	data, err := cs.readFile()
 	if err != nil {
 		return nil, err
 	}
-	return &config, nil
+	config, _, err := cs.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := cs.validate(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
 }
 
-// Write persists the global configuration.
+// Write persists the global configuration after validating it.
 func (cs *ConfigStoreImpl) Write(config *ports.Config) error {
-	return cs.store.Write("config.json", config)
+	if err := cs.validate(nil, config); err != nil {
+		return err
+	}
+	config.SchemaVersion = configSchemaVersion
+	return cs.store.Write(configFile, config)
+}
+
+// Update applies fn to the configuration under the file lock. Only the
+// result is validated, so an update can repair an invalid setting. Only the
+// settings fn changed are written back, into the user's own document, so
+// defaults and unknown keys stay as they were (see applyChanges).
+func (cs *ConfigStoreImpl) Update(fn func(*ports.Config) error) error {
+	var raw json.RawMessage
+	return cs.store.Update(configFile, &raw, func(exists bool) error {
+		data := []byte(raw)
+		if !exists {
+			data = []byte("{}")
+		}
+		config, _, err := cs.parse(data)
+		if err != nil {
+			return err
+		}
+		before, err := toDoc(config)
+		if err != nil {
+			return err
+		}
+		if err := fn(config); err != nil {
+			return err
+		}
+		if err := cs.validate(data, config); err != nil {
+			return err
+		}
+		after, err := toDoc(config)
+		if err != nil {
+			return err
+		}
+
+		var doc, defaults map[string]any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(defaultConfig, &defaults); err != nil {
+			return fmt.Errorf("failed to parse default config: %w", err)
+		}
+		applyChanges(doc, before, after, defaults)
+		doc["schema_version"] = configSchemaVersion
+		raw, err = json.Marshal(doc)
+		return err
+	})
+}
+
+// toDoc encodes config as a generic JSON document.
+func toDoc(config *ports.Config) (map[string]any, error) {
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	err = json.Unmarshal(encoded, &doc)
+	return doc, err
+}
+
+// applyChanges writes the settings that differ between before and after
+// into doc, the user's own document, descending into objects. A setting
+// changed to its default is removed from doc instead, so it follows the
+// default again; settings fn left alone are not touched.
+func applyChanges(doc, before, after, defaults map[string]any) {
+	for key, next := range after {
+		prev, existed := before[key]
+		nextMap, nextOK := next.(map[string]any)
+		prevMap, prevOK := prev.(map[string]any)
+		if nextOK && prevOK {
+			sub, inDoc := doc[key].(map[string]any)
+			if !inDoc {
+				sub = map[string]any{}
+			}
+			defMap, _ := defaults[key].(map[string]any)
+			applyChanges(sub, prevMap, nextMap, defMap)
+			if inDoc || len(sub) > 0 {
+				doc[key] = sub
+			}
+			continue
+		}
+		if existed && reflect.DeepEqual(prev, next) {
+			continue
+		}
+		if def, ok := defaults[key]; ok && reflect.DeepEqual(def, next) {
+			delete(doc, key)
+		} else {
+			doc[key] = next
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			delete(doc, key)
+		}
+	}
+}
+
+// Check validates config.json and returns a warning for each key kod does
+// not know, with the line it is on.
+func (cs *ConfigStoreImpl) Check() ([]string, error) {
+	data, err := cs.readFile()
+	if err != nil {
+		return nil, err
+	}
+	config, unknown, err := cs.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := cs.validate(data, config); err != nil {
+		return nil, err
+	}
+
+	lines := keyLines(data)
+	sort.SliceStable(unknown, func(i, j int) bool { return lines[unknown[i]] < lines[unknown[j]] })
+	warnings := make([]string, 0, len(unknown))
+	for _, key := range unknown {
+		warnings = append(warnings, fmt.Sprintf("%s:%d: unknown key %q", cs.path, lines[key], key))
+	}
+	return warnings, nil
+}
+
+// Defaults returns the embedded default configuration.
+func (cs *ConfigStoreImpl) Defaults() (*ports.Config, error) {
+	config, _, err := cs.parse([]byte("{}"))
+	return config, err
+}
+
+// Path returns the location of config.json.
+func (cs *ConfigStoreImpl) Path() string {
+	return cs.path
+}
+
+func (cs *ConfigStoreImpl) readFile() ([]byte, error) {
+	data, err := os.ReadFile(cs.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s is empty", cs.path)
+	}
+	return data, nil
+}
+
+// parse decodes data merged over the defaults and returns the keys in data
+// that do not match a setting. Syntax and type errors carry the line they
+// occur on.
+func (cs *ConfigStoreImpl) parse(data []byte) (*ports.Config, []string, error) {
+	// Decoding the file on its own first reports errors at their position
+	// in the file rather than in the merged document.
+	var own ports.Config
+	if err := json.Unmarshal(data, &own); err != nil {
+		return nil, nil, decodeError(cs.path, data, err)
+	}
+	if err := checkVersion(configFile, own.SchemaVersion, configSchemaVersion); err != nil {
+		return nil, nil, err
+	}
+
+	var doc, defaults map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", cs.path, err)
+	}
+	if err := json.Unmarshal(defaultConfig, &defaults); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse default config: %w", err)
+	}
+	unknown := unknownKeys(doc, reflect.TypeOf(own), "")
+	mergeDefaults(doc, defaults)
+
+	merged, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	config := &ports.Config{}
+	if err := json.Unmarshal(merged, config); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", cs.path, err)
+	}
+	config.SchemaVersion = own.SchemaVersion
+	return config, unknown, nil
+}
+
+// validate reports every invalid value in config, each with its line in
+// data when the setting appears there.
+func (cs *ConfigStoreImpl) validate(data []byte, config *ports.Config) error {
+	fieldErrs := config.Validate()
+	if len(fieldErrs) == 0 {
+		return nil
+	}
+	lines := keyLines(data)
+	errs := make([]error, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		if line := lineOf(lines, fe.Key); line > 0 {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", cs.path, line, fe))
+		} else {
+			errs = append(errs, fmt.Errorf("%s: %w", cs.path, fe))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// mergeDefaults fills in every key of defaults that doc lacks, descending
+// into objects present in both. Values set in doc, including lists, win.
+func mergeDefaults(doc, defaults map[string]any) {
+	for key, def := range defaults {
+		cur, ok := doc[key]
+		if !ok {
+			doc[key] = def
+			continue
+		}
+		curMap, curOK := cur.(map[string]any)
+		defMap, defOK := def.(map[string]any)
+		if curOK && defOK {
+			mergeDefaults(curMap, defMap)
+		}
+	}
+}
+
+// unknownKeys returns the dotted keys of doc that match no field of the
+// struct type t. Maps accept any key, so only struct settings are checked.
+func unknownKeys(doc map[string]any, t reflect.Type, prefix string) []string {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = f.Type
+		}
+	}
+
+	var unknown []string
+	for key, value := range doc {
+		ft, ok := fields[key]
+		if !ok {
+			unknown = append(unknown, prefix+key)
+			continue
+		}
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sub, ok := value.(map[string]any); ok && ft.Kind() == reflect.Struct {
+			unknown = append(unknown, unknownKeys(sub, ft, prefix+key+".")...)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
 }
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lineAt returns the 1-based line of the byte offset in data.
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return 1 + bytes.Count(data[:offset], []byte("\n"))
+}
+
+// decodeError describes a failure to decode the JSON file at path, giving
+// the line of syntax and type errors.
+func decodeError(path string, data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("%s:%d: invalid JSON: %w", path, lineAt(data, syntaxErr.Offset), err)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("%s:%d: %s: expected %s, got %s", path, lineAt(data, typeErr.Offset), typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+	return fmt.Errorf("failed to unmarshal JSON: %w", err)
+}
+
+// keyLines maps the dotted path of every key in a JSON document to the line
+// it is on. Array elements are addressed by index. Parsing stops quietly at
+// the first syntax error, keeping the keys found so far.
+func keyLines(data []byte) map[string]int {
+	lines := make(map[string]int)
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key := joinKey(path, keyTok.(string))
+				lines[key] = lineAt(data, dec.InputOffset())
+				if err := walk(key); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+			return err
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(joinKey(path, strconv.Itoa(i))); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+			return err
+		}
+		return nil
+	}
+	_ = walk("")
+	return lines
+}
+
+// lineOf returns the line of key in lines, falling back to its closest
+// parent that is there, or 0.
+func lineOf(lines map[string]int, key string) int {
+	for key != "" {
+		if line, ok := lines[key]; ok {
+			return line
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return 0
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -39,7 +39,7 @@ func (js *JSONStore) Read(filename string, target interface{}) error {
 	}
 
 	if err := json.Unmarshal(data, target); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+		return decodeError(path, data, err)
 	}
 
 	return nil
@@ -54,6 +54,54 @@ func (js *JSONStore) Write(filename string, data interface{}) error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return js.write(path, data)
+}
+
+// Update reads a JSON file into target, lets fn change it and writes it
+// back, holding the file's lock throughout so concurrent updates from other
+// goroutines or processes are not lost. exists tells fn whether the file
+// was there; target is left untouched when it was not. When fn returns an
+// error nothing is written.
+func (js *JSONStore) Update(filename string, target interface{}, fn func(exists bool) error) error {
+	path := filepath.Join(js.baseDir, filename)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	exists := true
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err) || err == nil && len(data) == 0:
+		exists = false
+	case err != nil:
+		return fmt.Errorf("failed to read file: %w", err)
+	default:
+		if err := json.Unmarshal(data, target); err != nil {
+			return decodeError(path, data, err)
+		}
+	}
+
+	if err := fn(exists); err != nil {
+		return err
+	}
+	return js.write(path, target)
+}
+
+// write replaces path with data through a uniquely named temp file, so
+// concurrent writers never share a temp file. The caller holds the lock.
+func (js *JSONStore) write(path string, data interface{}) error {
 	// Marshal to JSON
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
@@ -61,17 +109,23 @@ func (js *JSONStore) Write(filename string, data interface{}) error {
 	}
 
 	// Write to temp file
-	tmpPath := path + ".tmp"
-	if err := os.WriteFile(tmpPath, jsonData, 0644); err != nil {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := file.Name()
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to write temp file: %w", err)
 	}
-
-	// Sync to ensure data is written
-	file, err := os.OpenFile(tmpPath, os.O_RDWR, 0644)
-	if err != nil {
+	if _, err := file.Write(jsonData); err != nil {
+		file.Close()
 		os.Remove(tmpPath)
-		return fmt.Errorf("failed to open temp file for sync: %w", err)
+		return fmt.Errorf("failed to write temp file: %w", err)
 	}
+
+	// Sync to ensure data is written
 	if err := file.Sync(); err != nil {
 		file.Close()
 		os.Remove(tmpPath)
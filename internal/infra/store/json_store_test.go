@@ -0,0 +1,152 @@
+//go:build unix
+
+package store
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// storeHelperEnv names the directory a re-executed test binary increments
+// the counter in, see TestJSONStoreUpdateHelperProcess.
+const storeHelperEnv = "KODKAFA_STORE_HELPER_DIR"
+
+const (
+	counterFile      = "counter.json"
+	updatesPerWorker = 50
+)
+
+type counter struct {
+	Count int `json:"count"`
+}
+
+func increment(js *JSONStore, times int) error {
+	for range times {
+		var c counter
+		err := js.Update(counterFile, &c, func(bool) error {
+			c.Count++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestJSONStoreUpdateHelperProcess is the worker run in other processes by
+// TestJSONStoreUpdateConcurrent.
+func TestJSONStoreUpdateHelperProcess(t *testing.T) {
+	dir := os.Getenv(storeHelperEnv)
+	if dir == "" {
+		t.Skip("helper process for TestJSONStoreUpdateConcurrent")
+	}
+	if err := increment(NewJSONStore(dir), updatesPerWorker); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONStoreUpdateConcurrent(t *testing.T) {
+	const goroutines, processes = 8, 4
+	dir := t.TempDir()
+	js := NewJSONStore(dir)
+
+	var cmds []*exec.Cmd
+	for range processes {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestJSONStoreUpdateHelperProcess$")
+		cmd.Env = append(os.Environ(), storeHelperEnv+"="+dir)
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- increment(js, updatesPerWorker)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("helper process: %v", err)
+		}
+	}
+
+	var c counter
+	if err := js.Read(counterFile, &c); err != nil {
+		t.Fatal(err)
+	}
+	if want := (goroutines + processes) * updatesPerWorker; c.Count != want {
+		t.Errorf("count = %d after %d increments; updates were lost", c.Count, want)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) > 0 {
+		t.Errorf("temp files left behind: %v", tmp)
+	}
+}
+
+func TestJSONStoreUpdate(t *testing.T) {
+	errAbort := errors.New("abort")
+	tests := []struct {
+		name       string
+		existing   *counter
+		fnErr      error
+		wantExists bool
+		want       *counter // file contents afterwards, nil for no file
+	}{
+		{name: "new file", wantExists: false, want: &counter{Count: 1}},
+		{name: "existing file", existing: &counter{Count: 41}, wantExists: true, want: &counter{Count: 42}},
+		{name: "fn fails on a new file", fnErr: errAbort},
+		{name: "fn fails on an existing file", existing: &counter{Count: 7}, fnErr: errAbort, wantExists: true, want: &counter{Count: 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			js := NewJSONStore(t.TempDir())
+			if tt.existing != nil {
+				if err := js.Write(counterFile, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var c counter
+			err := js.Update(counterFile, &c, func(exists bool) error {
+				if exists != tt.wantExists {
+					t.Errorf("exists = %v, want %v", exists, tt.wantExists)
+				}
+				c.Count++
+				return tt.fnErr
+			})
+			if !errors.Is(err, tt.fnErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.fnErr)
+			}
+
+			if tt.want == nil {
+				if js.Exists(counterFile) {
+					t.Error("Update() wrote the file although fn failed")
+				}
+				return
+			}
+			var got counter
+			if err := js.Read(counterFile, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != *tt.want {
+				t.Errorf("file holds %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package store
+
+// lockFile is a no-op where flock is not available; writes stay atomic
+// but concurrent read-modify-write cycles are not serialized.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// lockfileRecord is the on-disk form of entities.Lockfile.
+type lockfileRecord struct {
+	Version int                  `json:"version"`
+	Plugins []lockedPluginRecord `json:"plugins"`
+}
+
+type lockedPluginRecord struct {
+	Name     string `json:"name"`
+	Origin   string `json:"origin"`
+	Ref      string `json:"ref,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	Subdir   string `json:"subdir,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// LockfileStoreImpl implements ports.LockfileStore using JSON files.
+type LockfileStoreImpl struct{}
+
+// NewLockfileStore creates a new LockfileStore implementation.
+func NewLockfileStore() ports.LockfileStore {
+	return &LockfileStoreImpl{}
+}
+
+// Read loads the lockfile at path.
+func (ls *LockfileStoreImpl) Read(path string) (*entities.Lockfile, error) {
+	var record lockfileRecord
+	if err := NewJSONStore(filepath.Dir(path)).Read(filepath.Base(path), &record); err != nil {
+		return nil, fmt.Errorf("failed to read lockfile %s: %w", path, err)
+	}
+	if record.Version > entities.LockfileVersion {
+		return nil, fmt.Errorf("lockfile %s has version %d, this kodkafa reads up to %d", path, record.Version, entities.LockfileVersion)
+	}
+
+	lock := &entities.Lockfile{Version: record.Version}
+	for _, p := range record.Plugins {
+		if p.Name == "" || p.Origin == "" {
+			return nil, fmt.Errorf("lockfile %s: every plugin needs a name and an origin", path)
+		}
+		lock.Plugins = append(lock.Plugins, entities.LockedPlugin(p))
+	}
+	return lock, nil
+}
+
+// Write stores the lockfile at path.
+func (ls *LockfileStoreImpl) Write(path string, lock *entities.Lockfile) error {
+	record := lockfileRecord{Version: lock.Version, Plugins: []lockedPluginRecord{}}
+	for _, p := range lock.Plugins {
+		record.Plugins = append(record.Plugins, lockedPluginRecord(p))
+	}
+	return NewJSONStore(filepath.Dir(path)).Write(filepath.Base(path), record)
+}
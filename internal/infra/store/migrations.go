@@ -0,0 +1,187 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"kodkafa/internal/domain/ports"
+)
+
+// Document kinds, each with its own schema_version.
+const (
+	docConfig = "config"
+	docState  = "state"
+	docUsage  = "usage"
+)
+
+// migration upgrades one kind of document to version by rewriting its
+// decoded JSON in place.
+type migration struct {
+	kind    string
+	version int
+	apply   func(doc map[string]any) error
+}
+
+// migrations lists every schema change, oldest first. A document is
+// upgraded by applying each step of its kind above its schema_version;
+// documents without one are version 0.
+var migrations = []migration{
+	// v1: explicit snake_case keys instead of Go field names. State no longer
+	// keeps MaxHistorySize, which used to override history_size.
+	{kind: docConfig, version: 1, apply: func(map[string]any) error { return nil }},
+	{kind: docState, version: 1, apply: func(doc map[string]any) error {
+		delete(doc, "MaxHistorySize")
+		snakeKeys(doc)
+		return nil
+	}},
+	{kind: docUsage, version: 1, apply: func(doc map[string]any) error {
+		snakeKeys(doc)
+		return nil
+	}},
+}
+
+// currentVersions is the version the stores write for each kind.
+var currentVersions = map[string]int{
+	docConfig: configSchemaVersion,
+	docState:  stateSchemaVersion,
+	docUsage:  usageSchemaVersion,
+}
+
+// errNoChange ends an Update without writing.
+var errNoChange = errors.New("no change")
+
+// MigratorImpl implements ports.Migrator for the JSON documents in a
+// profile directory, including those of its projects.
+type MigratorImpl struct {
+	baseDir string
+}
+
+// NewMigrator creates a new Migrator for the documents under baseDir.
+func NewMigrator(baseDir string) ports.Migrator {
+	return &MigratorImpl{baseDir: baseDir}
+}
+
+// Migrate upgrades config.json, usage.json and state/*.json, for the profile
+// and every project in it. Originals go to backups/<time>/ under baseDir.
+func (m *MigratorImpl) Migrate() ([]string, string, error) {
+	backupDir := filepath.Join(m.baseDir, "backups", time.Now().Format("20060102-150405"))
+
+	docs := map[string]string{"config.json": docConfig}
+	dirs := []string{""}
+	if projects, err := os.ReadDir(filepath.Join(m.baseDir, "projects")); err == nil {
+		for _, p := range projects {
+			if p.IsDir() {
+				dirs = append(dirs, filepath.Join("projects", p.Name()))
+			}
+		}
+	}
+	for _, dir := range dirs {
+		docs[filepath.Join(dir, "usage.json")] = docUsage
+		states, _ := os.ReadDir(filepath.Join(m.baseDir, dir, "state"))
+		for _, s := range states {
+			if !s.IsDir() && strings.HasSuffix(s.Name(), ".json") {
+				docs[filepath.Join(dir, "state", s.Name())] = docState
+			}
+		}
+	}
+
+	var migrated []string
+	for rel, kind := range docs {
+		changed, err := m.migrate(rel, kind, backupDir)
+		if err != nil {
+			return migrated, backupDir, err
+		}
+		if changed {
+			migrated = append(migrated, rel)
+		}
+	}
+	return migrated, backupDir, nil
+}
+
+// migrate upgrades one document under its lock and reports whether it
+// changed.
+func (m *MigratorImpl) migrate(rel, kind, backupDir string) (bool, error) {
+	path := filepath.Join(m.baseDir, rel)
+	if _, err := os.Stat(path); err != nil {
+		return false, nil
+	}
+
+	var doc map[string]any
+	err := NewJSONStore(m.baseDir).Update(rel, &doc, func(exists bool) error {
+		if !exists || doc == nil {
+			return errNoChange
+		}
+		version := 0
+		if v, ok := doc["schema_version"].(float64); ok {
+			version = int(v)
+		}
+		if err := checkVersion(rel, version, currentVersions[kind]); err != nil {
+			return err
+		}
+
+		steps := 0
+		for _, mg := range migrations {
+			if mg.kind != kind || mg.version <= version {
+				continue
+			}
+			if err := mg.apply(doc); err != nil {
+				return fmt.Errorf("failed to migrate %s to version %d: %w", rel, mg.version, err)
+			}
+			doc["schema_version"] = mg.version
+			steps++
+		}
+		if steps == 0 {
+			return errNoChange
+		}
+		return backup(path, filepath.Join(backupDir, rel))
+	})
+	if errors.Is(err, errNoChange) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// backup copies the file at path to dst.
+func backup(path, dst string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return nil
+}
+
+var wordBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// snakeKeys renames Go field name keys, at any depth, to snake_case.
+func snakeKeys(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			value := v[key]
+			snakeKeys(value)
+			if snake := strings.ToLower(wordBoundary.ReplaceAllString(key, "${1}_${2}")); snake != key {
+				delete(v, key)
+				v[snake] = value
+			}
+		}
+	case []any:
+		for _, item := range v {
+			snakeKeys(item)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+const (
+	profilesDir = "profiles"
+	profileFile = "profile.json"
+)
+
+// profileRecord is the on-disk form of the active profile selection.
+type profileRecord struct {
+	SchemaVersion int    `json:"schema_version"`
+	Active        string `json:"active"`
+}
+
+// ProfileStoreImpl implements ports.ProfileStore. The default profile is the
+// home directory itself, so existing installs keep working; other profiles
+// live under profiles/<name>.
+type ProfileStoreImpl struct {
+	store *JSONStore
+	home  string
+}
+
+// NewProfileStore creates a new ProfileStore implementation.
+func NewProfileStore(home string) ports.ProfileStore {
+	return &ProfileStoreImpl{
+		store: NewJSONStore(home),
+		home:  home,
+	}
+}
+
+// List returns the names of all profiles, the default one first.
+func (ps *ProfileStoreImpl) List() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(ps.home, profilesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && entities.IsValidProfileName(entry.Name()) && entry.Name() != entities.DefaultProfile {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return append([]string{entities.DefaultProfile}, names...), nil
+}
+
+// Create adds an empty profile directory.
+func (ps *ProfileStoreImpl) Create(name string) error {
+	if !entities.IsValidProfileName(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '-' and '_'", name)
+	}
+	if name == entities.DefaultProfile {
+		return fmt.Errorf("profile %s already exists", name)
+	}
+	if err := os.MkdirAll(filepath.Join(ps.home, profilesDir), 0755); err != nil {
+		return fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	if err := os.Mkdir(filepath.Join(ps.home, profilesDir, name), 0755); err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("profile %s already exists", name)
+		}
+		return fmt.Errorf("failed to create profile: %w", err)
+	}
+	return nil
+}
+
+// Dir returns the base directory of an existing profile.
+func (ps *ProfileStoreImpl) Dir(name string) (string, error) {
+	if name == "" || name == entities.DefaultProfile {
+		return ps.home, nil
+	}
+	if !entities.IsValidProfileName(name) {
+		return "", fmt.Errorf("invalid profile name %q", name)
+	}
+	dir := filepath.Join(ps.home, profilesDir, name)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("profile %s does not exist (create it with 'kod profile create %s')", name, name)
+	}
+	return dir, nil
+}
+
+// Active returns the selected profile, or the default one when none was
+// selected or the selected one was removed.
+func (ps *ProfileStoreImpl) Active() (string, error) {
+	var record profileRecord
+	if err := ps.store.Read(profileFile, &record); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return entities.DefaultProfile, nil
+		}
+		return "", fmt.Errorf("failed to read active profile: %w", err)
+	}
+	if err := checkVersion(profileFile, record.SchemaVersion, profileSchemaVersion); err != nil {
+		return "", err
+	}
+	if _, err := ps.Dir(record.Active); err != nil || record.Active == "" {
+		return entities.DefaultProfile, nil
+	}
+	return record.Active, nil
+}
+
+// SetActive makes an existing profile the active one.
+func (ps *ProfileStoreImpl) SetActive(name string) error {
+	if _, err := ps.Dir(name); err != nil {
+		return err
+	}
+	return ps.store.Write(profileFile, profileRecord{SchemaVersion: profileSchemaVersion, Active: name})
+}
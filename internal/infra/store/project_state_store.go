@@ -0,0 +1,69 @@
+package store
+
+import (
+	"errors"
+	"os"
+
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// ProjectStateStore keeps the state of a project's plugins in the project's
+// own directory, so run history does not mix across repositories.
+type ProjectStateStore struct {
+	global    ports.StateStore
+	project   ports.StateStore
+	isProject func(pluginName string) (bool, error)
+}
+
+// NewProjectStateStore creates a state store that routes project plugins,
+// as reported by isProject, to the project store.
+func NewProjectStateStore(global, project ports.StateStore, isProject func(pluginName string) (bool, error)) ports.StateStore {
+	return &ProjectStateStore{global: global, project: project, isProject: isProject}
+}
+
+func (ps *ProjectStateStore) scope(pluginName string) ports.StateStore {
+	if ok, err := ps.isProject(pluginName); err == nil && ok {
+		return ps.project
+	}
+	return ps.global
+}
+
+// Read reads the state for a plugin, creating it if it doesn't exist.
+func (ps *ProjectStateStore) Read(pluginName string) (*entities.PluginState, error) {
+	return ps.scope(pluginName).Read(pluginName)
+}
+
+// Write persists the state for a plugin.
+func (ps *ProjectStateStore) Write(state *entities.PluginState) error {
+	return ps.scope(state.PluginName).Write(state)
+}
+
+// Update applies fn to the state for a plugin in the store it belongs to.
+func (ps *ProjectStateStore) Update(pluginName string, fn func(*entities.PluginState) error) error {
+	return ps.scope(pluginName).Update(pluginName, fn)
+}
+
+// RunHistory returns every recorded run of a plugin from the store it
+// belongs to, or the runs Read keeps when that store has no full history.
+func (ps *ProjectStateStore) RunHistory(pluginName string) ([]entities.RunRecord, error) {
+	store := ps.scope(pluginName)
+	if h, ok := store.(ports.RunHistoryReader); ok {
+		return h.RunHistory(pluginName)
+	}
+	state, err := store.Read(pluginName)
+	if err != nil {
+		return nil, err
+	}
+	return state.History, nil
+}
+
+// Delete removes the state for a plugin. The plugin itself is usually gone
+// by then, so project state is tried first.
+func (ps *ProjectStateStore) Delete(pluginName string) error {
+	err := ps.project.Delete(pluginName)
+	if errors.Is(err, os.ErrNotExist) {
+		return ps.global.Delete(pluginName)
+	}
+	return err
+}
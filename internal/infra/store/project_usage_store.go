@@ -0,0 +1,146 @@
+package store
+
+import (
+	"slices"
+	"sort"
+
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+)
+
+// ProjectUsageStore keeps the usage of a project's plugins in the project's
+// own directory and the usage of every other plugin in the global store,
+// presenting both as one set of statistics. Pins are kept globally, so they
+// stay in place wherever kod is started.
+type ProjectUsageStore struct {
+	global    ports.UsageStore
+	project   ports.UsageStore
+	isProject func(pluginName string) (bool, error)
+}
+
+// NewProjectUsageStore creates a usage store that routes the entries of
+// project plugins, as reported by isProject, to the project store.
+func NewProjectUsageStore(global, project ports.UsageStore, isProject func(pluginName string) (bool, error)) ports.UsageStore {
+	return &ProjectUsageStore{global: global, project: project, isProject: isProject}
+}
+
+func (ps *ProjectUsageStore) inProject(pluginName string) bool {
+	ok, err := ps.isProject(pluginName)
+	return err == nil && ok
+}
+
+// Read returns the global and project statistics merged. Global entries of
+// plugins shadowed by a project plugin are left out.
+func (ps *ProjectUsageStore) Read() (*entities.UsageStats, error) {
+	global, err := ps.global.Read()
+	if err != nil {
+		return nil, err
+	}
+	project, err := ps.project.Read()
+	if err != nil {
+		return nil, err
+	}
+	return ps.merge(global, project), nil
+}
+
+// Write persists the statistics, each entry in the store it belongs to.
+func (ps *ProjectUsageStore) Write(stats *entities.UsageStats) error {
+	return ps.Update(func(merged *entities.UsageStats) error {
+		*merged = *stats
+		return nil
+	})
+}
+
+// Update applies fn to the merged statistics and splits the result back,
+// holding both stores' locks, always global first.
+func (ps *ProjectUsageStore) Update(fn func(*entities.UsageStats) error) error {
+	return ps.global.Update(func(global *entities.UsageStats) error {
+		return ps.project.Update(func(project *entities.UsageStats) error {
+			merged := ps.merge(global, project)
+			if err := fn(merged); err != nil {
+				return err
+			}
+			ps.split(merged, global, project)
+			return nil
+		})
+	})
+}
+
+func (ps *ProjectUsageStore) merge(global, project *entities.UsageStats) *entities.UsageStats {
+	merged := *global
+	merged.RecentlyUsed = byRecency(append(ps.visible(global.RecentlyUsed), project.RecentlyUsed...))
+	merged.MostUsed = byRunCount(append(ps.visible(global.MostUsed), project.MostUsed...))
+	// Pins used to be kept per store; adopt any left in the project store
+	merged.Pinned = slices.Clone(global.Pinned)
+	for _, e := range project.Pinned {
+		if !merged.IsPinned(e.PluginName) {
+			merged.Pinned = append(merged.Pinned, e)
+		}
+	}
+	return &merged
+}
+
+func (ps *ProjectUsageStore) split(merged, global, project *entities.UsageStats) {
+	project.RecentlyUsed, global.RecentlyUsed = ps.route(merged.RecentlyUsed, global.RecentlyUsed, project.RecentlyUsed, func(e, last entities.UsageEntry) bool {
+		return !e.Timestamp.After(last.Timestamp)
+	})
+	project.MostUsed, global.MostUsed = ps.route(merged.MostUsed, global.MostUsed, project.MostUsed, func(e, last entities.UsageEntry) bool {
+		return e.RunCount <= last.RunCount
+	})
+	project.Pinned, global.Pinned = []entities.UsageEntry{}, merged.Pinned
+	project.RecentlyUsed, global.RecentlyUsed = byRecency(project.RecentlyUsed), byRecency(global.RecentlyUsed)
+	project.MostUsed, global.MostUsed = byRunCount(project.MostUsed), byRunCount(global.MostUsed)
+}
+
+// visible drops the global entries of plugins shadowed by project plugins.
+func (ps *ProjectUsageStore) visible(entries []entities.UsageEntry) []entities.UsageEntry {
+	var out []entities.UsageEntry
+	for _, e := range entries {
+		if !ps.inProject(e.PluginName) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// route splits merged entries into project and global ones. Entries
+// missing from merged are kept when they were shadowed, so never shown, or
+// when trimmed reports that they only fell off the end of a bounded list
+// behind last, making room for entries of the other store.
+func (ps *ProjectUsageStore) route(merged, prevGlobal, prevProject []entities.UsageEntry, trimmed func(e, last entities.UsageEntry) bool) (project, global []entities.UsageEntry) {
+	project, global = []entities.UsageEntry{}, []entities.UsageEntry{}
+	listed := make(map[string]bool, len(merged))
+	for _, e := range merged {
+		listed[e.PluginName] = true
+		if ps.inProject(e.PluginName) {
+			project = append(project, e)
+		} else {
+			global = append(global, e)
+		}
+	}
+
+	dropped := func(e entities.UsageEntry) bool {
+		return !listed[e.PluginName] && len(merged) > 0 && trimmed(e, merged[len(merged)-1])
+	}
+	for _, e := range prevGlobal {
+		if ps.inProject(e.PluginName) || dropped(e) {
+			global = append(global, e)
+		}
+	}
+	for _, e := range prevProject {
+		if dropped(e) {
+			project = append(project, e)
+		}
+	}
+	return project, global
+}
+
+func byRecency(entries []entities.UsageEntry) []entities.UsageEntry {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.After(entries[j].Timestamp) })
+	return entries
+}
+
+func byRunCount(entries []entities.UsageEntry) []entities.UsageEntry {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].RunCount > entries[j].RunCount })
+	return entries
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"kodkafa/internal/domain/entities"
+)
+
+// Current schema_version of each document kind the stores write. Older
+// documents are upgraded at startup by the migrations in migrations.go.
+const (
+	configSchemaVersion  = 1
+	stateSchemaVersion   = 1
+	usageSchemaVersion   = 1
+	profileSchemaVersion = 1
+)
+
+// checkVersion refuses documents written by a newer kod, which may hold
+// data this version would drop when writing them back.
+func checkVersion(name string, version, current int) error {
+	if version > current {
+		return fmt.Errorf("%s has schema version %d, newer than this kod supports (%d); please upgrade kod", name, version, current)
+	}
+	return nil
+}
+
+// stateRecord is the on-disk form of entities.PluginState. MaxHistorySize is
+// not stored: history is bounded by the history_size config setting.
+type stateRecord struct {
+	SchemaVersion  int            `json:"schema_version"`
+	PluginName     string         `json:"plugin_name"`
+	AddedAt        time.Time      `json:"added_at"`
+	LastExecutedAt time.Time      `json:"last_executed_at"`
+	RunCount       int            `json:"run_count"`
+	History        []runRecord    `json:"history"`
+	Consent        *consentRecord `json:"consent,omitempty"`
+	Tags           []string       `json:"tags,omitempty"`
+}
+
+// runRecord is the on-disk form of entities.RunRecord.
+type runRecord struct {
+	Timestamp time.Time          `json:"timestamp"`
+	Args      string             `json:"args"`
+	ExitCode  int                `json:"exit_code"`
+	Duration  time.Duration      `json:"duration"` // nanoseconds
+	Status    entities.RunStatus `json:"status"`
+	Reason    string             `json:"reason,omitempty"`
+}
+
+// consentRecord is the on-disk form of entities.Consent.
+type consentRecord struct {
+	Permissions *permissionsRecord `json:"permissions"` // null: unrestricted
+	GrantedAt   time.Time          `json:"granted_at"`
+}
+
+// permissionsRecord is the on-disk form of entities.Permissions.
+type permissionsRecord struct {
+	Sandbox      bool     `json:"sandbox"`
+	Network      bool     `json:"network"`
+	Filesystem   []string `json:"filesystem,omitempty"`
+	Env          []string `json:"env,omitempty"`
+	Secrets      []string `json:"secrets,omitempty"`
+	Subprocesses bool     `json:"subprocesses"`
+}
+
+func toStateRecord(state *entities.PluginState) stateRecord {
+	record := stateRecord{
+		SchemaVersion:  stateSchemaVersion,
+		PluginName:     state.PluginName,
+		AddedAt:        state.AddedAt,
+		LastExecutedAt: state.LastExecutedAt,
+		RunCount:       state.RunCount,
+		History:        make([]runRecord, len(state.History)),
+		Consent:        toConsentRecord(state.Consent),
+		Tags:           state.Tags,
+	}
+	for i, r := range state.History {
+		record.History[i] = runRecord(r)
+	}
+	return record
+}
+
+func (r stateRecord) toEntity() *entities.PluginState {
+	state := entities.NewPluginState(r.PluginName)
+	state.AddedAt = r.AddedAt
+	state.LastExecutedAt = r.LastExecutedAt
+	state.RunCount = r.RunCount
+	for _, h := range r.History {
+		state.History = append(state.History, entities.RunRecord(h))
+	}
+	state.Consent = r.Consent.toEntity()
+	state.Tags = r.Tags
+	return state
+}
+
+func toConsentRecord(c *entities.Consent) *consentRecord {
+	if c == nil {
+		return nil
+	}
+	record := &consentRecord{GrantedAt: c.GrantedAt}
+	if c.Permissions != nil {
+		p := permissionsRecord(*c.Permissions)
+		record.Permissions = &p
+	}
+	return record
+}
+
+func (r *consentRecord) toEntity() *entities.Consent {
+	if r == nil {
+		return nil
+	}
+	c := &entities.Consent{GrantedAt: r.GrantedAt}
+	if r.Permissions != nil {
+		p := entities.Permissions(*r.Permissions)
+		c.Permissions = &p
+	}
+	return c
+}
+
+// usageRecord is the on-disk form of entities.UsageStats.
+type usageRecord struct {
+	SchemaVersion int                `json:"schema_version"`
+	RecentlyUsed  []usageEntryRecord `json:"recently_used"`
+	MostUsed      []usageEntryRecord `json:"most_used"`
+	Pinned        []usageEntryRecord `json:"pinned,omitempty"`
+	MaxRecent     int                `json:"max_recent"`
+	MaxMostUsed   int                `json:"max_most_used"`
+}
+
+// usageEntryRecord is the on-disk form of entities.UsageEntry.
+type usageEntryRecord struct {
+	PluginName string    `json:"plugin_name"`
+	Timestamp  time.Time `json:"timestamp"`
+	RunCount   int       `json:"run_count"`
+}
+
+func toUsageRecord(stats *entities.UsageStats) usageRecord {
+	record := usageRecord{
+		SchemaVersion: usageSchemaVersion,
+		RecentlyUsed:  make([]usageEntryRecord, len(stats.RecentlyUsed)),
+		MostUsed:      make([]usageEntryRecord, len(stats.MostUsed)),
+		MaxRecent:     stats.MaxRecent,
+		MaxMostUsed:   stats.MaxMostUsed,
+	}
+	for i, e := range stats.RecentlyUsed {
+		record.RecentlyUsed[i] = usageEntryRecord(e)
+	}
+	for i, e := range stats.MostUsed {
+		record.MostUsed[i] = usageEntryRecord(e)
+	}
+	for _, e := range stats.Pinned {
+		record.Pinned = append(record.Pinned, usageEntryRecord(e))
+	}
+	return record
+}
+
+func (r usageRecord) toEntity() *entities.UsageStats {
+	stats := entities.NewUsageStats()
+	for _, e := range r.RecentlyUsed {
+		stats.RecentlyUsed = append(stats.RecentlyUsed, entities.UsageEntry(e))
+	}
+	for _, e := range r.MostUsed {
+		stats.MostUsed = append(stats.MostUsed, entities.UsageEntry(e))
+	}
+	for _, e := range r.Pinned {
+		stats.Pinned = append(stats.Pinned, entities.UsageEntry(e))
+	}
+	if r.MaxRecent > 0 {
+		stats.MaxRecent = r.MaxRecent
+	}
+	if r.MaxMostUsed > 0 {
+		stats.MaxMostUsed = r.MaxMostUsed
+	}
+	return stats
+}
@@ -0,0 +1,527 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"kodkafa/internal/domain/entities"
+	"kodkafa/internal/domain/ports"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteFile is the database used when storage is "sqlite".
+const sqliteFile = "kodkafa.db"
+
+// sqliteSchemaVersion is the schema_version recorded in the meta table.
+const sqliteSchemaVersion = 2
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS plugin_state (
+	plugin_name      TEXT PRIMARY KEY,
+	added_at         INTEGER NOT NULL,
+	last_executed_at INTEGER NOT NULL,
+	run_count        INTEGER NOT NULL,
+	consent          TEXT,
+	tags             TEXT
+);
+CREATE TABLE IF NOT EXISTS run_history (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	plugin_name TEXT NOT NULL,
+	started_at  INTEGER NOT NULL,
+	args        TEXT NOT NULL,
+	exit_code   INTEGER NOT NULL,
+	duration    INTEGER NOT NULL,
+	status      TEXT NOT NULL,
+	reason      TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS run_history_run ON run_history (plugin_name, started_at);
+CREATE INDEX IF NOT EXISTS run_history_started ON run_history (started_at);
+CREATE INDEX IF NOT EXISTS run_history_status ON run_history (status);
+CREATE TABLE IF NOT EXISTS usage_entries (
+	list        TEXT NOT NULL,
+	position    INTEGER NOT NULL,
+	plugin_name TEXT NOT NULL,
+	timestamp   INTEGER NOT NULL,
+	run_count   INTEGER NOT NULL,
+	PRIMARY KEY (list, position)
+);
+`
+
+// Usage lists as stored in usage_entries.list.
+const (
+	usageRecent = "recent"
+	usageMost   = "most"
+	usagePinned = "pinned"
+)
+
+// SQLiteDB is an open kodkafa.db shared by the SQLite stores.
+type SQLiteDB struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens or creates kodkafa.db in baseDir. The first time, the
+// state and usage kept in JSON files are imported; the files are left in
+// place as a backup and no longer updated.
+func OpenSQLite(baseDir string) (*SQLiteDB, error) {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	// Immediate transactions take the write lock up front, so concurrent
+	// Updates from other processes wait instead of failing on upgrade.
+	dsn := "file:" + filepath.ToSlash(filepath.Join(baseDir, sqliteFile)) + "?" + url.Values{
+		"_pragma": {"busy_timeout(10000)", "journal_mode(WAL)"},
+		"_txlock": {"immediate"},
+	}.Encode()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create database schema: %w", err)
+	}
+	var version int
+	err = db.QueryRow(`SELECT CAST(value AS INTEGER) FROM meta WHERE key = 'schema_version'`).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = db.Exec(`INSERT INTO meta (key, value) VALUES ('schema_version', ?)`, sqliteSchemaVersion)
+		version = sqliteSchemaVersion
+	}
+	if err == nil {
+		err = checkVersion(sqliteFile, version, sqliteSchemaVersion)
+	}
+	if err == nil && version < sqliteSchemaVersion {
+		err = upgradeSQLite(db, version)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &SQLiteDB{db: db}
+	if err := s.importJSON(baseDir); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to import JSON state: %w", err)
+	}
+	return s, nil
+}
+
+// sqliteUpgrades[i] brings a database from schema version i+1 to i+2.
+var sqliteUpgrades = []string{
+	// 2: local tags
+	`ALTER TABLE plugin_state ADD COLUMN tags TEXT`,
+}
+
+// upgradeSQLite applies the schema changes made since version.
+func upgradeSQLite(db *sql.DB, version int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range sqliteUpgrades[version-1:] {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to upgrade %s: %w", sqliteFile, err)
+		}
+	}
+	if _, err := tx.Exec(`UPDATE meta SET value = ? WHERE key = 'schema_version'`, sqliteSchemaVersion); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close closes the database.
+func (s *SQLiteDB) Close() error {
+	return s.db.Close()
+}
+
+// importJSON copies state/*.json and usage.json into the database once.
+func (s *SQLiteDB) importJSON(baseDir string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var done string
+	err = tx.QueryRow(`SELECT value FROM meta WHERE key = 'json_imported'`).Scan(&done)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	js := NewJSONStore(baseDir)
+	entries, err := os.ReadDir(filepath.Join(baseDir, "state"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		var record stateRecord
+		if err := js.Read("state/"+entry.Name(), &record); err != nil {
+			continue // unreadable files were unusable before, too
+		}
+		state := record.toEntity()
+		state.PluginName = name
+		if err := writeState(tx, state); err != nil {
+			return err
+		}
+	}
+
+	var usage usageRecord
+	if err := js.Read("usage.json", &usage); err == nil {
+		if err := writeUsage(tx, usage.toEntity()); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO meta (key, value) VALUES ('json_imported', ?)`, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx.
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// unixNano stores a time as nanoseconds, 0 for the zero time.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// SQLiteStateStore implements ports.StateStore on kodkafa.db. Run history is
+// never trimmed; Read returns the latest run per distinct args, up to
+// historySize, as the JSON store does, and RunHistory returns all of it.
+type SQLiteStateStore struct {
+	db          *SQLiteDB
+	historySize int
+}
+
+// NewSQLiteStateStore creates a new StateStore backed by SQLite that reads
+// up to historySize runs per plugin (history_size in config).
+func NewSQLiteStateStore(db *SQLiteDB, historySize int) ports.StateStore {
+	if historySize <= 0 {
+		historySize = entities.DefaultHistorySize
+	}
+	return &SQLiteStateStore{db: db, historySize: historySize}
+}
+
+// Read reads the state for a plugin, creating it if it doesn't exist.
+func (ss *SQLiteStateStore) Read(pluginName string) (*entities.PluginState, error) {
+	state, err := readState(ss.db.db, pluginName, ss.historySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if state == nil {
+		state = entities.NewPluginState(pluginName)
+		if err := ss.Write(state); err != nil {
+			return nil, fmt.Errorf("failed to create initial state: %w", err)
+		}
+	}
+	return state, nil
+}
+
+// Write persists the state for a plugin.
+func (ss *SQLiteStateStore) Write(state *entities.PluginState) error {
+	tx, err := ss.db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := writeState(tx, state); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update applies fn to the plugin's state inside one write transaction.
+func (ss *SQLiteStateStore) Update(pluginName string, fn func(*entities.PluginState) error) error {
+	tx, err := ss.db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := readState(tx, pluginName, ss.historySize)
+	if err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+	if state == nil {
+		state = entities.NewPluginState(pluginName)
+	}
+	if err := fn(state); err != nil {
+		return err
+	}
+	if err := writeState(tx, state); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RunHistory returns every recorded run of a plugin, oldest first.
+func (ss *SQLiteStateStore) RunHistory(pluginName string) ([]entities.RunRecord, error) {
+	rows, err := ss.db.db.Query(`SELECT started_at, args, exit_code, duration, status, reason FROM run_history
+		WHERE plugin_name = ? ORDER BY started_at, id`, pluginName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	runs, err := scanRuns(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	return runs, nil
+}
+
+// Delete removes the state and run history of a plugin.
+func (ss *SQLiteStateStore) Delete(pluginName string) error {
+	tx, err := ss.db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`DELETE FROM plugin_state WHERE plugin_name = ?`, pluginName)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM run_history WHERE plugin_name = ?`, pluginName); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("state of %s: %w", pluginName, os.ErrNotExist)
+	}
+	return nil
+}
+
+// readState loads a plugin's state with its latest historySize runs, or nil
+// when it has none.
+func readState(q queryer, pluginName string, historySize int) (*entities.PluginState, error) {
+	state := entities.NewPluginState(pluginName)
+	var addedAt, lastExecutedAt int64
+	var consent, tags sql.NullString
+	err := q.QueryRow(`SELECT added_at, last_executed_at, run_count, consent, tags
+		FROM plugin_state WHERE plugin_name = ?`, pluginName).
+		Scan(&addedAt, &lastExecutedAt, &state.RunCount, &consent, &tags)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.AddedAt = fromUnixNano(addedAt)
+	state.LastExecutedAt = fromUnixNano(lastExecutedAt)
+	if consent.Valid {
+		var record consentRecord
+		if err := json.Unmarshal([]byte(consent.String), &record); err != nil {
+			return nil, fmt.Errorf("invalid consent: %w", err)
+		}
+		state.Consent = record.toEntity()
+	}
+	if tags.Valid {
+		if err := json.Unmarshal([]byte(tags.String), &state.Tags); err != nil {
+			return nil, fmt.Errorf("invalid tags: %w", err)
+		}
+	}
+
+	rows, err := q.Query(`SELECT started_at, args, exit_code, duration, status, reason FROM run_history
+		WHERE id IN (SELECT MAX(id) FROM run_history WHERE plugin_name = ? GROUP BY args)
+		ORDER BY started_at DESC LIMIT ?`, pluginName, historySize)
+	if err != nil {
+		return nil, err
+	}
+	if state.History, err = scanRuns(rows); err != nil {
+		return nil, err
+	}
+	// History is kept oldest first
+	slices.Reverse(state.History)
+	return state, nil
+}
+
+// scanRuns reads run_history rows selected as started_at, args, exit_code,
+// duration, status, reason and closes them.
+func scanRuns(rows *sql.Rows) ([]entities.RunRecord, error) {
+	defer rows.Close()
+	var runs []entities.RunRecord
+	for rows.Next() {
+		var r entities.RunRecord
+		var startedAt, duration int64
+		var status string
+		if err := rows.Scan(&startedAt, &r.Args, &r.ExitCode, &duration, &status, &r.Reason); err != nil {
+			return nil, err
+		}
+		r.Timestamp = fromUnixNano(startedAt)
+		r.Duration = time.Duration(duration)
+		r.Status = entities.RunStatus(status)
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// writeState upserts the state row and every record in its history. Runs
+// no longer in the in-memory history stay in the table.
+func writeState(q queryer, state *entities.PluginState) error {
+	var consent any
+	if state.Consent != nil {
+		data, err := json.Marshal(toConsentRecord(state.Consent))
+		if err != nil {
+			return fmt.Errorf("failed to marshal consent: %w", err)
+		}
+		consent = string(data)
+	}
+	var tags any
+	if len(state.Tags) > 0 {
+		data, err := json.Marshal(state.Tags)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tags: %w", err)
+		}
+		tags = string(data)
+	}
+	_, err := q.Exec(`INSERT INTO plugin_state (plugin_name, added_at, last_executed_at, run_count, consent, tags)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (plugin_name) DO UPDATE SET added_at = excluded.added_at,
+			last_executed_at = excluded.last_executed_at, run_count = excluded.run_count,
+			consent = excluded.consent, tags = excluded.tags`,
+		state.PluginName, unixNano(state.AddedAt), unixNano(state.LastExecutedAt),
+		state.RunCount, consent, tags)
+	if err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	for _, r := range state.History {
+		_, err := q.Exec(`INSERT INTO run_history (plugin_name, started_at, args, exit_code, duration, status, reason)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (plugin_name, started_at) DO UPDATE SET args = excluded.args,
+				exit_code = excluded.exit_code, duration = excluded.duration,
+				status = excluded.status, reason = excluded.reason`,
+			state.PluginName, unixNano(r.Timestamp), r.Args, r.ExitCode, int64(r.Duration), string(r.Status), r.Reason)
+		if err != nil {
+			return fmt.Errorf("failed to write run history: %w", err)
+		}
+	}
+	return nil
+}
+
+// SQLiteUsageStore implements ports.UsageStore on kodkafa.db.
+type SQLiteUsageStore struct {
+	db *SQLiteDB
+}
+
+// NewSQLiteUsageStore creates a new UsageStore backed by SQLite.
+func NewSQLiteUsageStore(db *SQLiteDB) ports.UsageStore {
+	return &SQLiteUsageStore{db: db}
+}
+
+// Read reads the global usage statistics.
+func (us *SQLiteUsageStore) Read() (*entities.UsageStats, error) {
+	return readUsage(us.db.db)
+}
+
+// Write persists the global usage statistics.
+func (us *SQLiteUsageStore) Write(stats *entities.UsageStats) error {
+	tx, err := us.db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := writeUsage(tx, stats); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update applies fn to the usage statistics inside one write transaction.
+func (us *SQLiteUsageStore) Update(fn func(*entities.UsageStats) error) error {
+	tx, err := us.db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stats, err := readUsage(tx)
+	if err != nil {
+		return err
+	}
+	if err := fn(stats); err != nil {
+		return err
+	}
+	if err := writeUsage(tx, stats); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func readUsage(q queryer) (*entities.UsageStats, error) {
+	stats := entities.NewUsageStats()
+	rows, err := q.Query(`SELECT list, plugin_name, timestamp, run_count FROM usage_entries ORDER BY list, position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var list string
+		var e entities.UsageEntry
+		var ts int64
+		if err := rows.Scan(&list, &e.PluginName, &ts, &e.RunCount); err != nil {
+			return nil, fmt.Errorf("failed to read usage: %w", err)
+		}
+		e.Timestamp = fromUnixNano(ts)
+		switch list {
+		case usageMost:
+			stats.MostUsed = append(stats.MostUsed, e)
+		case usagePinned:
+			stats.Pinned = append(stats.Pinned, e)
+		default:
+			stats.RecentlyUsed = append(stats.RecentlyUsed, e)
+		}
+	}
+	return stats, rows.Err()
+}
+
+func writeUsage(q queryer, stats *entities.UsageStats) error {
+	if _, err := q.Exec(`DELETE FROM usage_entries`); err != nil {
+		return fmt.Errorf("failed to write usage: %w", err)
+	}
+	for list, entries := range map[string][]entities.UsageEntry{usageRecent: stats.RecentlyUsed, usageMost: stats.MostUsed, usagePinned: stats.Pinned} {
+		for i, e := range entries {
+			_, err := q.Exec(`INSERT INTO usage_entries (list, position, plugin_name, timestamp, run_count) VALUES (?, ?, ?, ?, ?)`,
+				list, i, e.PluginName, unixNano(e.Timestamp), e.RunCount)
+			if err != nil {
+				return fmt.Errorf("failed to write usage: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -25,8 +25,8 @@ func NewStateStore(baseDir string) ports.StateStore {
 func (ss *StateStoreImpl) Read(pluginName string) (*entities.PluginState, error) {
 	filename := fmt.Sprintf("state/%s.json", pluginName)
 
-	var state entities.PluginState
-	err := ss.store.Read(filename, &state)
+	var record stateRecord
+	err := ss.store.Read(filename, &record)
 	if err != nil {
 		// If file doesn't exist, create a new state
 		if !ss.store.Exists(filename) {
@@ -38,16 +38,42 @@ func (ss *StateStoreImpl) Read(pluginName string) (*entities.PluginState, error)
 		}
 		return nil, fmt.Errorf("failed to read state: %w", err)
 	}
+	if err := checkVersion(filename, record.SchemaVersion, stateSchemaVersion); err != nil {
+		return nil, err
+	}
 
 	// Ensure plugin name matches
+	state := record.toEntity()
 	state.PluginName = pluginName
-	return &state, nil
+	return state, nil
 }
 
 // Write persists the state for a plugin.
 func (ss *StateStoreImpl) Write(state *entities.PluginState) error {
 	filename := fmt.Sprintf("state/%s.json", state.PluginName)
-	return ss.store.Write(filename, state)
+	return ss.store.Write(filename, toStateRecord(state))
+}
+
+// Update applies fn to the plugin's state under its file lock.
+func (ss *StateStoreImpl) Update(pluginName string, fn func(*entities.PluginState) error) error {
+	filename := fmt.Sprintf("state/%s.json", pluginName)
+
+	var record stateRecord
+	return ss.store.Update(filename, &record, func(exists bool) error {
+		state := entities.NewPluginState(pluginName)
+		if exists {
+			if err := checkVersion(filename, record.SchemaVersion, stateSchemaVersion); err != nil {
+				return err
+			}
+			state = record.toEntity()
+		}
+		state.PluginName = pluginName
+		if err := fn(state); err != nil {
+			return err
+		}
+		record = toStateRecord(state)
+		return nil
+	})
 }
 
 // Delete removes the state for a plugin.
@@ -19,8 +19,8 @@ func NewUsageStore(baseDir string) ports.UsageStore {
 
 // Read reads the global usage statistics.
 func (us *UsageStoreImpl) Read() (*entities.UsageStats, error) {
-	var stats entities.UsageStats
-	err := us.store.Read("usage.json", &stats)
+	var record usageRecord
+	err := us.store.Read("usage.json", &record)
 	if err != nil {
 		// If file doesn't exist, create default stats
 		if !us.store.Exists("usage.json") {
@@ -32,10 +32,32 @@ func (us *UsageStoreImpl) Read() (*entities.UsageStats, error) {
 		}
 		return nil, err
 	}
-	return &stats, nil
+	if err := checkVersion("usage.json", record.SchemaVersion, usageSchemaVersion); err != nil {
+		return nil, err
+	}
+	return record.toEntity(), nil
 }
 
 // Write persists the global usage statistics.
 func (us *UsageStoreImpl) Write(stats *entities.UsageStats) error {
-	return us.store.Write("usage.json", stats)
+	return us.store.Write("usage.json", toUsageRecord(stats))
+}
+
+// Update applies fn to the usage statistics under the file lock.
+func (us *UsageStoreImpl) Update(fn func(*entities.UsageStats) error) error {
+	var record usageRecord
+	return us.store.Update("usage.json", &record, func(exists bool) error {
+		stats := entities.NewUsageStats()
+		if exists {
+			if err := checkVersion("usage.json", record.SchemaVersion, usageSchemaVersion); err != nil {
+				return err
+			}
+			stats = record.toEntity()
+		}
+		if err := fn(stats); err != nil {
+			return err
+		}
+		record = toUsageRecord(stats)
+		return nil
+	})
 }
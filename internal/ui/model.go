@@ -39,6 +39,10 @@ type Model struct {
 	infoUC   *usecases.GetPluginInfoUseCase
 	runUC    *usecases.RunPluginUseCase
 	initUC   *usecases.InitLayoutUseCase
+	searchUC *usecases.SearchPluginsUseCase
+
+	listConfigUC *usecases.ListConfigUseCase
+	setConfigUC  *usecases.SetConfigUseCase
 
 	pendingCmd        string
 	pendingName       string
@@ -50,8 +54,8 @@ type Model struct {
 }
 
 // NewModel creates the root TUI model.
-func NewModel(listUC *usecases.ListPluginsUseCase, addUC *usecases.AddPluginUseCase, deleteUC *usecases.DeletePluginUseCase, loadUC *usecases.LoadPluginDepsUseCase, infoUC *usecases.GetPluginInfoUseCase, runUC *usecases.RunPluginUseCase, initUC *usecases.InitLayoutUseCase, showSplash bool) *Model {
-	dashboard := screens.NewDashboardModel(listUC)
+func NewModel(listUC *usecases.ListPluginsUseCase, addUC *usecases.AddPluginUseCase, deleteUC *usecases.DeletePluginUseCase, loadUC *usecases.LoadPluginDepsUseCase, infoUC *usecases.GetPluginInfoUseCase, runUC *usecases.RunPluginUseCase, initUC *usecases.InitLayoutUseCase, searchUC *usecases.SearchPluginsUseCase, listConfigUC *usecases.ListConfigUseCase, setConfigUC *usecases.SetConfigUseCase, pinUC *usecases.PinPluginUseCase, showSplash bool) *Model {
+	dashboard := screens.NewDashboardModel(listUC, setConfigUC, pinUC)
 
 	var activeScreen tea_pkg.Model = dashboard
 	if showSplash {
@@ -69,6 +73,9 @@ func NewModel(listUC *usecases.ListPluginsUseCase, addUC *usecases.AddPluginUseC
 		infoUC:       infoUC,
 		runUC:        runUC,
 		initUC:       initUC,
+		searchUC:     searchUC,
+		listConfigUC: listConfigUC,
+		setConfigUC:  setConfigUC,
 	}
 }
 
@@ -266,6 +273,12 @@ func (m *Model) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
 			m.inputModel = screens.NewInputModel(m.addUC, msg.Mode)
 			m.activeScreen = m.inputModel
 			return m, m.inputModel.Init()
+		case tea.StateBrowse:
+			m.activeScreen = screens.NewBrowseModel(m.searchUC, m.addUC)
+			return m, m.activeScreen.Init()
+		case tea.StateSettings:
+			m.activeScreen = screens.NewSettingsModel(m.listConfigUC, m.setConfigUC)
+			return m, m.activeScreen.Init()
 		case tea.StateDeleteConfirm:
 			// Handled by switch cmdToRun
 		case tea.StateDeleteDepsConfirm:
@@ -310,6 +323,15 @@ func (m *Model) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
 				return m, tea_pkg.Quit
 			}
 		}
+	case tea.PluginConsentMsg:
+		m.loading(false)
+		m.activeScreen = screens.NewConsentModel(msg.Plugin, func() tea_pkg.Msg {
+			return msg.Answer(true)
+		}, func() tea_pkg.Msg {
+			return msg.Answer(false)
+		})
+		return m, m.activeScreen.Init()
+
 	case tea.PluginAddedMsg:
 		m.loading(true)
 		// Auto-load dependencies
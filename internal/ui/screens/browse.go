@@ -0,0 +1,208 @@
+package screens
+
+import (
+	"fmt"
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/app/usecases"
+	"kodkafa/internal/ui/components"
+	"kodkafa/internal/ui/tea"
+	"strings"
+
+	tea_pkg "github.com/charmbracelet/bubbletea"
+)
+
+// browseVisible is how many registry entries are listed at once.
+const browseVisible = 12
+
+// BrowseModel lists the plugins of the configured registries and installs
+// the selected one.
+type BrowseModel struct {
+	searchUC    *usecases.SearchPluginsUseCase
+	addUC       *usecases.AddPluginUseCase
+	data        dto.SearchPluginsResult
+	cursor      int
+	loading     bool
+	adding      bool
+	err         error
+	filter      string
+	isSearching bool
+}
+
+// NewBrowseModel creates a new BrowseModel.
+func NewBrowseModel(searchUC *usecases.SearchPluginsUseCase, addUC *usecases.AddPluginUseCase) *BrowseModel {
+	return &BrowseModel{
+		searchUC: searchUC,
+		addUC:    addUC,
+		loading:  true,
+	}
+}
+
+// Init loads every registry entry; filtering happens on screen.
+func (m *BrowseModel) Init() tea_pkg.Cmd {
+	return func() tea_pkg.Msg {
+		data, err := m.searchUC.Execute(usecases.SearchPluginsInput{})
+		if err != nil {
+			return tea.ErrMsg{Err: err}
+		}
+		return tea.RegistryLoadedMsg{Data: data}
+	}
+}
+
+// Update handles input and registry loading.
+func (m *BrowseModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
+	switch msg := msg.(type) {
+	case tea.RegistryLoadedMsg:
+		m.data = msg.Data
+		m.loading = false
+		m.cursor = 0
+		return m, nil
+
+	case tea.ErrMsg:
+		m.err = msg.Err
+		m.loading = false
+		m.adding = false
+		return m, nil
+
+	case tea_pkg.KeyMsg:
+		if m.loading || m.adding {
+			return m, nil
+		}
+
+		if m.isSearching {
+			switch msg.String() {
+			case "esc":
+				m.isSearching = false
+				m.filter = ""
+				m.cursor = 0
+			case "enter":
+				m.isSearching = false
+			case "backspace":
+				if len(m.filter) > 0 {
+					m.filter = m.filter[:len(m.filter)-1]
+					m.cursor = 0
+				}
+			default:
+				if len(msg.String()) == 1 {
+					m.filter += msg.String()
+					m.cursor = 0
+				}
+			}
+			return m, nil
+		}
+
+		plugins := m.filtered()
+		switch msg.String() {
+		case "esc", "left":
+			return m, func() tea_pkg.Msg {
+				return tea.SwitchStateMsg{State: tea.StateNormal}
+			}
+		case "s":
+			m.isSearching = true
+		case "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down":
+			if m.cursor < len(plugins)-1 {
+				m.cursor++
+			}
+		case "enter":
+			if m.cursor >= len(plugins) {
+				return m, nil
+			}
+			p := plugins[m.cursor]
+			if p.Installed {
+				return m, func() tea_pkg.Msg {
+					return tea.PluginSelectedMsg{PluginName: p.Name, Cmd: "kod info"}
+				}
+			}
+			m.adding = true
+			return m, addPlugin(m.addUC, p.Name)
+		}
+	}
+	return m, nil
+}
+
+func (m *BrowseModel) filtered() []dto.RegistryPluginInfo {
+	if m.filter == "" {
+		return m.data.Plugins
+	}
+	query := strings.ToLower(m.filter)
+	var res []dto.RegistryPluginInfo
+	for _, p := range m.data.Plugins {
+		text := strings.ToLower(p.Name + " " + p.Description + " " + strings.Join(p.Tags, " "))
+		if strings.Contains(text, query) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// View renders the registry list.
+func (m *BrowseModel) View() string {
+	var b strings.Builder
+	b.WriteString(components.RenderHeader("A Persistent CLI with Memory", "PLUGIN REGISTRY"))
+
+	switch {
+	case m.loading:
+		b.WriteString("  Loading registries...\n")
+		return b.String()
+	case m.adding:
+		b.WriteString("  Adding plugin...\n")
+		return b.String()
+	case m.err != nil:
+		b.WriteString(failStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n\n")
+		b.WriteString(components.RenderFooter(components.FooterItem{Key: "←/Esc", Label: "Back"}))
+		return b.String()
+	}
+
+	if m.isSearching || m.filter != "" {
+		b.WriteString("Search: " + m.filter)
+		if m.isSearching {
+			b.WriteString("█")
+		}
+		b.WriteString("\n\n")
+	}
+	for _, e := range m.data.Errors {
+		b.WriteString(failStyle.Render("  "+e) + "\n")
+	}
+
+	plugins := m.filtered()
+	if len(plugins) == 0 {
+		b.WriteString("  No plugins found.\n")
+	}
+	start := max(0, min(m.cursor-browseVisible/2, len(plugins)-browseVisible))
+	for i := start; i < len(plugins) && i < start+browseVisible; i++ {
+		p := plugins[i]
+		style := unselectedItemStyle
+		prefix := "  "
+		if i == m.cursor {
+			style = selectedItemStyle
+			prefix = "> "
+		}
+		desc := p.Description
+		if p.Installed {
+			desc = "[installed] " + desc
+		}
+		b.WriteString(style.Render(fmt.Sprintf("%s%-20s %-10s %s", prefix, p.Name, p.Latest, desc)) + "\n")
+	}
+
+	if m.cursor < len(plugins) {
+		p := plugins[m.cursor]
+		b.WriteString("\n")
+		if len(p.Tags) > 0 {
+			b.WriteString(paginationStyle.Render("Tags: "+strings.Join(p.Tags, ", ")) + "\n")
+		}
+		b.WriteString(paginationStyle.Render("Source: "+p.Source) + "\n")
+		b.WriteString(paginationStyle.Render("Registry: "+p.Registry) + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(components.RenderFooter(
+		components.FooterItem{Key: "↑/↓", Label: ""},
+		components.FooterItem{Key: "S", Label: "Search"},
+		components.FooterItem{Key: "Enter", Label: "Add"},
+		components.FooterItem{Key: "←/Esc", Label: "Back"},
+	))
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package screens
+
+import (
+	"strings"
+
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/ui/components"
+	"kodkafa/internal/ui/theme"
+
+	tea_pkg "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ConsentModel shows the permissions a plugin requests and asks the user to grant them.
+type ConsentModel struct {
+	plugin    dto.PluginInfo
+	onConfirm func() tea_pkg.Msg
+	onCancel  func() tea_pkg.Msg
+}
+
+func NewConsentModel(plugin dto.PluginInfo, onConfirm, onCancel func() tea_pkg.Msg) *ConsentModel {
+	return &ConsentModel{
+		plugin:    plugin,
+		onConfirm: onConfirm,
+		onCancel:  onCancel,
+	}
+}
+
+func (m *ConsentModel) Init() tea_pkg.Cmd {
+	return nil
+}
+
+func (m *ConsentModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
+	switch msg := msg.(type) {
+	case tea_pkg.KeyMsg:
+		switch msg.String() {
+		case "y", "Y":
+			return m, m.onConfirm
+		case "n", "N", "esc":
+			return m, m.onCancel
+		}
+	}
+	return m, nil
+}
+
+func (m *ConsentModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(components.RenderHeader("A Persistent CLI with Memory", "PLUGIN PERMISSIONS"))
+
+	b.WriteString(infoLabelStyle.Render("Plugin:") + " " + lipgloss.NewStyle().Foreground(theme.Accent).Bold(true).Render(m.plugin.Name) + "\n")
+	if m.plugin.Description != "" {
+		b.WriteString(infoLabelStyle.Render("Description:") + " " + infoValueStyle.Render(m.plugin.Description) + "\n")
+	}
+	b.WriteString("\n")
+
+	for _, line := range m.plugin.Permissions.Describe() {
+		b.WriteString("  " + infoValueStyle.Render(line) + "\n")
+	}
+
+	b.WriteString("\n  Grant these permissions and install? (y/N)\n\n")
+	b.WriteString(components.RenderFooter(
+		components.FooterItem{Key: "Y", Label: "Yes, install"},
+		components.FooterItem{Key: "N/Esc", Label: "Cancel"},
+	))
+
+	return b.String()
+}
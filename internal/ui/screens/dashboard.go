@@ -8,6 +8,7 @@ import (
 	"kodkafa/internal/ui/components"
 	"kodkafa/internal/ui/tea"
 	"strings"
+	"time"
 
 	"kodkafa/internal/ui/theme"
 
@@ -44,45 +45,107 @@ var (
 				PaddingLeft(1)
 )
 
+// linkedWatchInterval is how often linked plugins are re-read for manifest edits.
+const linkedWatchInterval = 2 * time.Second
+
+// Dashboard sections, as stored in DashboardModel.listSource.
+const (
+	sourceTop = iota
+	sourceMain
+	sourceProject
+)
+
+// sectionOrder is the order sections are shown and navigated in.
+var sectionOrder = []int{sourceProject, sourceTop, sourceMain}
+
+// groupedVisible is how many rows of the grouped main list are shown at once.
+const groupedVisible = 15
+
+// dashboardRow is a line of a section: a plugin, or the header of a category
+// when the main list is grouped.
+type dashboardRow struct {
+	item     *dto.PluginListItem // nil for a category header
+	category string
+	count    int // plugins in the category, for headers
+}
+
 // DashboardModel handles the main plugin list view.
 type DashboardModel struct {
 	listUC      *usecases.ListPluginsUseCase
+	setConfigUC *usecases.SetConfigUseCase
+	pinUC       *usecases.PinPluginUseCase
 	data        dto.DashboardDTO
 	cursor      int
-	listSource  int // 0 for Top list, 1 for Main list
+	listSource  int // sourceTop, sourceMain or sourceProject
 	loading     bool
 	err         error
 	filter      string
 	isSearching bool
+	all         *dto.DashboardDTO // every plugin, read once per search and filtered as it is typed
+	watchGen    int               // invalidates linked-plugin watch ticks from earlier loads
+	collapsed   map[string]bool   // grouped categories folded away, by lower-case name
+	follow      string            // plugin to put the cursor on once the lists reload
 }
 
 // NewDashboardModel creates a new DashboardModel.
-func NewDashboardModel(listUC *usecases.ListPluginsUseCase) *DashboardModel {
+func NewDashboardModel(listUC *usecases.ListPluginsUseCase, setConfigUC *usecases.SetConfigUseCase, pinUC *usecases.PinPluginUseCase) *DashboardModel {
 	return &DashboardModel{
-		listUC:  listUC,
-		loading: true,
+		listUC:      listUC,
+		setConfigUC: setConfigUC,
+		pinUC:       pinUC,
+		loading:     true,
+		listSource:  sectionOrder[0],
+		collapsed:   make(map[string]bool),
 	}
 }
 
 // Init fetches the initial plugin list.
 func (m *DashboardModel) Init() tea_pkg.Cmd {
-	return func() tea_pkg.Msg {
-		data, err := m.listUC.Execute(usecases.ListPluginsInput{Page: 1, PageSize: 0})
-		if err != nil {
-			return tea.ErrMsg{Err: err}
-		}
-		return tea.PluginsLoadedMsg{Data: data}
-	}
+	return m.fetchPage(1)
 }
 
 // Update handles input and data loading for the dashboard.
 func (m *DashboardModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
 	switch msg := msg.(type) {
 	case tea.PluginsLoadedMsg:
-		m.data = msg.Data
+		if msg.Data.Query != m.filter {
+			// A search typed further since; filter the plugins this one read
+			if msg.All != nil && m.all == nil && strings.TrimSpace(m.filter) != "" {
+				m.all = msg.All
+				return m, m.fetchPage(1)
+			}
+			return m, nil
+		}
+		m.data, m.all = msg.Data, msg.All
 		m.loading = false
 		m.cursor = 0 // Reset cursor to avoid out-of-bounds
-		return m, nil
+		m.clampCursor()
+		if m.follow != "" {
+			m.selectPlugin(m.follow)
+			m.follow = ""
+		}
+		return m, m.watchLinked()
+
+	case tea.LinkedWatchTickMsg:
+		if msg.Gen != m.watchGen || m.loading {
+			return m, nil
+		}
+		page, query := m.data.CurrentPage, m.filter
+		return m, func() tea_pkg.Msg {
+			data, all, err := m.list(page, query)
+			if err != nil {
+				return nil
+			}
+			return tea.LinkedRefreshMsg{Gen: msg.Gen, Data: data, All: all}
+		}
+
+	case tea.LinkedRefreshMsg:
+		if msg.Gen != m.watchGen || m.loading || msg.Data.Query != m.filter {
+			return m, nil
+		}
+		m.data, m.all = msg.Data, msg.All
+		m.clampCursor()
+		return m, m.watchLinked()
 
 	case tea.ErrMsg:
 		m.err = msg.Err
@@ -118,6 +181,42 @@ func (m *DashboardModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
 			case "s":
 				m.isSearching = true
 				return m, nil
+			case "b":
+				return m, func() tea_pkg.Msg {
+					return tea.SwitchStateMsg{State: tea.StateBrowse}
+				}
+			case "o":
+				m.loading = true
+				return m, m.saveSetting("sort_by", usecases.NextSortMode(m.data.SortBy))
+			case "g":
+				m.loading = true
+				return m, m.saveSetting("group_by_category", fmt.Sprint(!m.data.Grouped))
+			case "c":
+				m.toggleGroup()
+				return m, nil
+			case "p":
+				if pName != "" {
+					m.loading = true
+					return m, m.pin(usecases.PinPluginInput{PluginName: pName, Op: usecases.PinToggle})
+				}
+			case "shift+up", "[", "shift+down", "]":
+				// Reorder pinned plugins, which lead the top list
+				row := m.selectedRow()
+				if m.listSource != sourceTop || row == nil || !row.item.Pinned {
+					return m, nil
+				}
+				pos := m.cursor // one up, counting from 1
+				if k := msg.String(); k == "shift+down" || k == "]" {
+					pos = m.cursor + 2
+					if pos > len(m.data.TopPlugins) || !m.data.TopPlugins[pos-1].Pinned {
+						return m, nil
+					}
+				}
+				if pos < 1 {
+					return m, nil
+				}
+				m.loading = true
+				return m, m.pin(usecases.PinPluginInput{PluginName: pName, Position: pos})
 			}
 		}
 
@@ -127,22 +226,21 @@ func (m *DashboardModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
 			case "esc":
 				m.isSearching = false
 				m.filter = ""
-				m.updateFilter()
-				return m, nil
+				return m, m.fetchPage(1)
 			case "enter":
 				m.isSearching = false
 				return m, nil
 			case "backspace":
 				if len(m.filter) > 0 {
 					m.filter = m.filter[:len(m.filter)-1]
-					m.updateFilter()
+					return m, m.fetchPage(1)
 				}
 				return m, nil
 			default:
 				// If simple character, append
 				if len(msg.String()) == 1 {
 					m.filter += msg.String()
-					m.updateFilter()
+					return m, m.fetchPage(1)
 				}
 				return m, nil
 			}
@@ -153,36 +251,46 @@ func (m *DashboardModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
 		case "up":
 			m.cursor--
 			if m.cursor < 0 {
-				if m.listSource == 1 && len(m.filteredTop()) > 0 {
-					m.listSource = 0
-					m.cursor = len(m.filteredTop()) - 1
+				if prev, ok := m.adjacentSection(-1); ok {
+					m.listSource = prev
+					m.cursor = len(m.rows(prev)) - 1
 				} else {
 					m.cursor = 0
 				}
 			}
 		case "down":
 			m.cursor++
-			if m.listSource == 0 {
-				if m.cursor >= len(m.filteredTop()) {
-					m.listSource = 1
+			if n := len(m.rows(m.listSource)); m.cursor >= n {
+				if next, ok := m.adjacentSection(1); ok {
+					m.listSource = next
 					m.cursor = 0
-				}
-			} else {
-				if m.cursor >= len(m.filteredMain()) {
-					m.cursor = len(m.filteredMain()) - 1
+				} else {
+					m.cursor = max(n-1, 0)
 				}
 			}
 		case "left":
-			if m.listSource == 1 && m.data.CurrentPage > 1 {
+			if m.listSource == sourceMain && m.data.Grouped {
+				if row := m.selectedRow(); row != nil && !m.collapsed[groupKey(row.category)] {
+					m.toggleGroup()
+				}
+			} else if m.listSource == sourceMain && m.data.CurrentPage > 1 {
 				m.loading = true
 				return m, m.fetchPage(m.data.CurrentPage - 1)
 			}
 		case "right":
-			if m.listSource == 1 && m.data.CurrentPage < m.data.TotalPages {
+			if m.listSource == sourceMain && m.data.Grouped {
+				if row := m.selectedRow(); row != nil && row.item == nil && m.collapsed[groupKey(row.category)] {
+					m.toggleGroup()
+				}
+			} else if m.listSource == sourceMain && m.data.CurrentPage < m.data.TotalPages {
 				m.loading = true
 				return m, m.fetchPage(m.data.CurrentPage + 1)
 			}
 		case "enter":
+			if row := m.selectedRow(); row != nil && row.item == nil {
+				m.toggleGroup()
+				return m, nil
+			}
 			if pName != "" {
 				return m, func() tea_pkg.Msg {
 					return tea.PluginSelectedMsg{PluginName: pName}
@@ -193,58 +301,234 @@ func (m *DashboardModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
 	return m, nil
 }
 
-func (m *DashboardModel) updateFilter() {
-	m.cursor = 0
+// watchLinked schedules the next re-read of the plugin list while linked
+// plugins are shown and watch_linked is enabled.
+func (m *DashboardModel) watchLinked() tea_pkg.Cmd {
+	m.watchGen++
+	if !m.data.WatchLinked || !hasLinked(m.data) {
+		return nil
+	}
+	gen := m.watchGen
+	return tea_pkg.Tick(linkedWatchInterval, func(time.Time) tea_pkg.Msg {
+		return tea.LinkedWatchTickMsg{Gen: gen}
+	})
+}
+
+func hasLinked(data dto.DashboardDTO) bool {
+	for _, list := range [][]dto.PluginListItem{data.ProjectPlugins, data.TopPlugins, data.MainPlugins} {
+		for _, p := range list {
+			if p.Linked {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clampCursor keeps the cursor on an existing item after the lists changed,
+// moving to the first non-empty section when the current one became empty.
+func (m *DashboardModel) clampCursor() {
+	if len(m.rows(m.listSource)) == 0 {
+		for _, src := range sectionOrder {
+			if len(m.rows(src)) > 0 {
+				m.listSource = src
+				m.cursor = 0
+				break
+			}
+		}
+	}
+	if n := len(m.rows(m.listSource)); m.cursor >= n {
+		m.cursor = max(n-1, 0)
+	}
 }
 
-func (m *DashboardModel) filteredTop() []dto.PluginListItem {
-	if m.filter == "" {
+// section returns the items of a dashboard section. While searching the
+// lists hold only matches, filtered by usecases.SearchPlugins.
+func (m *DashboardModel) section(src int) []dto.PluginListItem {
+	switch src {
+	case sourceTop:
 		return m.data.TopPlugins
+	case sourceProject:
+		return m.data.ProjectPlugins
+	}
+	return m.data.MainPlugins
+}
+
+// adjacentSection returns the nearest non-empty section before (dir -1) or
+// after (dir 1) the current one.
+func (m *DashboardModel) adjacentSection(dir int) (int, bool) {
+	i := 0
+	for i < len(sectionOrder) && sectionOrder[i] != m.listSource {
+		i++
 	}
-	var res []dto.PluginListItem
-	for _, p := range m.data.TopPlugins {
-		if strings.Contains(strings.ToLower(p.Name), strings.ToLower(m.filter)) {
-			res = append(res, p)
+	for i += dir; i >= 0 && i < len(sectionOrder); i += dir {
+		if len(m.rows(sectionOrder[i])) > 0 {
+			return sectionOrder[i], true
 		}
 	}
-	return res
+	return 0, false
 }
 
-func (m *DashboardModel) filteredMain() []dto.PluginListItem {
-	if m.filter == "" {
-		return m.data.MainPlugins
+// rows returns the lines of a section. The grouped main list has a header
+// per category, followed by its plugins unless the category is collapsed.
+func (m *DashboardModel) rows(src int) []dashboardRow {
+	items := m.section(src)
+	var rows []dashboardRow
+	if src != sourceMain || !m.data.Grouped {
+		for i := range items {
+			rows = append(rows, dashboardRow{item: &items[i], category: items[i].Category})
+		}
+		return rows
 	}
-	var res []dto.PluginListItem
-	for _, p := range m.data.MainPlugins {
-		if strings.Contains(strings.ToLower(p.Name), strings.ToLower(m.filter)) {
-			res = append(res, p)
+	for start := 0; start < len(items); {
+		end := start + 1
+		for end < len(items) && groupKey(items[end].Category) == groupKey(items[start].Category) {
+			end++
+		}
+		rows = append(rows, dashboardRow{category: items[start].Category, count: end - start})
+		if !m.collapsed[groupKey(items[start].Category)] {
+			for i := start; i < end; i++ {
+				rows = append(rows, dashboardRow{item: &items[i], category: items[i].Category})
+			}
 		}
+		start = end
 	}
-	return res
+	return rows
 }
 
-func (m *DashboardModel) getSelectedName() string {
-	top := m.filteredTop()
-	main := m.filteredMain()
+// groupKey identifies a category regardless of case.
+func groupKey(category string) string {
+	return strings.ToLower(category)
+}
+
+func (m *DashboardModel) selectedRow() *dashboardRow {
+	rows := m.rows(m.listSource)
+	if m.cursor >= 0 && m.cursor < len(rows) {
+		return &rows[m.cursor]
+	}
+	return nil
+}
 
-	if m.listSource == 0 && m.cursor < len(top) {
-		return top[m.cursor].Name
-	} else if m.listSource == 1 && m.cursor < len(main) && m.cursor >= 0 {
-		return main[m.cursor].Name
+func (m *DashboardModel) getSelectedName() string {
+	if row := m.selectedRow(); row != nil && row.item != nil {
+		return row.item.Name
 	}
 	return ""
 }
 
+// toggleGroup collapses or expands the category of the selected row in the
+// grouped main list, leaving the cursor on its header.
+func (m *DashboardModel) toggleGroup() {
+	row := m.selectedRow()
+	if m.listSource != sourceMain || !m.data.Grouped || row == nil {
+		return
+	}
+	key := groupKey(row.category)
+	m.collapsed[key] = !m.collapsed[key]
+	for i, r := range m.rows(sourceMain) {
+		if r.item == nil && groupKey(r.category) == key {
+			m.cursor = i
+			break
+		}
+	}
+}
+
+// selectPlugin moves the cursor to the named plugin, if it is listed.
+func (m *DashboardModel) selectPlugin(name string) {
+	for _, src := range sectionOrder {
+		for i, row := range m.rows(src) {
+			if row.item != nil && row.item.Name == name {
+				m.listSource, m.cursor = src, i
+				return
+			}
+		}
+	}
+}
+
+// pin pins, unpins or moves a plugin and reloads the current page, keeping
+// the cursor on the plugin.
+func (m *DashboardModel) pin(input usecases.PinPluginInput) tea_pkg.Cmd {
+	m.follow = input.PluginName
+	page, query := m.data.CurrentPage, m.filter
+	return func() tea_pkg.Msg {
+		if _, err := m.pinUC.Execute(input); err != nil {
+			return tea.ErrMsg{Err: err}
+		}
+		data, all, err := m.list(page, query)
+		if err != nil {
+			return tea.ErrMsg{Err: err}
+		}
+		return tea.PluginsLoadedMsg{Data: data, All: all}
+	}
+}
+
+// saveSetting saves a dashboard preference, such as sort_by, and reloads
+// the lists with it.
+func (m *DashboardModel) saveSetting(key, value string) tea_pkg.Cmd {
+	query := m.filter
+	return func() tea_pkg.Msg {
+		if _, err := m.setConfigUC.Execute(usecases.SetConfigInput{Key: key, Value: value}); err != nil {
+			return tea.ErrMsg{Err: err}
+		}
+		data, all, err := m.list(1, query)
+		if err != nil {
+			return tea.ErrMsg{Err: err}
+		}
+		return tea.PluginsLoadedMsg{Data: data, All: all}
+	}
+}
+
+// fetchPage loads a page of the lists. Once a search has read every plugin,
+// further keystrokes and pages filter those instead of reading them again.
 func (m *DashboardModel) fetchPage(page int) tea_pkg.Cmd {
+	query, all := m.filter, m.all
 	return func() tea_pkg.Msg {
-		data, err := m.listUC.Execute(usecases.ListPluginsInput{Page: page, PageSize: 0})
+		if all != nil && strings.TrimSpace(query) != "" {
+			return tea.PluginsLoadedMsg{Data: usecases.SearchPlugins(*all, query, page), All: all}
+		}
+		data, all, err := m.list(page, query)
 		if err != nil {
 			return tea.ErrMsg{Err: err}
 		}
-		return tea.PluginsLoadedMsg{Data: data}
+		return tea.PluginsLoadedMsg{Data: data, All: all}
 	}
 }
 
+// list reads the lists for a page. A search reads every plugin and returns
+// them as well, for the next keystrokes to filter.
+func (m *DashboardModel) list(page int, query string) (dto.DashboardDTO, *dto.DashboardDTO, error) {
+	if strings.TrimSpace(query) == "" {
+		data, err := m.listUC.Execute(usecases.ListPluginsInput{Page: page, PageSize: 0, Query: query})
+		return data, nil, err
+	}
+	all, err := m.listUC.Execute(usecases.ListPluginsInput{All: true})
+	if err != nil {
+		return dto.DashboardDTO{}, nil, err
+	}
+	return usecases.SearchPlugins(all, query, page), &all, nil
+}
+
+// itemDescription marks pinned and linked plugins in front of their
+// description.
+func itemDescription(p dto.PluginListItem) string {
+	desc := p.Description
+	if p.Linked {
+		desc = "[linked] " + desc
+	}
+	if p.Pinned {
+		desc = "★ " + desc
+	}
+	return desc
+}
+
+// sortLabel turns a sort_by value into words, e.g. "most used".
+func sortLabel(mode string) string {
+	if mode == "" {
+		mode = "name"
+	}
+	return strings.ReplaceAll(mode, "_", " ")
+}
+
 // View renders the dashboard.
 func (m *DashboardModel) View() string {
 	if m.loading {
@@ -264,51 +548,108 @@ func (m *DashboardModel) View() string {
 		b.WriteString("Search: " + m.filter)
 		if m.isSearching {
 			b.WriteString("█")
+			if m.filter == "" {
+				b.WriteString(paginationStyle.Render("  name or description, lang:python tag:db category:dev failed: linked:"))
+			}
 		}
 		b.WriteString("\n\n")
 	}
 
 	line := "|───────────────────────────────────────────────────────────────────────"
 
-	// Top List (Fixed)
-	topPlugins := m.filteredTop()
-	if len(topPlugins) > 0 {
-		b.WriteString(sectionHeaderStyle.Render("Recents") + " ")
-		b.WriteString(sectionHeaderStyle.Render(line) + "\n\n")
-		for i, p := range topPlugins {
+	renderRows := func(src int, rows []dashboardRow, start, end int) {
+		for i := start; i < end; i++ {
 			style := unselectedItemStyle
 			prefix := "  "
-			if m.listSource == 0 && i == m.cursor {
+			if m.listSource == src && i == m.cursor {
 				style = selectedItemStyle
 				prefix = "> "
 			}
-			b.WriteString(style.Render(fmt.Sprintf("%s%-20s %s", prefix, p.Name, p.Description)) + "\n")
+			row := rows[i]
+			switch {
+			case row.item == nil:
+				fold := "▾"
+				if m.collapsed[groupKey(row.category)] {
+					fold = "▸"
+				}
+				name := row.category
+				if name == "" {
+					name = "Uncategorized"
+				}
+				b.WriteString(style.Render(fmt.Sprintf("%s%s %s (%d)", prefix, fold, name, row.count)) + "\n")
+			case src == sourceMain && m.data.Grouped:
+				b.WriteString(style.Render(fmt.Sprintf("%s  %-18s %s", prefix, row.item.Name, itemDescription(*row.item))) + "\n")
+			default:
+				b.WriteString(style.Render(fmt.Sprintf("%s%-20s %s", prefix, row.item.Name, itemDescription(*row.item))) + "\n")
+			}
+		}
+	}
+	renderItems := func(src int) {
+		rows := m.rows(src)
+		renderRows(src, rows, 0, len(rows))
+	}
+
+	// Project plugins, when started inside a repository with a .kodkafa.yml
+	if m.data.ProjectName != "" {
+		b.WriteString(sectionHeaderStyle.Render("Project: "+m.data.ProjectName) + " ")
+		b.WriteString(sectionHeaderStyle.Render(line) + "\n\n")
+		renderItems(sourceProject)
+		if m.data.ProjectPending > 0 {
+			b.WriteString(paginationStyle.Render(fmt.Sprintf("  %d declared plugin(s) not installed, run 'kod project sync'", m.data.ProjectPending)) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	// Top List (Fixed)
+	if top := m.section(sourceTop); len(top) > 0 {
+		title := "Recents"
+		if top[0].Pinned {
+			title = "Pinned & Recents"
+			if top[len(top)-1].Pinned {
+				title = "Pinned"
+			}
 		}
+		b.WriteString(sectionHeaderStyle.Render(title) + " ")
+		b.WriteString(sectionHeaderStyle.Render(line) + "\n\n")
+		renderItems(sourceTop)
 	}
 
 	// Main Inventory
-	mainPlugins := m.filteredMain()
 	b.WriteString("\n")
-	b.WriteString(sectionHeaderStyle.Render("Plugins") + " ")
+	title := "Plugins by " + sortLabel(m.data.SortBy)
+	if m.data.Grouped {
+		title = "Plugins by category, then " + sortLabel(m.data.SortBy)
+	}
+	b.WriteString(sectionHeaderStyle.Render(title) + " ")
 	b.WriteString(sectionHeaderStyle.Render(line) + "\n\n")
-	for i, p := range mainPlugins {
-		style := unselectedItemStyle
-		prefix := "  "
-		if m.listSource == 1 && i == m.cursor {
-			style = selectedItemStyle
-			prefix = "> "
+	if m.data.Grouped {
+		// Every plugin is in one list, so only a window of it is shown
+		rows := m.rows(sourceMain)
+		start := 0
+		if m.listSource == sourceMain {
+			start = max(0, min(m.cursor-groupedVisible/2, len(rows)-groupedVisible))
+		}
+		end := min(start+groupedVisible, len(rows))
+		renderRows(sourceMain, rows, start, end)
+		b.WriteString("\n")
+		if len(rows) > 0 {
+			b.WriteString(paginationStyle.Render(fmt.Sprintf("Rows %d-%d of %d", start+1, end, len(rows))) + "\n")
 		}
-		b.WriteString(style.Render(fmt.Sprintf("%s%-20s %s", prefix, p.Name, p.Description)) + "\n")
+	} else {
+		renderItems(sourceMain)
+		b.WriteString("\n")
+		pageInfo := fmt.Sprintf("Page %d of %d", m.data.CurrentPage, m.data.TotalPages)
+		b.WriteString(paginationStyle.Render(pageInfo) + "\n")
 	}
 
-	b.WriteString("\n")
-	pageInfo := fmt.Sprintf("Page %d of %d", m.data.CurrentPage, m.data.TotalPages)
-	b.WriteString(paginationStyle.Render(pageInfo) + "\n")
-
 	b.WriteString(components.RenderFooter(
 		components.FooterItem{Key: "↑/↓", Label: ""},
 		components.FooterItem{Key: "←/→", Label: ""},
 		components.FooterItem{Key: "S", Label: "Search"},
+		components.FooterItem{Key: "B", Label: "Browse"},
+		components.FooterItem{Key: "O", Label: "Sort"},
+		components.FooterItem{Key: "G", Label: "Group"},
+		components.FooterItem{Key: "P", Label: "Pin"},
 		components.FooterItem{Key: "M", Label: "Menu"},
 		components.FooterItem{Key: "I", Label: "Info"},
 		components.FooterItem{Key: "L", Label: "Load"},
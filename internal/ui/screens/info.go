@@ -61,11 +61,41 @@ func (m *InfoModel) View() string {
 	// Metadata
 	b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Name:"), infoValueStyle.Render(p.Name)))
 	b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Interpreter:"), infoValueStyle.Render(p.Interpreter)))
+	if p.Version != "" {
+		b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Version:"), infoValueStyle.Render(p.Version)))
+	}
 	b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Description:"), infoValueStyle.Render(p.Description)))
+	if p.Category != "" {
+		b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Category:"), infoValueStyle.Render(p.Category)))
+	}
+	if tags := append(append([]string{}, p.Tags...), s.Tags...); len(tags) > 0 {
+		b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Tags:"), infoValueStyle.Render(strings.Join(tags, ", "))))
+	}
 	b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Added:"), infoValueStyle.Render(p.AddedAt.Format("2006-01-02 15:04"))))
 	b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Source:"), infoValueStyle.Render(p.Source)))
+	if in := p.Install; in != nil && in.Linked {
+		b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Linked:"), infoValueStyle.Render("yes - runs from the source directory, edits apply directly")))
+	} else if in != nil {
+		b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Origin:"), infoValueStyle.Render(in.Origin)))
+		if in.Ref != "" {
+			b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Ref:"), infoValueStyle.Render(in.Ref)))
+		}
+		if in.Commit != "" {
+			b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Commit:"), infoValueStyle.Render(in.Commit)))
+		}
+		if in.Subdir != "" {
+			b.WriteString(fmt.Sprintf("%s %s\n", infoLabelStyle.Render("Subdir:"), infoValueStyle.Render(in.Subdir)))
+		}
+	}
 	b.WriteString(fmt.Sprintf("%s %s\n", primaryStyle.Render("Usage:"), secondaryStyle.Render(p.Usage)))
 
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(theme.Accent).Render("PERMISSIONS"))
+	b.WriteString("\n")
+	for _, line := range p.Permissions.Describe() {
+		b.WriteString("  " + line + "\n")
+	}
+
 	b.WriteString("\n")
 	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(theme.Accent).Render("STATS"))
 	b.WriteString("\n")
@@ -84,7 +114,11 @@ func (m *InfoModel) View() string {
 			if h.Status != "completed" {
 				status = "✗"
 			}
-			b.WriteString(fmt.Sprintf("  %s %s (%s)\n", status, h.Args, h.Duration))
+			line := fmt.Sprintf("  %s %s (%s)", status, h.Args, h.Duration)
+			if h.Reason != "" {
+				line += " " + failStyle.Render(h.Reason)
+			}
+			b.WriteString(line + "\n")
 		}
 	}
 
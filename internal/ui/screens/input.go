@@ -2,9 +2,11 @@ package screens
 
 import (
 	"fmt"
+	"kodkafa/internal/app/dto"
 	"kodkafa/internal/app/usecases"
 	"kodkafa/internal/ui/components"
 	"kodkafa/internal/ui/tea"
+	"sync/atomic"
 
 	"kodkafa/internal/ui/theme"
 
@@ -59,13 +61,7 @@ func (m *InputModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
 			if val != "" {
 				if m.mode == tea.InputModePath {
 					m.loading = true
-					return m, func() tea_pkg.Msg {
-						res, err := m.addUC.Execute(usecases.AddPluginInput{Source: val})
-						if err != nil {
-							return tea.ErrMsg{Err: err}
-						}
-						return tea.PluginAddedMsg{PluginName: res.Plugin.Name}
-					}
+					return m, addPlugin(m.addUC, val)
 				} else if m.mode == tea.InputModeName {
 					return m, func() tea_pkg.Msg {
 						// Pass the name. Root Model will know the context from the state machine.
@@ -110,3 +106,45 @@ func (m *InputModel) View() string {
 		components.FooterItem{Key: "Enter", Label: "Run"},
 	)
 }
+
+// addPlugin adds the plugin at source. When its permissions need consent,
+// the add waits while a PluginConsentMsg asks the user, so the plugin is
+// fetched once and the copy shown is the one installed.
+func addPlugin(addUC *usecases.AddPluginUseCase, source string) tea_pkg.Cmd {
+	return func() tea_pkg.Msg {
+		consent := make(chan dto.PluginInfo)
+		answer := make(chan bool)
+		result := make(chan tea_pkg.Msg, 1)
+		go func() {
+			res, err := addUC.Execute(usecases.AddPluginInput{
+				Source: source,
+				Approve: func(plugin dto.PluginInfo) bool {
+					consent <- plugin
+					return <-answer
+				},
+			})
+			switch {
+			case err != nil:
+				result <- tea.ErrMsg{Err: err}
+			case res.NeedsConsent:
+				result <- tea.SwitchStateMsg{State: tea.StateCommandMenu}
+			default:
+				result <- tea.PluginAddedMsg{PluginName: res.Plugin.Name}
+			}
+		}()
+
+		select {
+		case plugin := <-consent:
+			var answered atomic.Bool
+			return tea.PluginConsentMsg{Plugin: plugin, Answer: func(approved bool) tea_pkg.Msg {
+				if !answered.CompareAndSwap(false, true) {
+					return nil // the add already has its answer
+				}
+				answer <- approved
+				return <-result
+			}}
+		case msg := <-result:
+			return msg
+		}
+	}
+}
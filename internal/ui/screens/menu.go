@@ -41,6 +41,11 @@ func NewMenuModel() *MenuModel {
 				Usage: ": Add new plugin",
 				Cmd:   "kod add",
 			},
+			{
+				Label: "kodkafa search [term]",
+				Usage: ": Browse plugin registries",
+				Cmd:   "kod search",
+			},
 			{
 				Label: "kodkafa run <name> (kod r <name>)",
 				Usage: ": Run plugin with memory",
@@ -66,6 +71,11 @@ func NewMenuModel() *MenuModel {
 				Usage: ": View execution logs",
 				Cmd:   "kod log",
 			},
+			{
+				Label: "kodkafa config",
+				Usage: ": View and change settings",
+				Cmd:   "kod config",
+			},
 			{
 				Label: "kodkafa init",
 				Usage: ": Initialize system layout",
@@ -104,6 +114,10 @@ func (m *MenuModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
 				return m, func() tea_pkg.Msg {
 					return tea.SwitchStateMsg{State: tea.StateInput, Mode: tea.InputModePath, Cmd: "kod add"}
 				}
+			case "kod search":
+				return m, func() tea_pkg.Msg {
+					return tea.SwitchStateMsg{State: tea.StateBrowse}
+				}
 			case "kod del":
 				return m, func() tea_pkg.Msg {
 					return tea.SwitchStateMsg{State: tea.StateInput, Mode: tea.InputModeName, Cmd: "kod del"}
@@ -124,6 +138,10 @@ func (m *MenuModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
 				return m, func() tea_pkg.Msg {
 					return tea.SwitchStateMsg{State: tea.StateInput, Mode: tea.InputModeName, Cmd: "kod log"}
 				}
+			case "kod config":
+				return m, func() tea_pkg.Msg {
+					return tea.SwitchStateMsg{State: tea.StateSettings}
+				}
 			case "kod init":
 				return m, func() tea_pkg.Msg {
 					return tea.InitLayoutMsg{}
@@ -104,6 +104,9 @@ func (m *ResultsModel) View() string {
 	b.WriteString(fmt.Sprintf("  Status:      %s\n", status))
 	b.WriteString(fmt.Sprintf("  ExitCode:    %d\n", m.result.ExitCode))
 	b.WriteString(fmt.Sprintf("  Duration:    %v\n", m.result.Duration))
+	if m.result.Reason != "" {
+		b.WriteString(fmt.Sprintf("  Reason:      %s\n", failStyle.Render(m.result.Reason)))
+	}
 	b.WriteString("\n")
 	b.WriteString(lipgloss.NewStyle().Foreground(theme.Muted).Render("OUTPUT (Scroll with ↑/↓)"))
 	b.WriteString("\n")
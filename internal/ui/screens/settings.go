@@ -0,0 +1,213 @@
+package screens
+
+import (
+	"fmt"
+	"kodkafa/internal/app/dto"
+	"kodkafa/internal/app/usecases"
+	"kodkafa/internal/ui/components"
+	"kodkafa/internal/ui/tea"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea_pkg "github.com/charmbracelet/bubbletea"
+)
+
+// settingsVisible is how many settings are listed at once.
+const settingsVisible = 14
+
+// SettingsModel lists the configuration and edits one setting at a time.
+type SettingsModel struct {
+	listUC  *usecases.ListConfigUseCase
+	setUC   *usecases.SetConfigUseCase
+	data    dto.ListConfigResult
+	cursor  int
+	loading bool
+	editing bool
+	input   textinput.Model
+	err     error
+	notice  string
+}
+
+// NewSettingsModel creates a new SettingsModel.
+func NewSettingsModel(listUC *usecases.ListConfigUseCase, setUC *usecases.SetConfigUseCase) *SettingsModel {
+	ti := textinput.New()
+	ti.CharLimit = 1024
+	ti.Width = 50
+	return &SettingsModel{
+		listUC:  listUC,
+		setUC:   setUC,
+		input:   ti,
+		loading: true,
+	}
+}
+
+// Init loads the settings.
+func (m *SettingsModel) Init() tea_pkg.Cmd {
+	return func() tea_pkg.Msg {
+		data, err := m.listUC.Execute()
+		if err != nil {
+			return tea.ErrMsg{Err: err}
+		}
+		return tea.ConfigLoadedMsg{Data: data}
+	}
+}
+
+// Update handles navigation, editing and saving.
+func (m *SettingsModel) Update(msg tea_pkg.Msg) (tea_pkg.Model, tea_pkg.Cmd) {
+	switch msg := msg.(type) {
+	case tea.ConfigLoadedMsg:
+		m.data = msg.Data
+		m.loading = false
+		m.cursor = min(m.cursor, max(len(m.data.Settings)-1, 0))
+		return m, nil
+
+	case tea.ConfigSavedMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+			return m, nil
+		}
+		m.editing = false
+		m.input.Blur()
+		m.notice = fmt.Sprintf("%s = %s", msg.Setting.Key, usecases.FormatConfigValue(msg.Setting.Value))
+		return m, m.Init()
+
+	case tea.ErrMsg:
+		m.err = msg.Err
+		m.loading = false
+		return m, nil
+
+	case tea_pkg.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		if m.editing {
+			switch msg.String() {
+			case "esc":
+				m.editing = false
+				m.err = nil
+				m.input.Blur()
+				return m, nil
+			case "enter":
+				s := m.data.Settings[m.cursor]
+				return m, m.save(usecases.SetConfigInput{Key: s.Key, Value: m.input.Value()})
+			}
+			var cmd tea_pkg.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "esc", "left":
+			return m, func() tea_pkg.Msg {
+				return tea.SwitchStateMsg{State: tea.StateCommandMenu}
+			}
+		case "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down":
+			if m.cursor < len(m.data.Settings)-1 {
+				m.cursor++
+			}
+		case "enter", "right":
+			if m.cursor >= len(m.data.Settings) {
+				return m, nil
+			}
+			s := m.data.Settings[m.cursor]
+			m.err = nil
+			m.notice = ""
+			if v, ok := s.Value.(bool); ok {
+				// Switches toggle in place
+				return m, m.save(usecases.SetConfigInput{Key: s.Key, Value: fmt.Sprint(!v)})
+			}
+			m.editing = true
+			m.input.SetValue(usecases.FormatConfigValue(s.Value))
+			m.input.CursorEnd()
+			m.input.Focus()
+			return m, textinput.Blink
+		case "u":
+			if m.cursor >= len(m.data.Settings) {
+				return m, nil
+			}
+			m.err = nil
+			return m, m.save(usecases.SetConfigInput{Key: m.data.Settings[m.cursor].Key, Op: usecases.ConfigUnset})
+		}
+	}
+	return m, nil
+}
+
+func (m *SettingsModel) save(input usecases.SetConfigInput) tea_pkg.Cmd {
+	return func() tea_pkg.Msg {
+		setting, err := m.setUC.Execute(input)
+		return tea.ConfigSavedMsg{Setting: setting, Err: err}
+	}
+}
+
+// View renders the settings list, or the editor for the selected setting.
+func (m *SettingsModel) View() string {
+	var b strings.Builder
+	b.WriteString(components.RenderHeader("A Persistent CLI with Memory", "SETTINGS"))
+
+	if m.loading {
+		if m.err != nil {
+			b.WriteString(failStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n\n")
+			b.WriteString(components.RenderFooter(components.FooterItem{Key: "←/Esc", Label: "Back"}))
+			return b.String()
+		}
+		b.WriteString("  Loading settings...\n")
+		return b.String()
+	}
+
+	settings := m.data.Settings
+	start := max(0, min(m.cursor-settingsVisible/2, len(settings)-settingsVisible))
+	for i := start; i < len(settings) && i < start+settingsVisible; i++ {
+		s := settings[i]
+		style := unselectedItemStyle
+		prefix := "  "
+		if i == m.cursor {
+			style = selectedItemStyle
+			prefix = "> "
+		}
+		marker := " "
+		if !s.Default {
+			marker = "*"
+		}
+		value := usecases.FormatConfigValue(s.Value)
+		if len(value) > 40 {
+			value = value[:37] + "..."
+		}
+		b.WriteString(style.Render(fmt.Sprintf("%s%s %-32s %s", prefix, marker, s.Key, value)) + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.editing && m.cursor < len(settings) {
+		s := settings[m.cursor]
+		b.WriteString(paginationStyle.Render(fmt.Sprintf("%s (%s)", s.Key, s.Type)) + "\n")
+		b.WriteString("  " + m.input.View() + "\n")
+	}
+	switch {
+	case m.err != nil:
+		b.WriteString(failStyle.Render(fmt.Sprintf("  %v", m.err)) + "\n")
+	case m.notice != "":
+		b.WriteString(successStyle.Render("  Saved: "+m.notice) + "\n")
+	default:
+		b.WriteString(paginationStyle.Render("* changed from the default, in "+m.data.Path) + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.editing {
+		b.WriteString(components.RenderFooter(
+			components.FooterItem{Key: "Enter", Label: "Save"},
+			components.FooterItem{Key: "Esc", Label: "Cancel"},
+		))
+		return b.String()
+	}
+	b.WriteString(components.RenderFooter(
+		components.FooterItem{Key: "↑/↓", Label: ""},
+		components.FooterItem{Key: "Enter", Label: "Edit/Toggle"},
+		components.FooterItem{Key: "U", Label: "Reset"},
+		components.FooterItem{Key: "←/Esc", Label: "Back"},
+	))
+	return b.String()
+}
@@ -2,6 +2,8 @@ package tea
 
 import (
 	"kodkafa/internal/app/dto"
+
+	tea_pkg "github.com/charmbracelet/bubbletea"
 )
 
 // TickMsg is sent for animation ticks
@@ -10,6 +12,36 @@ type TickMsg struct{}
 // PluginsLoadedMsg is sent when the dashboard data is ready
 type PluginsLoadedMsg struct {
 	Data dto.DashboardDTO
+	All  *dto.DashboardDTO // every plugin, read for a search; nil otherwise
+}
+
+// LinkedWatchTickMsg is sent periodically while the dashboard watches linked plugins
+type LinkedWatchTickMsg struct {
+	Gen int
+}
+
+// LinkedRefreshMsg carries dashboard data re-read for linked plugins; unlike
+// PluginsLoadedMsg it keeps the cursor where it is
+type LinkedRefreshMsg struct {
+	Gen  int
+	Data dto.DashboardDTO
+	All  *dto.DashboardDTO
+}
+
+// RegistryLoadedMsg is sent when the registry browse screen has its entries
+type RegistryLoadedMsg struct {
+	Data dto.SearchPluginsResult
+}
+
+// ConfigLoadedMsg is sent when the settings screen has the configuration
+type ConfigLoadedMsg struct {
+	Data dto.ListConfigResult
+}
+
+// ConfigSavedMsg is sent when a setting was changed, or could not be
+type ConfigSavedMsg struct {
+	Setting dto.ConfigSetting
+	Err     error
 }
 
 // PluginSelectedMsg is sent when a plugin is chosen from the dashboard
@@ -23,6 +55,14 @@ type PluginAddedMsg struct {
 	PluginName string
 }
 
+// PluginConsentMsg is sent when a plugin's permissions must be approved before it is added
+type PluginConsentMsg struct {
+	Plugin dto.PluginInfo
+	// Answer replies to the pending add and returns its outcome once the
+	// plugin is installed or dropped.
+	Answer func(approved bool) tea_pkg.Msg
+}
+
 // PluginInfoFetchedMsg is sent when detailed plugin info is loaded
 type PluginInfoFetchedMsg struct {
 	Data dto.PluginInfoResult
@@ -12,6 +12,8 @@ const (
 	StatePostRun
 	StateDeleteConfirm
 	StateDeleteDepsConfirm
+	StateBrowse
+	StateSettings
 )
 
 type InputModeType string